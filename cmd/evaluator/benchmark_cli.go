@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/logging"
+	"gexec-sandbox/internal/manifest"
+	"gexec-sandbox/internal/validation"
+)
+
+// benchmarkRun runs the benchmark in the foreground and writes its report.
+// With a run directory every finished run is logged there as well, so an
+// interrupted benchmark can be resumed.
+func (c cli) benchmarkRun(ctx context.Context, args []string) error {
+	flags := c.flagSet("benchmark run", "")
+	manifestPath := manifestFlag(flags)
+	output := flags.String("output", "", "write the report to `path` instead of stdout")
+	format := formatFlag(flags, "json", "text")
+	skipHealthCheck := flags.Bool("skip-health-check", false, "start without checking that the selected models respond")
+	runDir := flags.String("run-dir", "", "record each finished run to a new run log in `dir`")
+	resume := flags.String("resume", "", "resume the run log in `dir`, skipping the runs it already holds")
+	var selection benchmark.Selection
+	selectionFlags(flags, &selection)
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if err := checkFormat(flags, *format, "json", "text"); err != nil {
+		return err
+	}
+	if err := validation.BenchmarkSelection(selection); err != nil {
+		return err
+	}
+	if *resume != "" && (*runDir != "" || *manifestPath != "" || !selection.IsZero()) {
+		fmt.Fprintln(c.stderr, "evaluator benchmark run: --resume takes the manifest and selection from the run log; it cannot be combined with --run-dir, --manifest or selection flags")
+		return errUsage
+	}
+
+	var runLog *benchmark.RunLog
+	var loaded manifest.Loaded
+	var err error
+	switch {
+	case *resume != "":
+		if runLog, err = benchmark.OpenRunLog(*resume); err != nil {
+			return fmt.Errorf("resume run log: %w", err)
+		}
+		defer runLog.Close()
+		if _, loaded, err = loadBenchmarkManifest(runLog.ManifestPath()); err != nil {
+			return err
+		}
+		selection = runLog.Info().Selection
+	case *runDir != "":
+		var path string
+		if path, loaded, err = loadBenchmarkManifest(*manifestPath); err != nil {
+			return err
+		}
+		snapshot, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info := benchmark.RunLogInfo{RunID: logging.NewID(), Selection: selection, CreatedAt: time.Now().UTC()}
+		if runLog, err = benchmark.CreateRunLog(*runDir, snapshot, info); err != nil {
+			return fmt.Errorf("create run log: %w", err)
+		}
+		defer runLog.Close()
+	default:
+		if _, loaded, err = loadBenchmarkManifest(*manifestPath); err != nil {
+			return err
+		}
+	}
+
+	service, err := c.newBenchmark(loaded)
+	if err != nil {
+		return err
+	}
+	opts := benchmark.RunOptions{Selection: selection}
+	if runLog != nil {
+		opts.RunID, opts.Log = runLog.Info().RunID, runLog
+	}
+
+	if !*skipHealthCheck {
+		healthCtx, cancel := context.WithTimeout(ctx, modelHealthCheckTimeout)
+		err := service.HealthCheckModels(healthCtx, selection)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("benchmark model health check failed: %w", err)
+		}
+	}
+
+	report, err := runBenchmark(ctx, service, opts)
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(*output, func(w io.Writer) error {
+		return writeReport(w, report, *format)
+	})
+}
+
+// rescore grades the runs in a run directory again without calling any model
+// and writes the new report. Tasks come from the run's manifest snapshot
+// unless --manifest names another.
+func (c cli) rescore(ctx context.Context, args []string) error {
+	flags := c.flagSet("rescore", "RUN_DIR")
+	manifestPath := flags.String("manifest", "", "grade against the tasks in the manifest at `path` (default: the run's snapshot)")
+	grader := flags.String("grader", "", "grade with the named `grader`: "+strings.Join(benchmark.GraderNames(), " or ")+" (default: the one benchmark run uses)")
+	output := flags.String("output", "", "write the report to `path` instead of stdout")
+	format := formatFlag(flags, "json", "text")
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+	if err := checkFormat(flags, *format, "json", "text"); err != nil {
+		return err
+	}
+
+	dir := flags.Arg(0)
+	info, runs, err := benchmark.ReadRunLog(dir)
+	if err != nil {
+		return fmt.Errorf("read run log: %w", err)
+	}
+	if *manifestPath == "" {
+		*manifestPath = filepath.Join(dir, benchmark.RunLogManifestFile)
+	}
+	_, loaded, err := loadBenchmarkManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+	service, err := c.newBenchmark(loaded)
+	if err != nil {
+		return err
+	}
+
+	report, err := service.Rescore(ctx, runs, *grader, benchmark.RunOptions{Selection: info.Selection})
+	if err != nil {
+		return fmt.Errorf("rescore %s: %w", dir, err)
+	}
+	report.RescoredFrom = info.RunID
+	return c.writeOutput(*output, func(w io.Writer) error {
+		return writeReport(w, report, *format)
+	})
+}
+
+func runBenchmark(ctx context.Context, service benchmark.BenchmarkServiceAPI, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+	if err := ctx.Err(); err != nil {
+		return benchmark.BenchmarkReport{}, err
+	}
+	report, err := service.Run(ctx, opts)
+	if err != nil {
+		return benchmark.BenchmarkReport{}, fmt.Errorf("benchmark run failed: %w", err)
+	}
+	return report, nil
+}
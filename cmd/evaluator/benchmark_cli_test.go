@@ -1,39 +1,175 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/manifest"
 )
 
-func TestBenchmarkCLIPrintsJSON(t *testing.T) {
-	out, err := runBenchmarkCLI([]string{"benchmark"}, &fakeBenchmarkService{report: benchmark.BenchmarkReport{TotalTasks: 1}})
+// fakeRunner records the selection each call saw.
+type fakeRunner struct {
+	fakeBenchmarkService
+	healthErr    error
+	healthChecks int
+	selection    benchmark.Selection
+	runLog       *benchmark.RunLog
+	runID        string
+	rescored     []benchmark.Run
+	grader       string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+	f.selection = opts.Selection
+	f.runLog = opts.Log
+	f.runID = opts.RunID
+	return f.fakeBenchmarkService.Run(ctx, opts)
+}
+
+func (f *fakeRunner) Rescore(ctx context.Context, runs []benchmark.Run, grader string, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+	f.selection = opts.Selection
+	f.rescored = runs
+	f.grader = grader
+	return benchmark.BenchmarkReport{TotalTasks: 1, Grader: grader + "@1", Runs: runs}, nil
+}
+
+func (f *fakeRunner) HealthCheckModels(ctx context.Context, selection benchmark.Selection) error {
+	f.healthChecks++
+	return f.healthErr
+}
+
+func testCLI(runner *fakeRunner) (cli, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return cli{
+		stdin:  strings.NewReader(""),
+		stdout: &stdout,
+		stderr: &stderr,
+		newBenchmark: func(manifest.Loaded) (benchmarkRunner, error) {
+			return runner, nil
+		},
+	}, &stdout, &stderr
+}
+
+func TestBenchmarkRunWritesReportWithSelection(t *testing.T) {
+	runner := &fakeRunner{fakeBenchmarkService: fakeBenchmarkService{report: benchmark.BenchmarkReport{TotalTasks: 1}}}
+	c, _, _ := testCLI(runner)
+	output := filepath.Join(t.TempDir(), "report.json")
+
+	err := c.run(context.Background(), []string{
+		"benchmark", "run", "--manifest", "../../benchmark.yaml", "--output", output,
+		"--tasks", "csv-*", "--exclude-models", "beta", "--limit", "3", "--seed", "42", "--skip-health-check",
+	})
 	if err != nil {
-		t.Fatalf("runBenchmarkCLI() error = %v", err)
+		t.Fatalf("benchmark run error = %v", err)
 	}
-	if !strings.Contains(out, "\"total_tasks\"") {
-		t.Fatalf("CLI output = %s, want benchmark JSON", out)
+	raw, err := os.ReadFile(output)
+	if err != nil || !strings.Contains(string(raw), `"total_tasks": 1`) {
+		t.Fatalf("report file = %s (%v), want indented report JSON", raw, err)
+	}
+	selection := runner.selection
+	if strings.Join(selection.Tasks.Include, ",") != "csv-*" || selection.Models.Exclude[0] != "beta" || selection.Limit != 3 || *selection.Seed != 42 {
+		t.Fatalf("selection = %+v, want the flags' selection", selection)
+	}
+	if runner.healthChecks != 0 {
+		t.Fatalf("health checks = %d, want none with --skip-health-check", runner.healthChecks)
 	}
 }
 
-func TestBenchmarkCLIRespectsContextCancellation(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	cancel()
+func TestBareBenchmarkCommandHealthChecksBeforeRunning(t *testing.T) {
+	runner := &fakeRunner{healthErr: errors.New("model down")}
+	c, stdout, _ := testCLI(runner)
 
-	service := &fakeBenchmarkService{report: benchmark.BenchmarkReport{TotalTasks: 1}}
+	err := c.run(context.Background(), []string{"benchmark", "--manifest", "../../benchmark.yaml", "--format", "text"})
+	if err == nil || !strings.Contains(err.Error(), "model down") {
+		t.Fatalf("benchmark error = %v, want health check failure", err)
+	}
+	if runner.calls.Load() != 0 || stdout.Len() != 0 {
+		t.Fatalf("run calls = %d, output = %q; want no run after a failed health check", runner.calls.Load(), stdout.String())
+	}
+}
+
+func TestBenchmarkRunRecordsAndResumesRunLog(t *testing.T) {
+	runner := &fakeRunner{}
+	c, _, _ := testCLI(runner)
+	dir := filepath.Join(t.TempDir(), "run")
+
+	err := c.run(context.Background(), []string{"benchmark", "run", "--manifest", "../../benchmark.yaml", "--run-dir", dir, "--tasks", "csv-*", "--skip-health-check"})
+	if err != nil {
+		t.Fatalf("benchmark run error = %v", err)
+	}
+	if runner.runLog == nil || runner.runLog.Dir() != dir {
+		t.Fatalf("run log = %v, want one in %s", runner.runLog, dir)
+	}
+	snapshot, _ := os.ReadFile(filepath.Join(dir, benchmark.RunLogManifestFile))
+	original, _ := os.ReadFile("../../benchmark.yaml")
+	if len(snapshot) == 0 || !bytes.Equal(snapshot, original) {
+		t.Fatal("manifest snapshot does not match the manifest")
+	}
+	if err := c.run(context.Background(), []string{"benchmark", "run", "--manifest", "../../benchmark.yaml", "--run-dir", dir, "--skip-health-check"}); !errors.Is(err, benchmark.ErrRunLogExists) {
+		t.Fatalf("second --run-dir error = %v, want ErrRunLogExists", err)
+	}
+
+	runID := runner.runLog.Info().RunID
+	runner.selection = benchmark.Selection{}
+	if err := c.run(context.Background(), []string{"benchmark", "run", "--resume", dir, "--skip-health-check"}); err != nil {
+		t.Fatalf("benchmark run --resume error = %v", err)
+	}
+	if got := runner.runID; got == "" || got != runID || strings.Join(runner.selection.Tasks.Include, ",") != "csv-*" {
+		t.Fatalf("resumed run %q with selection %+v, want run %q with the logged selection", got, runner.selection, runID)
+	}
+	if err := c.run(context.Background(), []string{"benchmark", "run", "--resume", dir, "--tasks", "a"}); !errors.Is(err, errUsage) {
+		t.Fatalf("--resume with selection flags error = %v, want usage error", err)
+	}
+}
+
+func TestRescoreGradesRunLogAgainstItsSnapshot(t *testing.T) {
+	runner := &fakeRunner{}
+	c, stdout, _ := testCLI(runner)
+	dir := t.TempDir()
+	snapshot, _ := os.ReadFile("../../benchmark.yaml")
+	log, err := benchmark.CreateRunLog(dir, snapshot, benchmark.RunLogInfo{RunID: "r1", Selection: benchmark.Selection{Limit: 1}})
+	if err != nil {
+		t.Fatalf("CreateRunLog() error = %v", err)
+	}
+	log.Append(benchmark.Run{ModelID: "m", TaskID: "a", Code: "print('ok')"})
+	log.Close()
 
-	out, err := runBenchmarkCLIWithContext(ctx, []string{"benchmark"}, service)
-	if err == nil {
-		t.Fatal("runBenchmarkCLIWithContext() error = nil, want context cancellation")
+	if err := c.run(context.Background(), []string{"rescore", "--grader", "default", "--format", "text", dir}); err != nil {
+		t.Fatalf("rescore error = %v", err)
+	}
+	if runner.grader != "default" || len(runner.rescored) != 1 || runner.rescored[0].Code != "print('ok')" || runner.selection.Limit != 1 {
+		t.Fatalf("rescored %+v with grader %q and selection %+v, want the logged run and selection", runner.rescored, runner.grader, runner.selection)
+	}
+	if out := stdout.String(); !strings.Contains(out, "Rescored from  r1") || !strings.Contains(out, "Grader         default@1") {
+		t.Fatalf("report =\n%s\nwant the source run and grader", out)
 	}
+	if err := c.run(context.Background(), []string{"rescore", filepath.Join(dir, "missing")}); err == nil {
+		t.Fatal("rescore of a missing run log error = nil")
+	}
+}
 
-	if out != "" {
-		t.Fatalf("CLI output = %q, want empty output on cancellation", out)
+func TestBenchmarkRunRejectsInvalidSelection(t *testing.T) {
+	c, _, _ := testCLI(&fakeRunner{})
+	if err := c.run(context.Background(), []string{"benchmark", "run", "--families", "data_["}); err == nil {
+		t.Fatal("benchmark run error = nil, want invalid glob error")
 	}
+}
 
-	if service.calls != 0 {
-		t.Fatalf("service calls = %d, want 0", service.calls)
+func TestRunBenchmarkRespectsContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	service := &fakeBenchmarkService{report: benchmark.BenchmarkReport{TotalTasks: 1}}
+	if _, err := runBenchmark(ctx, service, benchmark.RunOptions{}); err == nil {
+		t.Fatal("runBenchmark() error = nil, want context cancellation")
+	}
+	if service.calls.Load() != 0 {
+		t.Fatalf("service calls = %d, want 0", service.calls.Load())
 	}
 }
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/manifest"
+	"gexec-sandbox/internal/validation"
+)
+
+// errUsage reports a command line the evaluator could not parse. The flag
+// set has already printed what was wrong.
+var errUsage = errors.New("invalid usage")
+
+// exitError ends the process with code, such as an executed program's exit
+// code, without printing anything.
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// benchmarkRunner is the part of BenchmarkService the benchmark and rescore
+// commands use.
+type benchmarkRunner interface {
+	benchmark.BenchmarkServiceAPI
+	benchmark.Rescorer
+	HealthCheckModels(ctx context.Context, selection benchmark.Selection) error
+}
+
+// cli runs evaluator subcommands. Results go to stdout; usage and program
+// stderr go to stderr.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// newBenchmark builds the benchmark service for a manifest; tests replace
+	// it to avoid real models.
+	newBenchmark func(manifest.Loaded) (benchmarkRunner, error)
+	// executor runs `exec` programs; nil uses the Docker sandbox.
+	executor benchmark.Executor
+}
+
+func newCLI() cli {
+	return cli{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		newBenchmark: func(loaded manifest.Loaded) (benchmarkRunner, error) {
+			return newBenchmarkService(loaded)
+		},
+	}
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(c cli, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"serve", "Serve the HTTP and gRPC APIs (the default)", cli.serve},
+	{"benchmark run", "Run the benchmark and write its report", cli.benchmarkRun},
+	{"rescore", "Grade a run log's stored runs again and write a new report", cli.rescore},
+	{"validate", "Check the benchmark manifest", cli.validate},
+	{"list tasks", "List the manifest's tasks", cli.listTasks},
+	{"list scaffolds", "List the manifest's scaffolds", cli.listScaffolds},
+	{"list models", "List the manifest's enabled models", cli.listModels},
+	{"exec", "Run a local source file in the sandbox", cli.exec},
+	{"report show", "Summarize a saved benchmark report", cli.reportShow},
+	{"report diff", "Compare two saved benchmark reports", cli.reportDiff},
+}
+
+// run dispatches args to a subcommand. Without one the evaluator serves, and
+// a bare "benchmark" means "benchmark run".
+func (c cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help" {
+		return c.serve(ctx, args)
+	}
+	switch args[0] {
+	case "help", "-h", "--help":
+		c.usage()
+		return nil
+	case "benchmark":
+		if len(args) == 1 || args[1] != "run" {
+			return c.benchmarkRun(ctx, args[1:])
+		}
+	}
+
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && slices.Equal(args[:len(words)], words) {
+			return cmd.run(c, ctx, args[len(words):])
+		}
+	}
+	fmt.Fprintf(c.stderr, "evaluator: unknown command %q\n\n", strings.Join(args[:min(len(args), 2)], " "))
+	c.usage()
+	return errUsage
+}
+
+func (c cli) usage() {
+	fmt.Fprintln(c.stderr, "Usage: evaluator <command> [flags]")
+	fmt.Fprintln(c.stderr)
+	tw := tabwriter.NewWriter(c.stderr, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, `Run "evaluator <command> -h" for a command's flags.`)
+}
+
+// flagSet returns a flag set for the named command whose usage line shows
+// args after the flags.
+func (c cli) flagSet(name string, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: evaluator %s [flags] %s\n\nFlags:\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses args, wanting between min and max positional arguments (max
+// below zero means unlimited).
+func parse(flags *flag.FlagSet, args []string, min int, max int) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if n := flags.NArg(); n < min || max >= 0 && n > max {
+		fmt.Fprintf(flags.Output(), "evaluator %s: wrong number of arguments\n", flags.Name())
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func manifestFlag(flags *flag.FlagSet) *string {
+	return flags.String("manifest", "", "benchmark manifest `path` (default: $EVALUATOR_MANIFEST, then ./benchmark.yaml)")
+}
+
+func formatFlag(flags *flag.FlagSet, formats ...string) *string {
+	return flags.String("format", formats[0], "output format: "+strings.Join(formats, " or "))
+}
+
+func checkFormat(flags *flag.FlagSet, format string, formats ...string) error {
+	if slices.Contains(formats, format) {
+		return nil
+	}
+	fmt.Fprintf(flags.Output(), "evaluator %s: unknown format %q\n", flags.Name(), format)
+	return errUsage
+}
+
+// selectionFlags registers the benchmark selection flags, filling selection
+// as they are parsed.
+func selectionFlags(flags *flag.FlagSet, selection *benchmark.Selection) {
+	selectors := []struct {
+		name     string
+		selector *benchmark.Selector
+	}{
+		{"tasks", &selection.Tasks},
+		{"families", &selection.Families},
+		{"tags", &selection.Tags},
+		{"languages", &selection.Languages},
+		{"scaffolds", &selection.Scaffolds},
+		{"models", &selection.Models},
+	}
+	for _, s := range selectors {
+		flags.Var((*patternList)(&s.selector.Include), s.name, "comma-separated `globs` of "+s.name+" to run")
+		flags.Var((*patternList)(&s.selector.Exclude), "exclude-"+s.name, "comma-separated `globs` of "+s.name+" to skip")
+	}
+	flags.IntVar(&selection.Limit, "limit", 0, "run at most `n` of the selected tasks")
+	flags.Func("seed", "sample --limit tasks at random with this `seed`", func(value string) error {
+		var seed uint64
+		if _, err := fmt.Sscan(value, &seed); err != nil {
+			return fmt.Errorf("seed must be a non-negative integer")
+		}
+		selection.Seed = &seed
+		return nil
+	})
+}
+
+// patternList collects comma-separated glob patterns from a repeatable flag.
+type patternList []string
+
+func (p *patternList) String() string {
+	if p == nil {
+		return ""
+	}
+	return strings.Join(*p, ",")
+}
+
+func (p *patternList) Set(value string) error {
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			*p = append(*p, pattern)
+		}
+	}
+	return nil
+}
+
+// writeOutput writes to the file at path, or to stdout when path is empty
+// or "-".
+func (c cli) writeOutput(path string, write func(io.Writer) error) error {
+	if path == "" || path == "-" {
+		return write(c.stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func writeJSONTo(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (c cli) validate(_ context.Context, args []string) error {
+	flags := c.flagSet("validate", "")
+	manifestPath := manifestFlag(flags)
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	path, loaded, err := loadBenchmarkManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "%s: ok (%d tasks, %d scaffolds, %d enabled models)\n",
+		path, len(loaded.Tasks.Tasks), len(loaded.Scaffolds.Scaffolds), len(loaded.Models))
+	return nil
+}
+
+// listFlags parses a list command's flags and loads the manifest.
+func (c cli) listFlags(name string, args []string) (manifest.Loaded, benchmark.Selection, string, error) {
+	flags := c.flagSet("list "+name, "")
+	manifestPath := manifestFlag(flags)
+	format := formatFlag(flags, "text", "json")
+	var selection benchmark.Selection
+	selectionFlags(flags, &selection)
+	if err := parse(flags, args, 0, 0); err != nil {
+		return manifest.Loaded{}, selection, "", err
+	}
+	if err := checkFormat(flags, *format, "text", "json"); err != nil {
+		return manifest.Loaded{}, selection, "", err
+	}
+	if err := validation.BenchmarkSelection(selection); err != nil {
+		return manifest.Loaded{}, selection, "", err
+	}
+	_, loaded, err := loadBenchmarkManifest(*manifestPath)
+	return loaded, selection, *format, err
+}
+
+func (c cli) listTasks(_ context.Context, args []string) error {
+	loaded, selection, format, err := c.listFlags("tasks", args)
+	if err != nil {
+		return err
+	}
+	tasks := selection.SelectTasks(loaded.Tasks).Tasks
+	if format == "json" {
+		return writeJSONTo(c.stdout, tasks)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tFAMILY\tLANGUAGE\tTAGS\tTITLE")
+	for _, task := range tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", task.ID, task.TaskFamily, task.Language, strings.Join(task.Tags, ","), task.Title)
+	}
+	return tw.Flush()
+}
+
+func (c cli) listScaffolds(_ context.Context, args []string) error {
+	loaded, selection, format, err := c.listFlags("scaffolds", args)
+	if err != nil {
+		return err
+	}
+	scaffolds := selection.SelectScaffolds(loaded.Scaffolds).Scaffolds
+	if format == "json" {
+		return writeJSONTo(c.stdout, scaffolds)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tBASELINE\tTOOLS\tDESCRIPTION")
+	for _, scaffold := range scaffolds {
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%s\n", scaffold.Name, scaffold.Baseline, strings.Join(scaffold.Tools, ","), scaffold.Description)
+	}
+	return tw.Flush()
+}
+
+// listedModel is how `list models` reports a model, leaving out credentials
+// and request mappings.
+type listedModel struct {
+	ID        string `json:"id"`
+	Provider  string `json:"provider"`
+	Kind      string `json:"kind"`
+	ModelName string `json:"model_name"`
+}
+
+func (c cli) listModels(_ context.Context, args []string) error {
+	loaded, selection, format, err := c.listFlags("models", args)
+	if err != nil {
+		return err
+	}
+	models := []listedModel{}
+	for _, model := range loaded.Models {
+		if selection.Models.Match(model.ID) {
+			models = append(models, listedModel{ID: model.ID, Provider: model.ProviderID, Kind: model.ProviderKind, ModelName: model.ModelName})
+		}
+	}
+	if format == "json" {
+		return writeJSONTo(c.stdout, models)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tPROVIDER\tKIND\tMODEL")
+	for _, model := range models {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", model.ID, model.Provider, model.Kind, model.ModelName)
+	}
+	return tw.Flush()
+}
+
+// exec runs a local file in the sandbox with the manifest's runtime limits.
+// In text format the program's output and exit code become the evaluator's.
+func (c cli) exec(ctx context.Context, args []string) error {
+	flags := c.flagSet("exec", "FILE [ARG...]")
+	manifestPath := manifestFlag(flags)
+	format := formatFlag(flags, "text", "json")
+	language := flags.String("language", "", "source `language` (default: from the file extension)")
+	stdinPath := flags.String("stdin", "", "`file` to feed the program on stdin; - reads the evaluator's stdin")
+	timeoutMS := flags.Int("timeout-ms", 0, "execution timeout in `milliseconds` (default: the manifest's)")
+	if err := parse(flags, args, 1, -1); err != nil {
+		return err
+	}
+	if err := checkFormat(flags, *format, "text", "json"); err != nil {
+		return err
+	}
+
+	_, loaded, err := loadBenchmarkManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+	cfg := loaded.Runtime
+
+	file := flags.Arg(0)
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	req := api.ExecutionRequest{
+		Language:   *language,
+		SourceCode: string(source),
+		TimeoutMS:  *timeoutMS,
+		Args:       flags.Args()[1:],
+	}
+	if req.Language == "" {
+		req.Language = strings.TrimPrefix(filepath.Ext(file), ".")
+		if _, ok := cfg.Languages[req.Language]; !ok {
+			fmt.Fprintf(c.stderr, "evaluator exec: cannot infer the language of %s; pass --language\n", file)
+			return errUsage
+		}
+	}
+	switch *stdinPath {
+	case "":
+	case "-":
+		stdin, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+		req.Stdin = string(stdin)
+	default:
+		stdin, err := os.ReadFile(*stdinPath)
+		if err != nil {
+			return err
+		}
+		req.Stdin = string(stdin)
+	}
+	if err := validation.ExecutionRequest(req, cfg); err != nil {
+		return err
+	}
+	if req.TimeoutMS == 0 {
+		req.TimeoutMS = cfg.DefaultTimeoutMS
+	}
+
+	executor := c.executor
+	if executor == nil {
+		executor = benchmark.NewCodeExecutionAdapter()
+	}
+	resp, err := executor.Execute(ctx, req, cfg)
+	if err != nil {
+		return fmt.Errorf("execute %s: %w", file, err)
+	}
+	if *format == "json" {
+		return writeJSONTo(c.stdout, resp)
+	}
+
+	io.WriteString(c.stdout, resp.Stdout)
+	io.WriteString(c.stderr, resp.Stderr)
+	switch {
+	case resp.ExitCode != 0:
+		return exitError{code: resp.ExitCode}
+	case resp.Error != "":
+		fmt.Fprintf(c.stderr, "evaluator exec: %s\n", resp.Error)
+		return exitError{code: 1}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+)
+
+const testManifest = "../../benchmark.yaml"
+
+func TestCLIValidatesAndListsTheManifest(t *testing.T) {
+	c, stdout, _ := testCLI(&fakeRunner{})
+	if err := c.run(context.Background(), []string{"validate", "--manifest", testManifest}); err != nil {
+		t.Fatalf("validate error = %v", err)
+	}
+	if got := stdout.String(); !strings.HasPrefix(got, testManifest+": ok (5 tasks") {
+		t.Fatalf("validate output = %q, want ok with counts", got)
+	}
+
+	stdout.Reset()
+	if err := c.run(context.Background(), []string{"list", "tasks", "--manifest", testManifest, "--tags", "csv", "--format", "json"}); err != nil {
+		t.Fatalf("list tasks error = %v", err)
+	}
+	var tasks []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &tasks); err != nil || len(tasks) != 1 || tasks[0].ID != "monthly-revenue-rollup" {
+		t.Fatalf("list tasks --tags csv = %s (%v), want only the CSV task", stdout.String(), err)
+	}
+
+	stdout.Reset()
+	if err := c.run(context.Background(), []string{"list", "scaffolds", "--manifest", testManifest}); err != nil {
+		t.Fatalf("list scaffolds error = %v", err)
+	}
+	if got := stdout.String(); !strings.HasPrefix(got, "NAME") || !strings.Contains(got, "baseline") {
+		t.Fatalf("list scaffolds = %q, want a table including the baseline", got)
+	}
+}
+
+func TestCLIValidateReportsManifestErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "benchmark.yaml")
+	if err := os.WriteFile(path, []byte("schema_version: 99\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	c, _, _ := testCLI(&fakeRunner{})
+	if err := c.run(context.Background(), []string{"validate", "--manifest", path}); err == nil || !strings.Contains(err.Error(), path) {
+		t.Fatalf("validate error = %v, want error naming the manifest", err)
+	}
+}
+
+func TestCLIUsageErrors(t *testing.T) {
+	c, _, stderr := testCLI(&fakeRunner{})
+	if err := c.run(context.Background(), []string{"help"}); err != nil || !strings.Contains(stderr.String(), "report diff") {
+		t.Fatalf("help = %v, %q; want the command list", err, stderr.String())
+	}
+	if err := c.run(context.Background(), []string{"frobnicate"}); !errors.Is(err, errUsage) {
+		t.Fatalf("unknown command error = %v, want errUsage", err)
+	}
+	if err := c.run(context.Background(), []string{"report", "diff", "only-one.json"}); !errors.Is(err, errUsage) {
+		t.Fatalf("report diff with one file error = %v, want errUsage", err)
+	}
+	if err := c.run(context.Background(), []string{"list", "tasks", "--format", "yaml"}); !errors.Is(err, errUsage) {
+		t.Fatalf("unknown format error = %v, want errUsage", err)
+	}
+}
+
+// echoExecutor answers each program with its stdin as stdout.
+type echoExecutor struct {
+	mu   sync.Mutex
+	reqs []api.ExecutionRequest
+}
+
+func (e *echoExecutor) Execute(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	e.mu.Lock()
+	e.reqs = append(e.reqs, req)
+	e.mu.Unlock()
+	return api.ExecutionResponse{Stdout: req.Stdin, Stderr: "warning\n", ExitCode: 3}, nil
+}
+
+func TestCLIExecRunsLocalFileInSandbox(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.py")
+	if err := os.WriteFile(source, []byte("print(input())"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	executor := &echoExecutor{}
+	c, stdout, stderr := testCLI(&fakeRunner{})
+	c.executor = executor
+	c.stdin = strings.NewReader("hello\n")
+
+	err := c.run(context.Background(), []string{"exec", "--manifest", testManifest, "--stdin", "-", source, "--verbose"})
+	var exit exitError
+	if !errors.As(err, &exit) || exit.code != 3 {
+		t.Fatalf("exec error = %v, want the program's exit code 3", err)
+	}
+	if stdout.String() != "hello\n" || stderr.String() != "warning\n" {
+		t.Fatalf("exec output = %q / %q, want program stdout and stderr", stdout.String(), stderr.String())
+	}
+	req := executor.reqs[0]
+	if req.Language != "py" || req.TimeoutMS == 0 || len(req.Args) != 1 || req.Args[0] != "--verbose" {
+		t.Fatalf("request = %+v, want inferred language, default timeout and program args", req)
+	}
+
+	unknown := filepath.Join(dir, "main.rb")
+	os.WriteFile(unknown, []byte("puts 1"), 0o600)
+	if err := c.run(context.Background(), []string{"exec", "--manifest", testManifest, unknown}); !errors.Is(err, errUsage) {
+		t.Fatalf("exec of unknown extension error = %v, want errUsage", err)
+	}
+}
@@ -2,116 +2,185 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"maps"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
-	"runtime"
 	"syscall"
 	"time"
 
-	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
 	"gexec-sandbox/internal/benchmark"
 	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/health"
 	"gexec-sandbox/internal/httpapi"
 	"gexec-sandbox/internal/llm"
+	"gexec-sandbox/internal/logging"
 	"gexec-sandbox/internal/manifest"
-	"gexec-sandbox/internal/metrics"
 	"gexec-sandbox/internal/middleware"
 	"gexec-sandbox/internal/modeladapter"
 	"gexec-sandbox/internal/sandbox"
+	"gexec-sandbox/internal/tracing"
 	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
 )
 
 const modelHealthCheckTimeout = 15 * time.Second
 
-func executeHandler(cfg config.Config) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		metrics.IncrementRequest()
-
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			metrics.IncrementError()
-			return
-		}
+// server holds what buildMux wires into routes.
+type server struct {
+	Config    config.Config
+	HTTP      config.Server
+	Benchmark benchmark.BenchmarkServiceAPI
+	// Keys authenticates requests; nil leaves the API open.
+	Keys *auth.Keyring
+	// Health backs /readyz; nil reports ready without checking anything.
+	Health *health.Checker
+	// Drain gates new work; nil gets a fresh one that accepts work.
+	Drain *middleware.Drain
+	// Runs tracks background benchmark runs; nil gets one over Benchmark.
+	Runs *benchmark.RunManager
+	// RateLimits are shared with the gRPC API; nil builds them from HTTP.
+	RateLimits map[string]*middleware.RateLimiter
+}
 
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, "Failed to read request body", http.StatusBadRequest)
-			metrics.IncrementError()
-			return
+// newRateLimiters builds a limiter for each route with a configured rate
+// limit, keyed by route.
+func newRateLimiters(cfg config.Server, keys *auth.Keyring) (map[string]*middleware.RateLimiter, error) {
+	documented := map[string]bool{}
+	for _, op := range httpapi.Operations {
+		documented[op.Path] = true
+	}
+	for path := range cfg.RateLimits {
+		if !documented[path] {
+			return nil, fmt.Errorf("rate limit configured for unknown endpoint %q", path)
 		}
+	}
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
-		var req api.ExecutionRequest
-		if err := json.Unmarshal(body, &req); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
-			metrics.IncrementError()
-			return
+	limiters := map[string]*middleware.RateLimiter{}
+	for path, limit := range cfg.RateLimits {
+		if limit.RequestsPerMinute <= 0 {
+			continue
 		}
+		limiters[path] = middleware.NewRateLimiter(middleware.Options{
+			Rate:           rate.Limit(limit.RequestsPerMinute / 60),
+			Burst:          limit.Burst,
+			TrustedProxies: trustedProxies,
+			Identify:       keys.IdentifyRequest,
+		})
+	}
+	return limiters, nil
+}
 
-		if req.SourceCode == "" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(api.ExecutionResponse{
-				Error: "source_code cannot be empty",
-			})
-			metrics.IncrementError()
-			return
-		}
+func buildMux(srv server) (*http.ServeMux, error) {
+	mux := http.NewServeMux()
+	if srv.Drain == nil {
+		srv.Drain = &middleware.Drain{}
+	}
+	if srv.Runs == nil {
+		srv.Runs = benchmark.NewRunManager(srv.Benchmark)
+	}
 
-		if req.TimeoutMS == 0 {
-			req.TimeoutMS = cfg.DefaultTimeoutMS
-		}
+	routes := map[string]http.Handler{
+		"/execute": httpapi.ExecuteHandler{
+			Config:   srv.Config,
+			Executor: benchmark.NewCodeExecutionAdapter(),
+		},
+		"/benchmark/run":               httpapi.BenchmarkRunHandler{Runs: srv.Runs},
+		"/benchmark/runs/{id}":         httpapi.BenchmarkRunStatusHandler{Runs: srv.Runs},
+		"/benchmark/runs/{id}/events":  httpapi.BenchmarkRunEventsHandler{Runs: srv.Runs},
+		"/benchmark/runs/{id}/rescore": httpapi.BenchmarkRunRescoreHandler{Runs: srv.Runs},
+		"/ping":                        http.HandlerFunc(httpapi.PingHandler),
+		"/healthz":                     http.HandlerFunc(httpapi.HealthzHandler),
+		"/readyz":                      httpapi.ReadinessHandler{Checker: srv.Health},
+		"/metrics":                     http.HandlerFunc(httpapi.MetricsHandler),
+		"/metrics/prometheus":          http.HandlerFunc(httpapi.PrometheusHandler),
+		"/openapi.json":                http.HandlerFunc(httpapi.OpenAPIHandler),
+		// Operations sharing a path share its handler, which switches on
+		// the method.
+		"/admin/executions":      httpapi.AdminExecutionsHandler{Drain: srv.Drain},
+		"/admin/executions/{id}": http.HandlerFunc(httpapi.AdminKillHandler),
+		"/admin/queue":           httpapi.AdminQueueHandler{Config: srv.Config},
+		"/admin/drain":           httpapi.AdminDrainHandler{Drain: srv.Drain},
+	}
+	// Unversioned aliases: the original paths for existing clients, and the
+	// probe paths orchestrators expect at the root.
+	legacy := map[string]bool{"/execute": true, "/benchmark/run": true, "/ping": true, "/metrics": true, "/healthz": true, "/readyz": true}
 
-		response, err := sandbox.RunCodeInSandbox(r.Context(), req, cfg)
+	if srv.RateLimits == nil {
+		limiters, err := newRateLimiters(srv.HTTP, srv.Keys)
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(api.ExecutionResponse{
-				Error: err.Error(),
-			})
-			metrics.IncrementError()
-			return
+			return nil, err
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+		srv.RateLimits = limiters
 	}
-}
 
-func buildMux(cfg config.Config, benchmarkService benchmark.BenchmarkServiceAPI) *http.ServeMux {
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
+	registered := map[string]bool{}
+	for _, op := range httpapi.Operations {
+		handler, ok := routes[op.Path]
+		if !ok {
+			return nil, fmt.Errorf("no handler for documented endpoint %q", op.Path)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
-	})
-
-	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
+		if registered[op.Path] {
+			continue
+		}
+		registered[op.Path] = true
+		if op.StartsWork {
+			handler = srv.Drain.Middleware(handler)
+		}
+		if srv.HTTP.MaxBodyBytes > 0 {
+			handler = http.MaxBytesHandler(handler, srv.HTTP.MaxBodyBytes)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(metrics.GetMetrics())
-	})
+		handler = srv.Keys.Require(op.Scope)(handler)
+		if limiter := srv.RateLimits[op.Path]; limiter != nil {
+			// The limiter runs before authentication so that requests with
+			// a missing or wrong key are limited too: by key when the
+			// request presents a known one and by address otherwise.
+			handler = limiter.Middleware(handler)
+		}
+		handler = middleware.RequestID(middleware.Instrument(op.Path, handler))
+		mux.Handle(httpapi.APIVersionPrefix+op.Path, handler)
+		if legacy[op.Path] {
+			mux.Handle(op.Path, handler)
+		}
+	}
+	mux.Handle(httpapi.APIVersionPrefix+"/", middleware.RequestID(http.HandlerFunc(httpapi.NotFoundHandler)))
 
-	mux.Handle("/benchmark/run", httpapi.BenchmarkRunHandler{
-		Service: benchmarkService,
-	})
+	return mux, nil
+}
 
-	mux.Handle("/execute", middleware.RateLimitMiddleware(rate.Every(6*time.Second), 10)(http.HandlerFunc(executeHandler(cfg))))
+// Readiness checks are cached for these periods so frequent probes do not
+// hammer the daemon or model providers.
+const (
+	dockerCheckTTL = 5 * time.Second
+	imageCheckTTL  = time.Minute
+	modelCheckTTL  = 30 * time.Second
+)
 
-	return mux
+// newHealthChecker checks Docker, which executions cannot run without, plus
+// the language images and every model, which only degrade the service.
+func newHealthChecker(cfg config.Config, models []benchmark.ModelClient) *health.Checker {
+	checks := []health.Check{
+		{Name: "docker", Critical: true, TTL: dockerCheckTTL, Run: sandbox.PingDocker},
+		{Name: "images", TTL: imageCheckTTL, Run: func(ctx context.Context) error {
+			return sandbox.CheckImages(ctx, cfg)
+		}},
+	}
+	for _, model := range models {
+		if model.HealthCheck == nil {
+			continue
+		}
+		checks = append(checks, health.Check{Name: "model:" + model.ID, TTL: modelCheckTTL, Run: model.HealthCheck})
+	}
+	return health.NewChecker(checks...)
 }
 
 func newBenchmarkService(loaded manifest.Loaded) (benchmark.BenchmarkService, error) {
@@ -125,6 +194,7 @@ func newBenchmarkService(loaded manifest.Loaded) (benchmark.BenchmarkService, er
 		if err != nil {
 			return benchmark.BenchmarkService{}, fmt.Errorf("create adapter for model %q: %w", modelConfig.ID, err)
 		}
+		adapter = modeladapter.Instrument(modelConfig.ID, adapter)
 		client, err := llm.NewClientWithAdapter(adapter)
 		if err != nil {
 			return benchmark.BenchmarkService{}, fmt.Errorf("create benchmark llm client for model %q: %w", modelConfig.ID, err)
@@ -133,151 +203,96 @@ func newBenchmarkService(loaded manifest.Loaded) (benchmark.BenchmarkService, er
 			ID:          modelConfig.ID,
 			Client:      client,
 			HealthCheck: adapter.HealthCheck,
+			Concurrency: modelConfig.Concurrency,
 		})
 	}
 
+	executor := benchmark.NewCodeExecutionAdapter()
 	return benchmark.BenchmarkService{
 		Tasks:             loaded.Tasks,
 		Scaffolds:         loaded.Scaffolds,
 		Models:            models,
-		Executor:          benchmark.NewCodeExecutionAdapter(),
-		Grader:            benchmark.DefaultGrader{},
+		Executor:          executor,
+		Grader:            benchmark.NewCheckerGrader(executor, loaded.Runtime),
 		Config:            loaded.Runtime,
 		DefaultModelRoles: maps.Clone(loaded.DefaultModelRoles),
 	}, nil
 }
 
-func loadBenchmarkManifest() (manifest.Loaded, error) {
-	path, err := repoDataPath("benchmark.yaml")
+// loadBenchmarkManifest loads the manifest at path, falling back to the one
+// manifest.Resolve finds, and returns the path it read.
+func loadBenchmarkManifest(path string) (string, manifest.Loaded, error) {
+	path, err := manifest.Resolve(path)
 	if err != nil {
-		return manifest.Loaded{}, err
+		return "", manifest.Loaded{}, err
 	}
 
 	loaded, err := manifest.Load(path)
 	if err != nil {
-		return manifest.Loaded{}, fmt.Errorf("load benchmark manifest: %w", err)
+		return path, manifest.Loaded{}, fmt.Errorf("load benchmark manifest %s: %w", path, err)
 	}
 
-	return loaded, nil
+	return path, loaded, nil
 }
 
-func loadBenchmarkCatalogs() (benchmark.TaskCatalog, benchmark.ScaffoldCatalog, error) {
-	tasksPath, err := benchmarkDataPath("tasks.json")
-	if err != nil {
-		return benchmark.TaskCatalog{}, benchmark.ScaffoldCatalog{}, err
-	}
-
-	scaffoldsPath, err := benchmarkDataPath("scaffolds.json")
+func main() {
+	logConfig, err := logging.ConfigFromEnv()
 	if err != nil {
-		return benchmark.TaskCatalog{}, benchmark.ScaffoldCatalog{}, err
+		fmt.Fprintf(os.Stderr, "invalid logging configuration: %v\n", err)
+		os.Exit(2)
 	}
+	logging.Setup(logConfig)
 
-	tasks, err := benchmark.LoadTaskCatalog(tasksPath)
+	traceConfig, err := tracing.ConfigFromEnv()
 	if err != nil {
-		return benchmark.TaskCatalog{}, benchmark.ScaffoldCatalog{}, fmt.Errorf("load benchmark tasks: %w", err)
+		fatal("invalid tracing configuration", err)
 	}
-
-	scaffolds, err := benchmark.LoadScaffoldCatalog(scaffoldsPath)
+	shutdownTracing, err := tracing.Setup(context.Background(), traceConfig)
 	if err != nil {
-		return benchmark.TaskCatalog{}, benchmark.ScaffoldCatalog{}, fmt.Errorf("load benchmark scaffolds: %w", err)
+		fatal("failed to configure tracing", err)
 	}
 
-	return tasks, scaffolds, nil
-}
-
-func benchmarkDataPath(name string) (string, error) {
-	return repoDataPath(filepath.Join("data", name))
-}
-
-func repoDataPath(name string) (string, error) {
-	_, currentFile, _, ok := runtime.Caller(0)
-	if !ok {
-		return "", fmt.Errorf("resolve evaluator source path")
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err = newCLI().run(ctx, os.Args[1:])
+	stop()
 
-	repoRoot := filepath.Clean(filepath.Join(filepath.Dir(currentFile), "..", ".."))
-	return filepath.Join(repoRoot, name), nil
-}
-
-func runBenchmarkCLI(args []string, service benchmark.BenchmarkServiceAPI) (string, error) {
-	return runBenchmarkCLIWithContext(context.Background(), args, service)
-}
-
-func runBenchmarkCLIWithContext(ctx context.Context, args []string, service benchmark.BenchmarkServiceAPI) (string, error) {
-	if len(args) == 0 || args[0] != "benchmark" {
-		return "", fmt.Errorf("unsupported command")
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Warn("failed to flush traces", "error", err)
 	}
-
-	if err := ctx.Err(); err != nil {
-		return "", err
+	cancel()
+
+	var exit exitError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &exit):
+		os.Exit(exit.code)
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "evaluator: %v\n", err)
+		os.Exit(1)
 	}
-
-	report, err := service.Run(ctx)
-	if err != nil {
-		return "", err
-	}
-
-	raw, err := json.Marshal(report)
-	if err != nil {
-		return "", err
-	}
-
-	return string(raw), nil
 }
 
-func main() {
-	loaded, err := loadBenchmarkManifest()
-	if err != nil {
-		log.Fatalf("Failed to load benchmark manifest: %v", err)
-	}
-	cfg := loaded.Runtime
-	rootCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
-	benchmarkService, err := newBenchmarkService(loaded)
-	if err != nil {
-		log.Fatalf("Failed to initialize benchmark service: %v", err)
-	}
-	healthCtx, cancelHealthCheck := context.WithTimeout(rootCtx, modelHealthCheckTimeout)
-	if err := benchmarkService.HealthCheckModels(healthCtx); err != nil {
-		cancelHealthCheck()
-		log.Fatalf("Failed benchmark model health check: %v", err)
-	}
-	cancelHealthCheck()
-	log.Printf("Initialized %d benchmark model adapter(s)", len(benchmarkService.Models))
-
-	if len(os.Args) > 1 && os.Args[1] == "benchmark" {
-		output, err := runBenchmarkCLIWithContext(rootCtx, os.Args[1:], benchmarkService)
-		if err != nil {
-			log.Fatalf("Failed to run benchmark CLI: %v", err)
-		}
-		fmt.Println(output)
-		return
-	}
-
-	server := &http.Server{
-		Addr:    ":8080",
-		Handler: buildMux(cfg, benchmarkService),
-	}
-
+// stopGRPC lets in-flight calls finish, cutting them off once ctx is done. It
+// reports whether the server stopped gracefully.
+func stopGRPC(ctx context.Context, s *grpc.Server) bool {
+	stopped := make(chan struct{})
 	go func() {
-		log.Println("Server starting on :8080")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
-		}
+		s.GracefulStop()
+		close(stopped)
 	}()
-
-	<-rootCtx.Done()
-
-	log.Println("Shutting down server...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
-		sandbox.CleanupAllContainers()
+	select {
+	case <-stopped:
+		return true
+	case <-ctx.Done():
+		s.Stop()
+		return false
 	}
+}
 
-	log.Println("Server exited")
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
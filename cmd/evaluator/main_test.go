@@ -9,40 +9,240 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
 	"gexec-sandbox/internal/benchmark"
 	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/health"
+	"gexec-sandbox/internal/httpapi"
 	"gexec-sandbox/internal/manifest"
 	"gexec-sandbox/internal/modeladapter"
 )
 
-func TestBuildMuxRegistersBenchmarkRunRoute(t *testing.T) {
+func TestBuildMuxRegistersBenchmarkRunRoutes(t *testing.T) {
 	service := &fakeBenchmarkService{
 		report: benchmark.BenchmarkReport{TotalTasks: 1},
 	}
+	runs := benchmark.NewRunManager(service)
+	mux := mustBuildMux(t, server{Benchmark: service, Runs: runs})
 
-	req := httptest.NewRequest(http.MethodPost, "/benchmark/run", strings.NewReader(`{}`))
 	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/benchmark/run", strings.NewReader(`{}`)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", rr.Code)
+	}
+	var started benchmark.RunStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &started); err != nil {
+		t.Fatalf("decode run status: %v", err)
+	}
+	if _, err := runs.Wait(context.Background(), started.ID); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if got := service.calls.Load(); got != 1 {
+		t.Fatalf("service calls = %d, want 1", got)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/benchmark/runs/"+started.ID, nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "\"total_tasks\":1") {
+		t.Fatalf("GET run = %d %s, want benchmark report JSON", rr.Code, rr.Body.String())
+	}
+}
+
+func TestBuildMuxServesVersionedRoutesAndJSONNotFound(t *testing.T) {
+	mux := mustBuildMux(t, server{Benchmark: &fakeBenchmarkService{}})
+
+	for _, op := range httpapi.Operations {
+		if op.Method != http.MethodGet || strings.Contains(op.Path, "{") {
+			continue
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(op.Method, httpapi.APIVersionPrefix+op.Path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200", op.Path, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/benchmark/run", nil))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("POST /v1/benchmark/run status = %d, want 202", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/unknown", nil))
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), `"code":"not_found"`) {
+		t.Fatalf("GET /v1/unknown = %d %s, want JSON not_found envelope", rr.Code, rr.Body.String())
+	}
+}
+
+func TestBuildMuxEnforcesAPIKeyScopes(t *testing.T) {
+	keys, err := auth.NewKeyring([]auth.Key{
+		{ID: "runner", Hash: auth.HashKey("runner-secret"), Scopes: []string{auth.ScopeExecute}},
+		{ID: "bench", Hash: auth.HashKey("bench-secret"), Scopes: []string{auth.ScopeBenchmark}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	mux := mustBuildMux(t, server{Config: config.Config{}, Benchmark: &fakeBenchmarkService{}, Keys: keys})
+
+	tests := []struct {
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{http.MethodGet, "/v1/ping", "", http.StatusOK},
+		{http.MethodGet, "/v1/openapi.json", "", http.StatusOK},
+		{http.MethodPost, "/v1/benchmark/run", "", http.StatusUnauthorized},
+		{http.MethodPost, "/v1/benchmark/run", "runner-secret", http.StatusForbidden},
+		{http.MethodPost, "/v1/benchmark/run", "bench-secret", http.StatusAccepted},
+		{http.MethodPost, "/benchmark/run", "bench-secret", http.StatusAccepted},
+		{http.MethodGet, "/v1/benchmark/runs/unknown", "runner-secret", http.StatusForbidden},
+		{http.MethodGet, "/v1/metrics", "bench-secret", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != tt.want {
+			t.Fatalf("%s %s with key %q status = %d, want %d", tt.method, tt.path, tt.key, rr.Code, tt.want)
+		}
+	}
+}
+
+func TestBuildMuxAppliesConfiguredRateLimits(t *testing.T) {
+	mux := mustBuildMux(t, server{
+		Benchmark: &fakeBenchmarkService{},
+		HTTP: config.Server{
+			RateLimits:     map[string]config.RateLimit{"/benchmark/run": {RequestsPerMinute: 1, Burst: 1}},
+			TrustedProxies: []string{"10.0.0.0/8"},
+		},
+	})
+
+	statuses := []int{}
+	for _, client := range []string{"198.51.100.1", "198.51.100.1", "198.51.100.2"} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/benchmark/run", nil)
+		req.RemoteAddr = "10.1.2.3:4000"
+		req.Header.Set("X-Forwarded-For", client)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		statuses = append(statuses, rr.Code)
+	}
+	if statuses[0] != http.StatusAccepted || statuses[1] != http.StatusTooManyRequests || statuses[2] != http.StatusAccepted {
+		t.Fatalf("statuses = %v, want per-client limit behind the trusted proxy", statuses)
+	}
+
+	_, err := buildMux(server{HTTP: config.Server{RateLimits: map[string]config.RateLimit{"/nope": {RequestsPerMinute: 1, Burst: 1}}}})
+	if err == nil || !strings.Contains(err.Error(), "/nope") {
+		t.Fatalf("buildMux() error = %v, want unknown endpoint error", err)
+	}
+}
+
+func TestBuildMuxRecordsRequestsInPrometheusExposition(t *testing.T) {
+	mux := mustBuildMux(t, server{Benchmark: &fakeBenchmarkService{}})
 
-	buildMux(config.Config{}, service).ServeHTTP(rr, req)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/ping", nil))
 
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/metrics/prometheus", nil))
 	if rr.Code != http.StatusOK {
 		t.Fatalf("status = %d, want 200", rr.Code)
 	}
+	if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type = %q, want Prometheus text format", got)
+	}
+	if want := `evaluator_http_requests_total{endpoint="/ping",status="200"}`; !strings.Contains(rr.Body.String(), want) {
+		t.Fatalf("body missing %s\n%s", want, rr.Body.String())
+	}
+}
+
+func TestBuildMuxServesProbesAtRootAndReportsUnavailableDependencies(t *testing.T) {
+	checker := health.NewChecker(
+		health.Check{Name: "docker", Critical: true, Run: func(context.Context) error { return errors.New("daemon down") }},
+	)
+	mux := mustBuildMux(t, server{Benchmark: &fakeBenchmarkService{}, Health: checker})
 
-	if service.calls != 1 {
-		t.Fatalf("service calls = %d, want 1", service.calls)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /healthz status = %d, want 200", rr.Code)
 	}
 
-	if !strings.Contains(rr.Body.String(), "\"total_tasks\":1") {
-		t.Fatalf("body = %s, want benchmark report JSON", rr.Body.String())
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /readyz status = %d, want 503", rr.Code)
+	}
+	var report api.ReadinessResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode readiness: %v", err)
+	}
+	if report.Status != api.ReadinessUnavailable || len(report.Checks) != 1 || report.Checks[0].Error != "daemon down" {
+		t.Fatalf("readiness = %+v, want docker failure", report)
+	}
+}
+
+func TestBuildMuxDrainRefusesNewWorkUntilResumed(t *testing.T) {
+	mux := mustBuildMux(t, server{Benchmark: &fakeBenchmarkService{}})
+	serve := func(method string, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(`{}`)))
+		return rr
+	}
+
+	if rr := serve(http.MethodPost, "/v1/admin/drain"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"draining":true`) {
+		t.Fatalf("POST /v1/admin/drain = %d %s, want draining", rr.Code, rr.Body.String())
+	}
+	for _, path := range []string{"/v1/benchmark/run", "/execute"} {
+		if rr := serve(http.MethodPost, path); rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), `"code":"draining"`) {
+			t.Fatalf("POST %s while draining = %d %s, want 503 draining", path, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := serve(http.MethodGet, "/v1/admin/executions"); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"draining":true`) {
+		t.Fatalf("GET /v1/admin/executions = %d %s, want draining listing", rr.Code, rr.Body.String())
+	}
+	if rr := serve(http.MethodGet, "/v1/benchmark/runs/unknown"); rr.Code != http.StatusNotFound {
+		t.Fatalf("GET benchmark run while draining = %d, want 404 rather than 503", rr.Code)
+	}
+
+	serve(http.MethodDelete, "/v1/admin/drain")
+	if rr := serve(http.MethodPost, "/v1/benchmark/run"); rr.Code != http.StatusAccepted {
+		t.Fatalf("POST /v1/benchmark/run after resume = %d, want 202", rr.Code)
+	}
+	if rr := serve(http.MethodDelete, "/v1/admin/executions/unknown"); rr.Code != http.StatusNotFound {
+		t.Fatalf("DELETE unknown execution = %d, want 404", rr.Code)
+	}
+}
+
+func TestNewHealthCheckerChecksEveryModel(t *testing.T) {
+	checker := newHealthChecker(config.Config{}, []benchmark.ModelClient{
+		{ID: "alpha", HealthCheck: func(context.Context) error { return nil }},
+		{ID: "beta", HealthCheck: func(context.Context) error { return errors.New("unreachable") }},
+	})
+
+	names := map[string]api.DependencyStatus{}
+	for _, check := range checker.Check(context.Background()).Checks {
+		names[check.Name] = check
+	}
+	if names["model:alpha"].Status != api.DependencyOK || names["model:beta"].Status != api.DependencyFailed || names["model:beta"].Critical {
+		t.Fatalf("checks = %+v, want alpha ok and beta failed but not critical", names)
+	}
+	if !names["docker"].Critical {
+		t.Fatalf("docker check = %+v, want critical", names["docker"])
 	}
 }
 
 func TestLoadBenchmarkManifestUsesReusableFixtureFiles(t *testing.T) {
-	loaded, err := loadBenchmarkManifest()
-	if err != nil {
+	t.Setenv(manifest.PathEnv, testManifest)
+	path, loaded, err := loadBenchmarkManifest("")
+	if err != nil || path != testManifest {
 		t.Fatalf("loadBenchmarkManifest() error = %v", err)
 	}
 	tasks := loaded.Tasks
@@ -67,11 +267,7 @@ func TestLoadBenchmarkManifestUsesReusableFixtureFiles(t *testing.T) {
 		t.Fatalf("baseline scaffold count = %d, want 1", baselineCount)
 	}
 
-	problemsPath, err := benchmarkDataPath("problems.json")
-	if err != nil {
-		t.Fatalf("benchmarkProblemsPath() error = %v", err)
-	}
-
+	problemsPath := filepath.Join("..", "..", "data", "problems.json")
 	rawProblems, err := os.ReadFile(problemsPath)
 	if err != nil {
 		t.Fatalf("ReadFile(%q) error = %v", problemsPath, err)
@@ -176,12 +372,21 @@ func TestNewBenchmarkServiceConstructsBeforeSeparateModelHealthCheck(t *testing.
 	}
 }
 
+func mustBuildMux(t *testing.T, srv server) *http.ServeMux {
+	t.Helper()
+	mux, err := buildMux(srv)
+	if err != nil {
+		t.Fatalf("buildMux() error = %v", err)
+	}
+	return mux
+}
+
 type fakeBenchmarkService struct {
 	report benchmark.BenchmarkReport
-	calls  int
+	calls  atomic.Int32
 }
 
-func (f *fakeBenchmarkService) Run(ctx context.Context) (benchmark.BenchmarkReport, error) {
-	f.calls++
+func (f *fakeBenchmarkService) Run(ctx context.Context, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+	f.calls.Add(1)
 	return f.report, nil
 }
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"gexec-sandbox/internal/benchmark"
+)
+
+func (c cli) reportShow(_ context.Context, args []string) error {
+	flags := c.flagSet("report show", "REPORT")
+	output := flags.String("output", "", "write to `path` instead of stdout")
+	format := formatFlag(flags, "text", "json")
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+	if err := checkFormat(flags, *format, "text", "json"); err != nil {
+		return err
+	}
+
+	report, err := readReport(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(*output, func(w io.Writer) error {
+		return writeReport(w, report, *format)
+	})
+}
+
+func (c cli) reportDiff(_ context.Context, args []string) error {
+	flags := c.flagSet("report diff", "BEFORE AFTER")
+	output := flags.String("output", "", "write to `path` instead of stdout")
+	format := formatFlag(flags, "text", "json")
+	if err := parse(flags, args, 2, 2); err != nil {
+		return err
+	}
+	if err := checkFormat(flags, *format, "text", "json"); err != nil {
+		return err
+	}
+
+	before, err := readReport(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := readReport(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	diff := benchmark.DiffReports(before, after)
+	return c.writeOutput(*output, func(w io.Writer) error {
+		if *format == "json" {
+			return writeJSONTo(w, diff)
+		}
+		return writeReportDiffText(w, diff)
+	})
+}
+
+// readReport reads a report written by `benchmark run`, or the status of a
+// finished run fetched from the HTTP API.
+func readReport(path string) (benchmark.BenchmarkReport, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return benchmark.BenchmarkReport{}, err
+	}
+	var status struct {
+		State  string                     `json:"state"`
+		Report *benchmark.BenchmarkReport `json:"report"`
+	}
+	if err := json.Unmarshal(raw, &status); err == nil && status.State != "" {
+		if status.Report == nil {
+			return benchmark.BenchmarkReport{}, fmt.Errorf("%s: run is %s and has no report", path, status.State)
+		}
+		return *status.Report, nil
+	}
+
+	var report benchmark.BenchmarkReport
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return benchmark.BenchmarkReport{}, fmt.Errorf("%s: not a benchmark report: %w", path, err)
+	}
+	return report, nil
+}
+
+func writeReport(w io.Writer, report benchmark.BenchmarkReport, format string) error {
+	if format == "json" {
+		return writeJSONTo(w, report)
+	}
+	return writeReportText(w, report)
+}
+
+func percent(rate float64) string {
+	return fmt.Sprintf("%.1f%%", rate*100)
+}
+
+func points(delta float64) string {
+	return fmt.Sprintf("%+.1f pts", delta*100)
+}
+
+func writeReportText(w io.Writer, report benchmark.BenchmarkReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if report.RunID != "" {
+		fmt.Fprintf(tw, "Run\t%s\n", report.RunID)
+	}
+	if report.RescoredFrom != "" {
+		fmt.Fprintf(tw, "Rescored from\t%s\n", report.RescoredFrom)
+	}
+	if report.Grader != "" {
+		fmt.Fprintf(tw, "Grader\t%s\n", report.Grader)
+	}
+	fmt.Fprintf(tw, "Tasks\t%d\n", report.TotalTasks)
+	fmt.Fprintf(tw, "Runs\t%d\n", len(report.Runs))
+	stats := report.Statistics
+	interval := func(name string, format func(float64) string) string {
+		if stats == nil {
+			return ""
+		}
+		ci := stats.Intervals[name]
+		return fmt.Sprintf(" [%g%% CI %s to %s]", 100*(1-stats.Alpha), format(ci.Low), format(ci.High))
+	}
+	fmt.Fprintf(tw, "Baseline\t%s%s\n", percent(report.BaselineSuccessRate), interval("baseline_success_rate", percent))
+	if report.ScaffoldedScaffold == "" {
+		// A baseline-only selection has nothing to compare with.
+		fmt.Fprintf(tw, "Mean score\t%.3f baseline\n", report.BaselineMeanScore)
+	} else {
+		fmt.Fprintf(tw, "Scaffolded\t%s (%s)%s\n", percent(report.ScaffoldedSuccessRate), report.ScaffoldedScaffold, interval("scaffolded_success_rate", percent))
+		fmt.Fprintf(tw, "Lift\t%s%s", points(report.Lift), interval("lift", points))
+		if stats != nil && !stats.LiftSignificant {
+			fmt.Fprintf(tw, ", not significant at alpha %g", stats.Alpha)
+		}
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "Mean score\t%.3f baseline, %.3f scaffolded (%+.3f)\n", report.BaselineMeanScore, report.ScaffoldedMeanScore, report.ScoreLift)
+	}
+
+	section := func(title string, names []string, row func(string) (float64, float64, float64)) {
+		if len(names) == 0 {
+			return
+		}
+		fmt.Fprintf(tw, "\n%s\tBASELINE\tSCAFFOLDED\tLIFT\n", title)
+		for _, name := range names {
+			baseline, scaffolded, lift := row(name)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, percent(baseline), percent(scaffolded), points(lift))
+		}
+	}
+	section("MODEL", slices.Sorted(maps.Keys(report.ByModel)), func(name string) (float64, float64, float64) {
+		s := report.ByModel[name]
+		return s.BaselineSuccessRate, s.ScaffoldedSuccessRate, s.Lift
+	})
+	section("FAMILY", slices.Sorted(maps.Keys(report.ByFamily)), func(name string) (float64, float64, float64) {
+		s := report.ByFamily[name]
+		return s.BaselineSuccessRate, s.ScaffoldedSuccessRate, s.Lift
+	})
+	section("SCAFFOLD", slices.Sorted(maps.Keys(report.ByScaffold)), func(name string) (float64, float64, float64) {
+		s := report.ByScaffold[name]
+		return s.BaselineSuccessRate, s.ScaffoldedSuccessRate, s.Lift
+	})
+
+	if stats != nil && len(stats.Comparisons) > 0 {
+		fmt.Fprintln(tw, "\nMODEL\tSCAFFOLD\tFIXED\tBROKEN\tMCNEMAR P\tBOOTSTRAP P\tSIGNIFICANT")
+		for _, c := range stats.Comparisons {
+			model := c.ModelID
+			if model == "" {
+				model = "(all)"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.3f\t%.3f\t%t\n", model, c.Scaffold, c.Fixed, c.Broken, c.McNemarP, c.BootstrapP, c.Significant)
+		}
+	}
+
+	if sampling := report.Sampling; sampling != nil && sampling.Epochs > 1 {
+		fmt.Fprintf(tw, "\nMODEL\tSCAFFOLD\tMEAN SCORE")
+		for _, k := range sampling.K {
+			fmt.Fprintf(tw, "\tPASS@%d", k)
+		}
+		fmt.Fprintln(tw)
+		for _, group := range sampling.Groups {
+			fmt.Fprintf(tw, "%s\t%s\t%.3f", group.ModelID, group.Scaffold, group.MeanScore)
+			for _, k := range sampling.K {
+				fmt.Fprintf(tw, "\t%s", percent(group.PassAtK[k]))
+			}
+			fmt.Fprintln(tw)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeReportDiffText(w io.Writer, diff benchmark.ReportDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if diff.Before != "" || diff.After != "" {
+		fmt.Fprintf(tw, "Runs\t%s -> %s\n\n", diff.Before, diff.After)
+	}
+	fmt.Fprintln(tw, "METRIC\tBEFORE\tAFTER\tDELTA")
+	for _, metric := range diff.Metrics {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", metric.Name, percent(metric.Before), percent(metric.After), points(metric.Delta))
+	}
+	if len(diff.Changes) > 0 {
+		fmt.Fprintln(tw, "\nMODEL\tTASK\tSCAFFOLD\tCHANGE")
+		for _, change := range diff.Changes {
+			scaffold := change.Scaffold
+			if change.Epoch > 0 {
+				scaffold += fmt.Sprintf(" (epoch %d)", change.Epoch)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", change.ModelID, change.TaskID, scaffold, change.Change)
+		}
+	}
+	return tw.Flush()
+}
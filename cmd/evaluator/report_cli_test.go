@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gexec-sandbox/internal/benchmark"
+)
+
+func writeReportFile(t *testing.T, name string, v any) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestReportShowSummarizesReportOrRunStatus(t *testing.T) {
+	report := benchmark.BenchmarkReport{
+		RunID:               "run-1",
+		TotalTasks:          2,
+		BaselineSuccessRate: 0.5,
+		ScaffoldedScaffold:  "tool-assisted",
+		Lift:                0.25,
+		ByFamily:            map[string]benchmark.FamilySummary{"finance_workflows": {BaselineSuccessRate: 1}},
+		Statistics: &benchmark.StatisticsReport{
+			Alpha:       0.05,
+			Intervals:   map[string]benchmark.Interval{"lift": {Estimate: 0.25, Low: -0.1, High: 0.6}},
+			Comparisons: []benchmark.ScaffoldComparison{{Scaffold: "tool-assisted", Fixed: 2, Broken: 1, McNemarP: 1}},
+		},
+		Sampling: &benchmark.SamplingReport{Epochs: 3, K: []int{1, 3}, Groups: []benchmark.SampleSummary{
+			{ModelID: "m", Scaffold: "baseline", MeanScore: 0.5, PassAtK: map[int]float64{1: 0.5, 3: 1}},
+		}},
+	}
+	// A status fetched from GET /v1/benchmark/runs/{id} wraps the report.
+	path := writeReportFile(t, "status.json", benchmark.RunStatus{ID: "run-1", State: benchmark.RunStateSucceeded, Report: &report})
+
+	c, stdout, _ := testCLI(&fakeRunner{})
+	if err := c.run(context.Background(), []string{"report", "show", path}); err != nil {
+		t.Fatalf("report show error = %v", err)
+	}
+	out := stdout.String()
+	for _, want := range []string{"run-1", "50.0%", "+25.0 pts [95% CI -10.0 pts to +60.0 pts], not significant at alpha 0.05", "(all)  tool-assisted  2      1       1.000", "finance_workflows  100.0%", "PASS@3", "0.500       50.0%   100.0%"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("report show output missing %q:\n%s", want, out)
+		}
+	}
+
+	failed := writeReportFile(t, "failed.json", benchmark.RunStatus{ID: "run-2", State: benchmark.RunStateFailed})
+	if err := c.run(context.Background(), []string{"report", "show", failed}); err == nil {
+		t.Fatal("report show of a failed run error = nil, want missing report error")
+	}
+}
+
+func TestReportDiffListsChangedRuns(t *testing.T) {
+	baseline := benchmark.Scaffold{Baseline: true, Name: "baseline"}
+	before := writeReportFile(t, "before.json", benchmark.BenchmarkReport{
+		BaselineSuccessRate: 0,
+		Runs:                []benchmark.Run{{ModelID: "m", TaskID: "a", Scaffold: baseline}},
+	})
+	after := writeReportFile(t, "after.json", benchmark.BenchmarkReport{
+		BaselineSuccessRate: 1,
+		Runs:                []benchmark.Run{{ModelID: "m", TaskID: "a", Scaffold: baseline, Passed: true}},
+	})
+
+	c, stdout, _ := testCLI(&fakeRunner{})
+	if err := c.run(context.Background(), []string{"report", "diff", before, after}); err != nil {
+		t.Fatalf("report diff error = %v", err)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "+100.0 pts") || !strings.Contains(out, "fixed") {
+		t.Fatalf("report diff output = %s, want baseline delta and the fixed run", out)
+	}
+
+	stdout.Reset()
+	if err := c.run(context.Background(), []string{"report", "diff", "--format", "json", before, after}); err != nil {
+		t.Fatalf("report diff --format json error = %v", err)
+	}
+	var diff benchmark.ReportDiff
+	if err := json.Unmarshal(stdout.Bytes(), &diff); err != nil || len(diff.Changes) != 1 {
+		t.Fatalf("report diff JSON = %s (%v), want one change", stdout.String(), err)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/grpcapi"
+	"gexec-sandbox/internal/middleware"
+	"gexec-sandbox/internal/sandbox"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// serve runs the HTTP API, and the gRPC API when an address is configured,
+// until ctx is done.
+func (c cli) serve(ctx context.Context, args []string) error {
+	flags := c.flagSet("serve", "")
+	manifestPath := manifestFlag(flags)
+	addr := flags.String("addr", "", "HTTP listen `address` (default: the manifest's server.addr, or :8080)")
+	grpcAddr := flags.String("grpc-addr", "", "gRPC listen `address` (default: the manifest's server.grpc_addr)")
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	path, loaded, err := loadBenchmarkManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+	cfg := loaded.Runtime
+	if *addr != "" {
+		loaded.Server.Addr = *addr
+	}
+	if *grpcAddr != "" {
+		loaded.Server.GRPCAddr = *grpcAddr
+	}
+
+	benchmarkService, err := newBenchmarkService(loaded)
+	if err != nil {
+		return fmt.Errorf("initialize benchmark service: %w", err)
+	}
+	slog.Info("initialized benchmark model adapters", "count", len(benchmarkService.Models))
+
+	keys, err := auth.LoadFromEnv()
+	if err != nil {
+		return fmt.Errorf("load API keys: %w", err)
+	}
+	if keys == nil {
+		slog.Warn("no API keys configured; the API is unauthenticated", "file_env", auth.KeysFileEnv, "env", auth.KeysEnv)
+	} else {
+		slog.Info("loaded API keys", "count", keys.Len())
+	}
+
+	// The server starts even when dependencies are down; /readyz reports
+	// what is missing until they recover.
+	checker := newHealthChecker(cfg, benchmarkService.Models)
+	healthCtx, cancelHealthCheck := context.WithTimeout(ctx, modelHealthCheckTimeout)
+	readiness := checker.Check(healthCtx)
+	cancelHealthCheck()
+	for _, check := range readiness.Checks {
+		if check.Status != api.DependencyOK {
+			slog.Warn("dependency unavailable", "dependency", check.Name, "critical", check.Critical, "error", check.Error)
+		}
+	}
+	if readiness.Status != api.ReadinessReady {
+		slog.Warn("starting in degraded mode", "status", readiness.Status)
+	}
+
+	drain := &middleware.Drain{}
+	runs := benchmark.NewRunManager(benchmarkService)
+	runs.MaxActive = loaded.Server.MaxActiveRuns
+	if runs.RunsDir = loaded.Server.RunsDir; runs.RunsDir != "" {
+		if runs.Manifest, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("snapshot manifest: %w", err)
+		}
+	}
+	limiters, err := newRateLimiters(loaded.Server, keys)
+	if err != nil {
+		return fmt.Errorf("configure rate limits: %w", err)
+	}
+	mux, err := buildMux(server{Config: cfg, HTTP: loaded.Server, Benchmark: benchmarkService, Keys: keys, Health: checker, Drain: drain, Runs: runs, RateLimits: limiters})
+	if err != nil {
+		return fmt.Errorf("configure routes: %w", err)
+	}
+	httpServer := newHTTPServer(loaded.Server, mux)
+	var grpcOptions []grpc.ServerOption
+	if loaded.Server.TLS() {
+		creds, err := credentials.NewServerTLSFromFile(loaded.Server.TLSCertFile, loaded.Server.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("load TLS certificate: %w", err)
+		}
+		grpcOptions = append(grpcOptions, grpc.Creds(creds))
+	}
+
+	failed := make(chan error, 2)
+	go func() {
+		slog.Info("server starting", "addr", httpServer.Addr, "tls", loaded.Server.TLS())
+		var err error
+		if loaded.Server.TLS() {
+			err = httpServer.ListenAndServeTLS(loaded.Server.TLSCertFile, loaded.Server.TLSKeyFile)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			failed <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+
+	var grpcServer *grpc.Server
+	if addr := loaded.Server.GRPCAddr; addr != "" {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("listen for gRPC: %w", err)
+		}
+		grpcServer = grpcapi.New(&grpcapi.Server{
+			Config:     cfg,
+			Executor:   benchmark.NewCodeExecutionAdapter(),
+			Runs:       runs,
+			Keys:       keys,
+			Drain:      drain,
+			RateLimits: limiters,
+		}, grpcOptions...)
+		go func() {
+			slog.Info("grpc server starting", "addr", listener.Addr().String())
+			if err := grpcServer.Serve(listener); err != nil {
+				failed <- fmt.Errorf("grpc server: %w", err)
+			}
+		}()
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-failed:
+	}
+
+	slog.Info("shutting down server", "grace", loaded.Server.ShutdownGrace)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), loaded.Server.ShutdownGrace)
+	defer cancel()
+
+	// New work is refused from here on. Benchmark runs are cancelled before
+	// the listeners close so their event streams end with them.
+	drain.Start()
+	forced := false
+	if err := runs.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("benchmark runs did not stop in time", "error", err)
+		forced = true
+	}
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("server forced to shut down", "error", err)
+		forced = true
+	}
+	if grpcServer != nil && !stopGRPC(shutdownCtx, grpcServer) {
+		slog.Warn("grpc server forced to shut down", "error", shutdownCtx.Err())
+		forced = true
+	}
+	if forced {
+		sandbox.CleanupAllContainers()
+	}
+
+	slog.Info("server exited")
+	return serveErr
+}
+
+func newHTTPServer(cfg config.Server, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes carried in ErrorResponse. Clients should branch on the code, not
+// the message.
+const (
+	ErrorCodeInvalidJSON      = "invalid_json"
+	ErrorCodeInvalidRequest   = "invalid_request"
+	ErrorCodeValidation       = "validation_failed"
+	ErrorCodeTooLarge         = "request_too_large"
+	ErrorCodeMethodNotAllowed = "method_not_allowed"
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeUnauthorized     = "unauthorized"
+	ErrorCodeForbidden        = "forbidden"
+	ErrorCodeRateLimited      = "rate_limited"
+	ErrorCodeQuotaExceeded    = "quota_exceeded"
+	ErrorCodeExecutionTimeout = "execution_timeout"
+	ErrorCodeExecutionFailed  = "execution_failed"
+	ErrorCodeExecutionKilled  = "execution_killed"
+	ErrorCodeDraining         = "draining"
+	ErrorCodeBenchmarkFailed  = "benchmark_failed"
+	ErrorCodeRunNotSucceeded  = "run_not_succeeded"
+	ErrorCodeNotRescorable    = "run_not_rescorable"
+	ErrorCodeTooManyRuns      = "too_many_runs"
+	ErrorCodeUnavailable      = "unavailable"
+	ErrorCodeInternal         = "internal_error"
+)
+
+// ErrorResponse is the body of every non-2xx response from the HTTP API.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Fields lists every invalid field of a validation_failed request.
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError names a request field by its JSON path, such as "files.a.txt",
+// and says what is wrong with it.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// WriteError writes the ErrorResponse envelope with status.
+func WriteError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: ErrorDetail{Code: code, Message: message}})
+}
@@ -1,10 +1,30 @@
 package api
 
+import "time"
+
+// ExecutionModeTest runs the language's test runner against the source and
+// companion files instead of running the program.
+const ExecutionModeTest = "test"
+
+const (
+	TestReportGoJSON   = "go_test_json"
+	TestReportJUnitXML = "junit_xml"
+)
+
 type ExecutionRequest struct {
-	Language   string `json:"language"`
-	SourceCode string `json:"source_code"`
-	Stdin      string `json:"stdin"`
-	TimeoutMS  int    `json:"timeout_ms"`
+	Language   string            `json:"language"`
+	SourceCode string            `json:"source_code"`
+	Stdin      string            `json:"stdin,omitempty"`
+	TimeoutMS  int               `json:"timeout_ms,omitempty"`
+	Files      map[string]string `json:"files,omitempty"`
+	Args       []string          `json:"args,omitempty"`
+	Mode       string            `json:"mode,omitempty"`
+	// CaptureFileChanges reports the paths the program added, changed or
+	// deleted in its container.
+	CaptureFileChanges bool `json:"capture_file_changes,omitempty"`
+	// Audit traces network, process and file syscalls. The language image
+	// must have strace installed.
+	Audit bool `json:"audit,omitempty"`
 }
 
 type ExecutionResponse struct {
@@ -12,4 +32,141 @@ type ExecutionResponse struct {
 	Stderr   string `json:"stderr"`
 	ExitCode int    `json:"exit_code"`
 	Error    string `json:"error"`
+	// DurationMS is the wall time the program ran inside its container.
+	DurationMS int64              `json:"duration_ms,omitempty"`
+	Interactor *ExecutionResponse `json:"interactor,omitempty"`
+	Transcript []TranscriptEntry  `json:"transcript,omitempty"`
+	// TestReport holds the test runner's machine-readable report in test mode.
+	TestReport       string        `json:"test_report,omitempty"`
+	TestReportFormat string        `json:"test_report_format,omitempty"`
+	FileChanges      []FileChange  `json:"file_changes,omitempty"`
+	Audit            *AuditSummary `json:"audit,omitempty"`
+}
+
+// TranscriptEntry is one contiguous chunk of output written by one side of an
+// interactive execution.
+type TranscriptEntry struct {
+	Source string `json:"source"`
+	Data   string `json:"data"`
+}
+
+const (
+	FileChangeAdded    = "added"
+	FileChangeModified = "modified"
+	FileChangeDeleted  = "deleted"
+)
+
+// FileChange is one path touched by the program, excluding the files the
+// sandbox itself wrote into the work directory.
+type FileChange struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+}
+
+const (
+	AuditNetwork       = "network"
+	AuditProcess       = "process"
+	AuditSensitivePath = "sensitive_path"
+)
+
+// AuditSummary counts notable syscalls made by an audited execution. Blocked
+// counts notable calls that the kernel refused.
+type AuditSummary struct {
+	NetworkAttempts int          `json:"network_attempts"`
+	ProcessSpawns   int          `json:"process_spawns"`
+	SensitivePaths  int          `json:"sensitive_paths"`
+	Blocked         int          `json:"blocked"`
+	Events          []AuditEvent `json:"events,omitempty"`
+}
+
+type AuditEvent struct {
+	Category string `json:"category"`
+	Syscall  string `json:"syscall"`
+	Detail   string `json:"detail"`
+	Blocked  bool   `json:"blocked,omitempty"`
+}
+
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+const (
+	ReadinessReady       = "ready"
+	ReadinessDegraded    = "degraded"
+	ReadinessUnavailable = "unavailable"
+
+	DependencyOK     = "ok"
+	DependencyFailed = "failed"
+)
+
+// ReadinessResponse reports each dependency's last check. Status is
+// unavailable when a critical dependency failed and degraded when only
+// optional ones did.
+type ReadinessResponse struct {
+	Status string             `json:"status"`
+	Checks []DependencyStatus `json:"checks"`
+}
+
+type DependencyStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	// DurationMS is how long the check took when it last ran.
+	DurationMS int64 `json:"duration_ms"`
+}
+
+const (
+	ExecutionKindSingle      = "execution"
+	ExecutionKindInteractive = "interactive"
+
+	ExecutionStateQueued  = "queued"
+	ExecutionStateRunning = "running"
+)
+
+// ActiveExecution describes an execution the sandbox is running or waiting to
+// admit.
+type ActiveExecution struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	State     string `json:"state"`
+	Language  string `json:"language"`
+	RequestID string `json:"request_id,omitempty"`
+	// Requester is the ID of the API key that started the execution.
+	Requester string     `json:"requester,omitempty"`
+	QueuedAt  time.Time  `json:"queued_at"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// AgeMS is the time since the execution was queued.
+	AgeMS      int64            `json:"age_ms"`
+	Containers []ContainerUsage `json:"containers,omitempty"`
+}
+
+// ContainerUsage is a container's resource usage as last reported by the
+// Docker daemon. Error is set instead when the daemon could not report it.
+type ContainerUsage struct {
+	ID               string  `json:"id"`
+	CPUSeconds       float64 `json:"cpu_seconds"`
+	MemoryBytes      uint64  `json:"memory_bytes"`
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes,omitempty"`
+	Error            string  `json:"error,omitempty"`
+}
+
+type ExecutionList struct {
+	Draining   bool              `json:"draining"`
+	Executions []ActiveExecution `json:"executions"`
+}
+
+// QueueStatus lists executions waiting for a slot under the concurrency limit.
+// A limit of zero means executions are admitted immediately.
+type QueueStatus struct {
+	Limit  int               `json:"limit"`
+	Active int               `json:"active"`
+	Queued []ActiveExecution `json:"queued"`
+}
+
+type DrainStatus struct {
+	Draining bool `json:"draining"`
+	Active   int  `json:"active"`
+	Queued   int  `json:"queued"`
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -44,7 +45,7 @@ func TestExecutionTypesRoundTripJSON(t *testing.T) {
 		t.Fatalf("json.Unmarshal() response error = %v", err)
 	}
 
-	if gotResp != resp {
+	if !reflect.DeepEqual(gotResp, resp) {
 		t.Fatalf("round trip response = %+v, want %+v", gotResp, resp)
 	}
 }
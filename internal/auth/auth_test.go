@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseKeysAndNewKeyringValidate(t *testing.T) {
+	keys, err := ParseKeys([]byte(`
+keys:
+  - id: ci
+    hash: ` + HashKey("secret") + `
+    scopes: [execute, benchmark]
+    quotas:
+      requests_per_day: 100
+      concurrent_executions: 2
+      sandbox_seconds_per_day: 60
+`))
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	if len(keys) != 1 || keys[0].Quotas.ConcurrentExecutions != 2 || keys[0].Quotas.SandboxSecondsPerDay != 60 {
+		t.Fatalf("keys = %+v, want parsed quotas", keys)
+	}
+	if _, err := NewKeyring(keys); err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	invalid := []Key{
+		{ID: "", Hash: HashKey("a"), Scopes: []string{ScopeExecute}},
+		{ID: "plain", Hash: "secret", Scopes: []string{ScopeExecute}},
+		{ID: "noscope", Hash: HashKey("a")},
+		{ID: "badscope", Hash: HashKey("a"), Scopes: []string{"root"}},
+		{ID: "negative", Hash: HashKey("a"), Scopes: []string{ScopeExecute}, Quotas: Quotas{RequestsPerDay: -1}},
+	}
+	for _, key := range invalid {
+		if _, err := NewKeyring([]Key{key}); !errors.Is(err, ErrInvalidKeys) {
+			t.Fatalf("NewKeyring(%+v) error = %v, want ErrInvalidKeys", key, err)
+		}
+	}
+	duplicate := []Key{
+		{ID: "same", Hash: HashKey("a"), Scopes: []string{ScopeExecute}},
+		{ID: "same", Hash: HashKey("b"), Scopes: []string{ScopeExecute}},
+	}
+	if _, err := NewKeyring(duplicate); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("NewKeyring(duplicate) error = %v, want duplicate id", err)
+	}
+}
+
+func TestLoadFromEnvReturnsNilWithoutConfiguration(t *testing.T) {
+	t.Setenv(KeysFileEnv, "")
+	t.Setenv(KeysEnv, "")
+
+	ring, err := LoadFromEnv()
+	if err != nil || ring != nil {
+		t.Fatalf("LoadFromEnv() = %v, %v, want nil keyring", ring, err)
+	}
+
+	t.Setenv(KeysEnv, `{"keys":[{"id":"inline","hash":"`+HashKey("k")+`","scopes":["admin"]}]}`)
+	ring, err = LoadFromEnv()
+	if err != nil || ring.Len() != 1 {
+		t.Fatalf("LoadFromEnv(inline) = %v, %v, want one key", ring, err)
+	}
+}
+
+func TestRequireAuthenticatesAndChecksScopes(t *testing.T) {
+	ring := mustKeyring(t,
+		Key{ID: "runner", Hash: HashKey("runner-secret"), Scopes: []string{ScopeExecute}},
+		Key{ID: "root", Hash: HashKey("root-secret"), Scopes: []string{ScopeAdmin}},
+	)
+	var seenKeyID string
+	handler := ring.Require(ScopeBenchmark)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenKeyID = KeyID(r.Context())
+	}))
+
+	tests := []struct {
+		header string
+		value  string
+		want   int
+	}{
+		{want: http.StatusUnauthorized},
+		{header: "Authorization", value: "Bearer wrong", want: http.StatusUnauthorized},
+		{header: "Authorization", value: "Bearer runner-secret", want: http.StatusForbidden},
+		{header: "X-API-Key", value: "root-secret", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/v1/benchmark/run", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != tt.want {
+			t.Fatalf("%s=%q status = %d, want %d", tt.header, tt.value, rr.Code, tt.want)
+		}
+	}
+	if seenKeyID != "root" {
+		t.Fatalf("KeyID = %q, want admin key to pass every scope", seenKeyID)
+	}
+}
+
+func TestRequireEnforcesDailyRequestAndSandboxTimeQuotas(t *testing.T) {
+	ring := mustKeyring(t, Key{ID: "ci", Hash: HashKey("s"), Scopes: []string{ScopeExecute}, Quotas: Quotas{RequestsPerDay: 3, SandboxSecondsPerDay: 2}})
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	ring.now = func() time.Time { return now }
+	handler := ring.Require(ScopeExecute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ChargeSandboxTime(r.Context(), 1500*time.Millisecond)
+	}))
+
+	statuses := []int{}
+	for range 3 {
+		statuses = append(statuses, serveWithKey(handler, "s"))
+	}
+	if statuses[0] != http.StatusOK || statuses[1] != http.StatusOK || statuses[2] != http.StatusTooManyRequests {
+		t.Fatalf("statuses = %v, want the sandbox time quota to reject the third request", statuses)
+	}
+	if usage, _ := ring.Usage("ci"); usage.Requests != 2 || usage.SandboxSeconds != 3 {
+		t.Fatalf("usage = %+v, want 2 requests and 3 sandbox seconds", usage)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if got := serveWithKey(handler, "s"); got != http.StatusOK {
+		t.Fatalf("status after UTC midnight = %d, want quotas reset", got)
+	}
+}
+
+func TestRequireLimitsConcurrentExecutions(t *testing.T) {
+	ring := mustKeyring(t, Key{ID: "ci", Hash: HashKey("s"), Scopes: []string{ScopeExecute}, Quotas: Quotas{ConcurrentExecutions: 1}})
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := ring.Require(ScopeExecute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serveWithKey(handler, "s")
+	}()
+	<-entered
+
+	if got := serveWithKey(handler, "s"); got != http.StatusTooManyRequests {
+		t.Fatalf("second concurrent status = %d, want 429", got)
+	}
+	close(release)
+	wg.Wait()
+
+	if usage, _ := ring.Usage("ci"); usage.ConcurrentExecutions != 0 {
+		t.Fatalf("active executions = %d, want slot released", usage.ConcurrentExecutions)
+	}
+}
+
+func TestNilKeyringLeavesHandlersOpen(t *testing.T) {
+	var ring *Keyring
+	rr := httptest.NewRecorder()
+	ring.Require(ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want open access without keys", rr.Code)
+	}
+}
+
+func mustKeyring(t *testing.T, keys ...Key) *Keyring {
+	t.Helper()
+	ring, err := NewKeyring(keys)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	return ring
+}
+
+func serveWithKey(handler http.Handler, key string) int {
+	req := httptest.NewRequest(http.MethodPost, "/v1/execute", nil)
+	req.Header.Set("X-API-Key", key)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr.Code
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Keyring authenticates API keys and tracks their quota usage in memory.
+type Keyring struct {
+	byHash map[string]*keyState
+	now    func() time.Time
+}
+
+type keyState struct {
+	key Key
+
+	mu             sync.Mutex
+	day            string
+	requests       int
+	sandboxSeconds float64
+	active         int
+}
+
+func NewKeyring(keys []Key) (*Keyring, error) {
+	ring := &Keyring{byHash: make(map[string]*keyState, len(keys)), now: time.Now}
+	ids := map[string]bool{}
+	for _, key := range keys {
+		if err := validateKey(key); err != nil {
+			return nil, err
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("%w: duplicate key id %q", ErrInvalidKeys, key.ID)
+		}
+		ids[key.ID] = true
+		ring.byHash[key.Hash] = &keyState{key: key}
+	}
+	return ring, nil
+}
+
+// Len reports the number of configured keys.
+func (k *Keyring) Len() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.byHash)
+}
+
+func (k *Keyring) lookup(raw string) *keyState {
+	return k.byHash[HashKey(raw)]
+}
+
+func (s *keyState) allows(scope string) bool {
+	return scope == "" || slices.Contains(s.key.Scopes, scope) || slices.Contains(s.key.Scopes, ScopeAdmin)
+}
+
+// resetLocked starts a new quota day when the UTC date changes.
+func (s *keyState) resetLocked(now time.Time) {
+	day := now.UTC().Format(time.DateOnly)
+	if s.day != day {
+		s.day = day
+		s.requests = 0
+		s.sandboxSeconds = 0
+	}
+}
+
+// admitRequests counts n requests against the daily quota, admitting all of
+// them or none.
+func (s *keyState) admitRequests(now time.Time, n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetLocked(now)
+	quotas := s.key.Quotas
+	if quotas.RequestsPerDay > 0 && s.requests+n > quotas.RequestsPerDay {
+		if left := quotas.RequestsPerDay - s.requests; left > 0 {
+			return fmt.Errorf("daily request quota of %d has %d requests left, %d needed", quotas.RequestsPerDay, left, n)
+		}
+		return fmt.Errorf("daily request quota of %d exhausted", quotas.RequestsPerDay)
+	}
+	if quotas.SandboxSecondsPerDay > 0 && s.sandboxSeconds >= quotas.SandboxSecondsPerDay {
+		return fmt.Errorf("daily sandbox time quota of %g seconds exhausted", quotas.SandboxSecondsPerDay)
+	}
+	s.requests += n
+	return nil
+}
+
+// acquireExecutions reserves n concurrent execution slots, all or none.
+func (s *keyState) acquireExecutions(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit := s.key.Quotas.ConcurrentExecutions; limit > 0 && s.active+n > limit {
+		if n > 1 {
+			return fmt.Errorf("concurrent execution quota of %d cannot cover %d executions", limit, n)
+		}
+		return fmt.Errorf("concurrent execution quota of %d reached", limit)
+	}
+	s.active += n
+	return nil
+}
+
+func (s *keyState) releaseExecutions(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active -= n
+}
+
+func (s *keyState) chargeSandboxTime(now time.Time, seconds float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resetLocked(now)
+	s.sandboxSeconds += seconds
+}
+
+// Usage is a snapshot of one key's consumption for the current day.
+type Usage struct {
+	Requests             int
+	SandboxSeconds       float64
+	ConcurrentExecutions int
+}
+
+func (k *Keyring) Usage(keyID string) (Usage, bool) {
+	for _, state := range k.byHash {
+		if state.key.ID != keyID {
+			continue
+		}
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		state.resetLocked(k.now())
+		return Usage{Requests: state.requests, SandboxSeconds: state.sandboxSeconds, ConcurrentExecutions: state.active}, true
+	}
+	return Usage{}, false
+}
+
+type principalKey struct{}
+
+type principal struct {
+	state *keyState
+	ring  *Keyring
+}
+
+// KeyID returns the ID of the API key that authenticated the request, or ""
+// when authentication is disabled.
+func KeyID(ctx context.Context) string {
+	if p, ok := ctx.Value(principalKey{}).(principal); ok {
+		return p.state.key.ID
+	}
+	return ""
+}
+
+// Owns reports whether the request's key may act on something the key with
+// ID owner started: it is that key or an admin key. Everything is owned when
+// authentication is disabled or owner is empty.
+func Owns(ctx context.Context, owner string) bool {
+	p, ok := ctx.Value(principalKey{}).(principal)
+	return !ok || owner == "" || p.state.key.ID == owner || slices.Contains(p.state.key.Scopes, ScopeAdmin)
+}
+
+// ChargeSandboxTime adds d to the calling key's daily sandbox time quota.
+func ChargeSandboxTime(ctx context.Context, d time.Duration) {
+	if p, ok := ctx.Value(principalKey{}).(principal); ok {
+		p.state.chargeSandboxTime(p.ring.now(), d.Seconds())
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ScopeExecute   = "execute"
+	ScopeBenchmark = "benchmark"
+	// ScopeAdmin grants every other scope as well.
+	ScopeAdmin = "admin"
+)
+
+const (
+	// KeysFileEnv names a YAML file of API keys; KeysEnv holds the same
+	// document inline.
+	KeysFileEnv = "EVALUATOR_API_KEYS_FILE"
+	KeysEnv     = "EVALUATOR_API_KEYS"
+)
+
+const hashPrefix = "sha256:"
+
+var ErrInvalidKeys = errors.New("invalid api keys")
+
+// Quotas limit one key. Zero means unlimited. Daily counters reset at
+// midnight UTC.
+type Quotas struct {
+	RequestsPerDay       int     `yaml:"requests_per_day"`
+	ConcurrentExecutions int     `yaml:"concurrent_executions"`
+	SandboxSecondsPerDay float64 `yaml:"sandbox_seconds_per_day"`
+}
+
+// Key is a configured API key. Only the SHA-256 hash of the secret is stored.
+type Key struct {
+	ID     string   `yaml:"id"`
+	Hash   string   `yaml:"hash"`
+	Scopes []string `yaml:"scopes"`
+	Quotas Quotas   `yaml:"quotas"`
+}
+
+type keysFile struct {
+	Keys []Key `yaml:"keys"`
+}
+
+// HashKey returns the stored form of a raw API key.
+func HashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// LoadFromEnv builds a keyring from KeysFileEnv or KeysEnv. It returns nil,
+// meaning authentication is disabled, when neither is set.
+func LoadFromEnv() (*Keyring, error) {
+	if path := os.Getenv(KeysFileEnv); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read api keys: %w", err)
+		}
+		return parseKeyring(raw)
+	}
+	if inline := os.Getenv(KeysEnv); inline != "" {
+		return parseKeyring([]byte(inline))
+	}
+	return nil, nil
+}
+
+func parseKeyring(raw []byte) (*Keyring, error) {
+	keys, err := ParseKeys(raw)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(keys)
+}
+
+func ParseKeys(raw []byte) ([]Key, error) {
+	var parsed keysFile
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeys, err)
+	}
+	return parsed.Keys, nil
+}
+
+func validateKey(key Key) error {
+	if key.ID == "" {
+		return fmt.Errorf("%w: key id is required", ErrInvalidKeys)
+	}
+	digest, ok := strings.CutPrefix(key.Hash, hashPrefix)
+	if decoded, err := hex.DecodeString(digest); !ok || err != nil || len(decoded) != sha256.Size {
+		return fmt.Errorf("%w: key %q hash must be sha256:<64 hex characters>", ErrInvalidKeys, key.ID)
+	}
+	if len(key.Scopes) == 0 {
+		return fmt.Errorf("%w: key %q requires at least one scope", ErrInvalidKeys, key.ID)
+	}
+	for _, scope := range key.Scopes {
+		switch scope {
+		case ScopeExecute, ScopeBenchmark, ScopeAdmin:
+		default:
+			return fmt.Errorf("%w: key %q has unknown scope %q", ErrInvalidKeys, key.ID, scope)
+		}
+	}
+	if key.Quotas.RequestsPerDay < 0 || key.Quotas.ConcurrentExecutions < 0 || key.Quotas.SandboxSecondsPerDay < 0 {
+		return fmt.Errorf("%w: key %q quotas cannot be negative", ErrInvalidKeys, key.ID)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/logging"
+	"gexec-sandbox/internal/metrics"
+)
+
+var (
+	// ErrUnauthenticated means no key was presented or the key is unknown.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden means the key lacks the required scope.
+	ErrForbidden = errors.New("forbidden")
+	// ErrQuotaExceeded means one of the key's quotas is used up.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+// authError carries a client-facing message while matching one of the
+// sentinel errors above.
+type authError struct {
+	kind    error
+	message string
+}
+
+func (e authError) Error() string        { return e.message }
+func (e authError) Is(target error) bool { return target == e.kind }
+
+// Authorize authenticates a raw API key, checks that it carries scope and
+// admits it against the key's quotas. ScopeExecute also reserves a concurrent
+// execution slot, freed by release. The returned context carries the key for
+// KeyID and ChargeSandboxTime. An empty scope marks a public call; a nil
+// keyring disables authentication entirely. Transports share it so HTTP and
+// gRPC callers are held to the same rules.
+func (k *Keyring) Authorize(ctx context.Context, raw string, scope string) (_ context.Context, release func(), err error) {
+	return k.AuthorizeN(ctx, raw, scope, 1)
+}
+
+// AuthorizeN is Authorize for a call carrying n executions, such as a batch.
+// It counts n requests and, for ScopeExecute, reserves n execution slots, and
+// refuses the whole call when the key's quotas cannot cover all of them.
+func (k *Keyring) AuthorizeN(ctx context.Context, raw string, scope string, n int) (_ context.Context, release func(), err error) {
+	if k == nil || scope == "" {
+		return ctx, func() {}, nil
+	}
+	if raw == "" {
+		return ctx, nil, authError{ErrUnauthenticated, "missing API key"}
+	}
+	state := k.lookup(raw)
+	if state == nil {
+		return ctx, nil, authError{ErrUnauthenticated, "invalid API key"}
+	}
+
+	keyID := state.key.ID
+	metrics.IncrementKeyRequest(keyID)
+	ctx = logging.With(ctx, slog.String(logging.KeyIDKey, keyID))
+	if !state.allows(scope) {
+		slog.WarnContext(ctx, "api key denied", "missing_scope", scope)
+		return ctx, nil, authError{ErrForbidden, "API key lacks the " + scope + " scope"}
+	}
+	if err := state.admitRequests(k.now(), n); err != nil {
+		slog.WarnContext(ctx, "api key quota exceeded", "error", err)
+		return ctx, nil, authError{ErrQuotaExceeded, err.Error()}
+	}
+	release = func() {}
+	if scope == ScopeExecute {
+		if err := state.acquireExecutions(n); err != nil {
+			slog.WarnContext(ctx, "api key quota exceeded", "error", err)
+			return ctx, nil, authError{ErrQuotaExceeded, err.Error()}
+		}
+		release = func() { state.releaseExecutions(n) }
+	}
+
+	return context.WithValue(ctx, principalKey{}, principal{state: state, ring: k}), release, nil
+}
+
+// Identify returns the ID of the key raw names, or "" when it names none or
+// authentication is disabled. It neither authorizes nor charges the key, so a
+// rate limiter in front of Authorize can tell clients apart by key.
+func (k *Keyring) Identify(raw string) string {
+	if k == nil || raw == "" {
+		return ""
+	}
+	if state := k.lookup(raw); state != nil {
+		return state.key.ID
+	}
+	return ""
+}
+
+// IdentifyRequest is Identify for the key an HTTP request presents.
+func (k *Keyring) IdentifyRequest(r *http.Request) string {
+	return k.Identify(presentedKey(r))
+}
+
+// Require is Authorize as HTTP middleware, answering failures with the JSON
+// error envelope.
+func (k *Keyring) Require(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if k == nil || scope == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, release, err := k.Authorize(r.Context(), presentedKey(r), scope)
+			switch {
+			case errors.Is(err, ErrUnauthenticated):
+				writeError(w, http.StatusUnauthorized, api.ErrorCodeUnauthorized, err.Error())
+				return
+			case errors.Is(err, ErrForbidden):
+				writeError(w, http.StatusForbidden, api.ErrorCodeForbidden, err.Error())
+				return
+			case errors.Is(err, ErrQuotaExceeded):
+				writeError(w, http.StatusTooManyRequests, api.ErrorCodeQuotaExceeded, err.Error())
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// presentedKey reads the key from "Authorization: Bearer" or X-API-Key.
+func presentedKey(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	api.WriteError(w, status, code, message)
+}
@@ -106,34 +106,38 @@ func ValidateScaffoldCatalog(catalog ScaffoldCatalog) error {
 	return nil
 }
 
-func (c TaskCatalog) FilterByFamily(family string) TaskCatalog {
+// Filter returns the tasks keep accepts, in catalog order.
+func (c TaskCatalog) Filter(keep func(Task) bool) TaskCatalog {
 	filtered := TaskCatalog{}
 	for _, task := range c.Tasks {
-		if task.TaskFamily == family {
+		if keep(task) {
 			filtered.Tasks = append(filtered.Tasks, task)
 		}
 	}
 	return filtered
 }
 
-func (c ScaffoldCatalog) FilterByName(name string) ScaffoldCatalog {
+func (c TaskCatalog) FilterByFamily(family string) TaskCatalog {
+	return c.Filter(func(task Task) bool { return task.TaskFamily == family })
+}
+
+// Filter returns the baseline scaffold plus the variants keep accepts, in
+// catalog order. The baseline is kept regardless because every run compares
+// against it.
+func (c ScaffoldCatalog) Filter(keep func(Scaffold) bool) ScaffoldCatalog {
 	filtered := ScaffoldCatalog{}
-	var baseline *Scaffold
 	for _, scaffold := range c.Scaffolds {
-		if scaffold.Baseline {
-			scaffoldCopy := scaffold
-			baseline = &scaffoldCopy
-		}
-		if scaffold.Name == name {
+		if scaffold.Baseline || keep(scaffold) {
 			filtered.Scaffolds = append(filtered.Scaffolds, scaffold)
 		}
 	}
-	if baseline != nil && (name != baseline.Name || len(filtered.Scaffolds) == 0) {
-		filtered.Scaffolds = append([]Scaffold{*baseline}, filtered.Scaffolds...)
-	}
 	return filtered
 }
 
+func (c ScaffoldCatalog) FilterByName(name string) ScaffoldCatalog {
+	return c.Filter(func(scaffold Scaffold) bool { return scaffold.Name == name })
+}
+
 func validateTask(task Task) error {
 	if task.ID == "" {
 		return ErrInvalidTaskCatalog
@@ -150,9 +154,31 @@ func validateTask(task Task) error {
 	if task.Language == "" {
 		return ErrInvalidTaskCatalog
 	}
-	if len(task.TestCases) == 0 && (task.ArtifactExpectation == nil || task.ArtifactExpectation.ExpectedOutput == "") {
+	switch task.GradingMode {
+	case "", GradingModeOutput:
+		if len(task.TestCases) == 0 && (task.ArtifactExpectation == nil || task.ArtifactExpectation.ExpectedOutput == "") {
+			return ErrInvalidTaskCatalog
+		}
+	case GradingModeTestsPass:
+		if task.Tests == nil || len(task.Tests.Files) == 0 || task.Tests.TimeoutMS < 0 || task.Checker != nil || task.Interactor != nil {
+			return ErrInvalidTaskCatalog
+		}
+	default:
 		return ErrInvalidTaskCatalog
 	}
+	if task.Checker != nil && (task.Checker.Language == "" || task.Checker.Source == "" || task.Checker.TimeoutMS < 0) {
+		return ErrInvalidTaskCatalog
+	}
+	if task.Interactor != nil && (task.Interactor.Language == "" || task.Interactor.Source == "" || task.Interactor.TimeoutMS < 0 || task.Checker != nil) {
+		return ErrInvalidTaskCatalog
+	}
+	if task.FileChanges != nil {
+		for _, pattern := range task.FileChanges.Allowed {
+			if !validFileChangePattern(pattern) {
+				return ErrInvalidTaskCatalog
+			}
+		}
+	}
 	if task.ArtifactExpectation != nil {
 		if task.ArtifactExpectation.Type == "" || task.ArtifactExpectation.Format == "" || task.ArtifactExpectation.Description == "" {
 			return ErrInvalidTaskCatalog
@@ -160,7 +186,7 @@ func validateTask(task Task) error {
 		if !isSupportedArtifactFormat(task.ArtifactExpectation.Format) {
 			return ErrInvalidTaskCatalog
 		}
-		if len(task.TestCases) == 0 && (task.ArtifactExpectation.Input == "" || task.ArtifactExpectation.ExpectedOutput == "") {
+		if task.GradingMode != GradingModeTestsPass && len(task.TestCases) == 0 && (task.ArtifactExpectation.Input == "" || task.ArtifactExpectation.ExpectedOutput == "") {
 			return ErrInvalidTaskCatalog
 		}
 	}
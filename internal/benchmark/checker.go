@@ -0,0 +1,161 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+)
+
+const (
+	VerdictAccepted          = "accepted"
+	VerdictWrongAnswer       = "wrong_answer"
+	VerdictPresentationError = "presentation_error"
+	VerdictPartial           = "partial"
+	VerdictCheckerError      = "checker_error"
+	VerdictRuntimeError      = "runtime_error"
+	VerdictTimeLimitExceeded = "time_limit_exceeded"
+	VerdictUnexpectedFiles   = "unexpected_file_changes"
+)
+
+// Checker programs receive three file paths as arguments, in this order.
+const (
+	checkerInputFile    = "input.txt"
+	checkerExpectedFile = "expected.txt"
+	checkerOutputFile   = "output.txt"
+)
+
+// CheckerGrader runs a task's checker program in its own sandbox container and
+// falls back to another grader for tasks that do not declare a checker.
+//
+// The checker follows the testlib exit code convention: 0 accepted, 1 wrong
+// answer, 2 presentation error, 7 partial credit; anything else is a checker
+// failure. An optional score in [0, 1] may be printed on the first stdout line
+// and a human-readable comment on stderr.
+type CheckerGrader struct {
+	Executor Executor
+	Config   config.Config
+	Fallback Grader
+}
+
+func NewCheckerGrader(exec Executor, cfg config.Config) CheckerGrader {
+	return CheckerGrader{
+		Executor: exec,
+		Config:   cfg,
+		Fallback: DefaultGrader{},
+	}
+}
+
+// CheckerGraderVersion changes whenever CheckerGrader would score the same
+// checker verdict differently.
+const CheckerGraderVersion = "checker@1"
+
+// Version names the checker logic and the fallback's version, which grades
+// tasks without a checker.
+func (g CheckerGrader) Version() string {
+	fallback := g.Fallback
+	if fallback == nil {
+		fallback = DefaultGrader{}
+	}
+	return CheckerGraderVersion + "+" + GraderVersion(fallback)
+}
+
+func (g CheckerGrader) Grade(task Task, resp api.ExecutionResponse, tc TestCase) Outcome {
+	return g.GradeContext(context.Background(), task, resp, tc)
+}
+
+func (g CheckerGrader) GradeContext(ctx context.Context, task Task, resp api.ExecutionResponse, tc TestCase) Outcome {
+	if task.Checker == nil {
+		fallback := g.Fallback
+		if fallback == nil {
+			fallback = DefaultGrader{}
+		}
+		return gradeTestCase(ctx, fallback, task, resp, tc)
+	}
+	if g.Executor == nil {
+		return Outcome{Verdict: VerdictCheckerError, Message: "checker executor is required"}
+	}
+
+	timeoutMS := task.Checker.TimeoutMS
+	if timeoutMS == 0 {
+		timeoutMS = g.Config.DefaultTimeoutMS
+	}
+
+	checkerResp, err := g.Executor.Execute(ctx, api.ExecutionRequest{
+		Language:   task.Checker.Language,
+		SourceCode: task.Checker.Source,
+		TimeoutMS:  timeoutMS,
+		Files: map[string]string{
+			checkerInputFile:    tc.Input,
+			checkerExpectedFile: tc.ExpectedOutput,
+			checkerOutputFile:   resp.Stdout,
+		},
+		Args: []string{checkerInputFile, checkerExpectedFile, checkerOutputFile},
+	}, g.Config)
+	if err != nil {
+		return Outcome{Verdict: VerdictCheckerError, Message: fmt.Sprintf("run checker: %v", err)}
+	}
+
+	return interpretCheckerResult(checkerResp)
+}
+
+func interpretCheckerResult(resp api.ExecutionResponse) Outcome {
+	outcome := Outcome{Message: strings.TrimSpace(resp.Stderr)}
+
+	score, hasScore, err := parseCheckerScore(resp.Stdout)
+	if err != nil {
+		outcome.Verdict = VerdictCheckerError
+		outcome.Message = err.Error()
+		return outcome
+	}
+
+	switch resp.ExitCode {
+	case 0:
+		outcome.Passed = true
+		outcome.Verdict = VerdictAccepted
+		outcome.Score = 1
+		if hasScore {
+			outcome.Score = score
+		}
+	case 1:
+		outcome.Verdict = VerdictWrongAnswer
+	case 2:
+		outcome.Verdict = VerdictPresentationError
+	case 7:
+		if !hasScore {
+			outcome.Verdict = VerdictCheckerError
+			outcome.Message = "checker reported partial credit without a score"
+			return outcome
+		}
+		outcome.Verdict = VerdictPartial
+		outcome.Score = score
+	default:
+		outcome.Verdict = VerdictCheckerError
+		if outcome.Message == "" {
+			outcome.Message = fmt.Sprintf("checker exited with code %d", resp.ExitCode)
+		}
+	}
+
+	return outcome
+}
+
+func parseCheckerScore(stdout string) (float64, bool, error) {
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(stdout), "\n")
+	firstLine = strings.TrimSpace(firstLine)
+	if firstLine == "" {
+		return 0, false, nil
+	}
+
+	score, err := strconv.ParseFloat(firstLine, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("checker score %q is not a number", firstLine)
+	}
+	if score < 0 || score > 1 {
+		return 0, false, fmt.Errorf("checker score %v must be between 0 and 1", score)
+	}
+
+	return score, true, nil
+}
@@ -0,0 +1,138 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+)
+
+func TestCheckerGraderRunsCheckerWithCaseFiles(t *testing.T) {
+	exec := &fakeExecutor{resp: api.ExecutionResponse{ExitCode: 0, Stdout: "0.75\n"}}
+	grader := NewCheckerGrader(exec, config.Config{DefaultTimeoutMS: 4321})
+	task := Task{Checker: &Checker{Language: "python", Source: "import sys"}}
+
+	outcome := grader.Grade(task, api.ExecutionResponse{Stdout: "3 1 2"}, TestCase{Input: "3", ExpectedOutput: "1 2 3"})
+
+	if !outcome.Passed || outcome.Verdict != VerdictAccepted || outcome.Score != 0.75 {
+		t.Fatalf("outcome = %#v, want accepted with score 0.75", outcome)
+	}
+	if exec.seenReq.Language != "python" || exec.seenReq.SourceCode != "import sys" {
+		t.Fatalf("checker request = %#v, want checker program", exec.seenReq)
+	}
+	if exec.seenReq.TimeoutMS != 4321 {
+		t.Fatalf("TimeoutMS = %d, want default 4321", exec.seenReq.TimeoutMS)
+	}
+	want := map[string]string{"input.txt": "3", "expected.txt": "1 2 3", "output.txt": "3 1 2"}
+	for name, contents := range want {
+		if exec.seenReq.Files[name] != contents {
+			t.Fatalf("Files[%q] = %q, want %q", name, exec.seenReq.Files[name], contents)
+		}
+	}
+	if len(exec.seenReq.Args) != 3 || exec.seenReq.Args[0] != "input.txt" || exec.seenReq.Args[2] != "output.txt" {
+		t.Fatalf("Args = %#v, want input, expected and output paths", exec.seenReq.Args)
+	}
+}
+
+func TestCheckerGraderFallsBackForTasksWithoutChecker(t *testing.T) {
+	exec := &fakeExecutor{}
+	grader := NewCheckerGrader(exec, config.Config{})
+
+	outcome := grader.Grade(Task{}, api.ExecutionResponse{Stdout: "ok\n"}, TestCase{ExpectedOutput: "ok"})
+
+	if !outcome.Passed {
+		t.Fatalf("outcome = %#v, want fallback pass", outcome)
+	}
+	if exec.seenReq.SourceCode != "" {
+		t.Fatalf("executor called with %#v, want no checker run", exec.seenReq)
+	}
+}
+
+func TestCheckerGraderReportsExecutionFailureAsCheckerError(t *testing.T) {
+	grader := NewCheckerGrader(failingExecutor{err: errors.New("docker down")}, config.Config{})
+	task := Task{Checker: &Checker{Language: "python", Source: "pass"}}
+
+	outcome := grader.GradeContext(context.Background(), task, api.ExecutionResponse{}, TestCase{})
+
+	if outcome.Passed || outcome.Verdict != VerdictCheckerError {
+		t.Fatalf("outcome = %#v, want checker error", outcome)
+	}
+}
+
+func TestInterpretCheckerResultMapsExitCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    api.ExecutionResponse
+		verdict string
+		passed  bool
+		score   float64
+	}{
+		{name: "accepted", resp: api.ExecutionResponse{ExitCode: 0}, verdict: VerdictAccepted, passed: true, score: 1},
+		{name: "wrong answer", resp: api.ExecutionResponse{ExitCode: 1, Stderr: "line 2 differs"}, verdict: VerdictWrongAnswer},
+		{name: "presentation error", resp: api.ExecutionResponse{ExitCode: 2}, verdict: VerdictPresentationError},
+		{name: "partial", resp: api.ExecutionResponse{ExitCode: 7, Stdout: "0.5"}, verdict: VerdictPartial, score: 0.5},
+		{name: "partial without score", resp: api.ExecutionResponse{ExitCode: 7}, verdict: VerdictCheckerError},
+		{name: "crash", resp: api.ExecutionResponse{ExitCode: 3}, verdict: VerdictCheckerError},
+		{name: "score out of range", resp: api.ExecutionResponse{ExitCode: 0, Stdout: "1.5"}, verdict: VerdictCheckerError},
+		{name: "score not numeric", resp: api.ExecutionResponse{ExitCode: 0, Stdout: "ok"}, verdict: VerdictCheckerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := interpretCheckerResult(tt.resp)
+			if outcome.Verdict != tt.verdict || outcome.Passed != tt.passed || outcome.Score != tt.score {
+				t.Fatalf("outcome = %#v, want verdict %q passed %v score %v", outcome, tt.verdict, tt.passed, tt.score)
+			}
+		})
+	}
+}
+
+func TestRunTaskWithCheckerGraderUsesRunContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	exec := &cancelingExecutor{cancel: cancel}
+	task := Task{
+		ID:          "checker-task",
+		Description: "sort",
+		Language:    "python",
+		TestCases:   []TestCase{{Input: "2 1", ExpectedOutput: "1 2"}},
+		Checker:     &Checker{Language: "python", Source: "pass"},
+	}
+
+	run := RunTaskWithGrader(ctx, task, Scaffold{Name: "baseline"}, RunModeBaseline, &fakeLLMClient{code: "print(1)"}, exec, NewCheckerGrader(exec, config.Config{}), config.Config{})
+
+	if run.Passed {
+		t.Fatalf("Passed = true, want false after checker observed cancellation")
+	}
+	if len(run.Outcomes) != 1 || run.Outcomes[0].Verdict != VerdictCheckerError {
+		t.Fatalf("Outcomes = %#v, want one checker error", run.Outcomes)
+	}
+}
+
+type failingExecutor struct {
+	err error
+}
+
+func (f failingExecutor) Execute(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	return api.ExecutionResponse{}, f.err
+}
+
+// cancelingExecutor cancels the run after the submission executes so the
+// checker call sees a canceled context.
+type cancelingExecutor struct {
+	cancel context.CancelFunc
+	calls  int
+}
+
+func (f *cancelingExecutor) Execute(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	f.calls++
+	if f.calls == 1 {
+		f.cancel()
+		return api.ExecutionResponse{Stdout: "1 2"}, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return api.ExecutionResponse{}, err
+	}
+	return api.ExecutionResponse{}, nil
+}
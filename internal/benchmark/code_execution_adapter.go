@@ -2,21 +2,73 @@ package benchmark
 
 import (
 	"context"
+	"time"
+
 	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
 	"gexec-sandbox/internal/config"
 	"gexec-sandbox/internal/sandbox"
 )
 
+// CodeExecutionAdapter runs executions in the sandbox as the calling API key
+// and charges their time to its sandbox time quota. A benchmark keeps the
+// context values of the request that started it, so its executions are
+// charged to that key.
 type CodeExecutionAdapter struct {
-	Runner func(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error)
+	Runner            func(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error)
+	InteractiveRunner func(ctx context.Context, submission api.ExecutionRequest, interactor api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error)
+	StreamRunner      func(ctx context.Context, req api.ExecutionRequest, cfg config.Config, sink sandbox.OutputFunc) (api.ExecutionResponse, error)
 }
 
 func NewCodeExecutionAdapter() CodeExecutionAdapter {
 	return CodeExecutionAdapter{
-		Runner: sandbox.RunCodeInSandbox,
+		Runner:            sandbox.RunCodeInSandbox,
+		InteractiveRunner: sandbox.RunInteractiveInSandbox,
+		StreamRunner:      sandbox.StreamCodeInSandbox,
 	}
 }
 
 func (a CodeExecutionAdapter) Execute(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
-	return a.Runner(ctx, req, cfg)
+	return charged(ctx, func(ctx context.Context) (api.ExecutionResponse, error) {
+		return a.Runner(ctx, req, cfg)
+	})
+}
+
+func (a CodeExecutionAdapter) ExecuteInteractive(ctx context.Context, submission api.ExecutionRequest, interactor api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	if a.InteractiveRunner == nil {
+		return api.ExecutionResponse{}, errInteractiveUnsupported
+	}
+	return charged(ctx, func(ctx context.Context) (api.ExecutionResponse, error) {
+		return a.InteractiveRunner(ctx, submission, interactor, cfg)
+	})
+}
+
+func (a CodeExecutionAdapter) ExecuteStream(ctx context.Context, req api.ExecutionRequest, cfg config.Config, sink sandbox.OutputFunc) (api.ExecutionResponse, error) {
+	if a.StreamRunner == nil {
+		return api.ExecutionResponse{}, errStreamingUnsupported
+	}
+	return charged(ctx, func(ctx context.Context) (api.ExecutionResponse, error) {
+		return a.StreamRunner(ctx, req, cfg, sink)
+	})
+}
+
+// charged runs an execution as the calling API key and charges its time to
+// the key's sandbox time quota.
+func charged(ctx context.Context, run func(ctx context.Context) (api.ExecutionResponse, error)) (api.ExecutionResponse, error) {
+	ctx = sandbox.WithRequester(ctx, auth.KeyID(ctx))
+	started := time.Now()
+	resp, err := run(ctx)
+	auth.ChargeSandboxTime(ctx, executionTime(resp, started))
+	return resp, err
+}
+
+// executionTime prefers the sandbox's own measurement, the wall time from
+// container start to exit, and falls back to the whole call's time when
+// execution failed before reporting one. Neither is CPU time: a program
+// sleeping or waiting on stdin is charged too.
+func executionTime(resp api.ExecutionResponse, started time.Time) time.Duration {
+	if resp.DurationMS > 0 {
+		return time.Duration(resp.DurationMS) * time.Millisecond
+	}
+	return time.Since(started)
 }
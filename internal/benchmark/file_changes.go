@@ -0,0 +1,58 @@
+package benchmark
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gexec-sandbox/internal/api"
+)
+
+// checkFileChanges fails an otherwise graded outcome when the submission
+// touched paths outside the task's allowed patterns.
+func checkFileChanges(task Task, resp api.ExecutionResponse, outcome Outcome) Outcome {
+	if task.FileChanges == nil {
+		return outcome
+	}
+
+	var unexpected []string
+	for _, change := range resp.FileChanges {
+		if !fileChangeAllowed(task.FileChanges.Allowed, change.Path) {
+			unexpected = append(unexpected, fmt.Sprintf("%s %s", change.Kind, change.Path))
+		}
+	}
+	if len(unexpected) == 0 {
+		return outcome
+	}
+
+	return Outcome{
+		Verdict: VerdictUnexpectedFiles,
+		Message: "unexpected file changes: " + strings.Join(unexpected, ", "),
+		Tests:   outcome.Tests,
+	}
+}
+
+// fileChangeAllowed matches a changed path against path.Match patterns. A
+// pattern ending in "/**" also allows everything below that directory.
+func fileChangeAllowed(patterns []string, changed string) bool {
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if changed == dir || strings.HasPrefix(changed, dir+"/") {
+				return true
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, changed); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func validFileChangePattern(pattern string) bool {
+	if !strings.HasPrefix(pattern, "/") {
+		return false
+	}
+	_, err := path.Match(strings.TrimSuffix(pattern, "/**"), "")
+	return err == nil
+}
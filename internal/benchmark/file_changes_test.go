@@ -0,0 +1,62 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+)
+
+func TestRunTaskCapturesFileChangesAndRejectsUnexpectedPaths(t *testing.T) {
+	exec := &fakeExecutor{resp: api.ExecutionResponse{
+		Stdout: "ok",
+		FileChanges: []api.FileChange{
+			{Path: "/tmp/report.csv", Kind: api.FileChangeAdded},
+			{Path: "/root/.cache/go-build/00/a", Kind: api.FileChangeAdded},
+			{Path: "/etc/passwd", Kind: api.FileChangeModified},
+		},
+	}}
+	task := Task{
+		ID:          "files",
+		Description: "write a report",
+		Language:    "go",
+		TestCases:   []TestCase{{ExpectedOutput: "ok"}},
+		FileChanges: &FileChangePolicy{Allowed: []string{"/tmp/*.csv", "/root/.cache/**"}},
+	}
+
+	run := RunTask(context.Background(), task, Scaffold{Name: "baseline"}, RunModeBaseline, &fakeLLMClient{code: "package main"}, exec, config.Config{DefaultTimeoutMS: 1000})
+
+	if !exec.seenReq.CaptureFileChanges {
+		t.Fatal("CaptureFileChanges = false, want diff capture for tasks with a file change policy")
+	}
+	if run.Passed || len(run.Outcomes) != 1 {
+		t.Fatalf("run = %#v, want one failed outcome", run)
+	}
+	if got := run.Outcomes[0]; got.Verdict != VerdictUnexpectedFiles || got.Message != "unexpected file changes: modified /etc/passwd" {
+		t.Fatalf("outcome = %#v, want unexpected /etc/passwd change", got)
+	}
+	if len(run.Trace) != 1 || len(run.Trace[0].FileChanges) != 3 {
+		t.Fatalf("Trace = %#v, want recorded file changes", run.Trace)
+	}
+}
+
+func TestRunTaskWithoutFileChangePolicyDoesNotCapture(t *testing.T) {
+	exec := &fakeExecutor{resp: api.ExecutionResponse{Stdout: "ok"}}
+	task := Task{ID: "plain", Description: "print ok", Language: "python", TestCases: []TestCase{{ExpectedOutput: "ok"}}}
+
+	run := RunTask(context.Background(), task, Scaffold{Name: "baseline"}, RunModeBaseline, &fakeLLMClient{code: "print('ok')"}, exec, config.Config{DefaultTimeoutMS: 1000})
+
+	if exec.seenReq.CaptureFileChanges || !run.Passed || run.Trace != nil {
+		t.Fatalf("request = %#v run = %#v, want plain passing run without capture", exec.seenReq, run)
+	}
+}
+
+func TestValidateTaskCatalogRejectsRelativeFileChangePattern(t *testing.T) {
+	task := Task{ID: "t", Title: "T", Description: "d", TaskFamily: "f", Language: "go", TestCases: []TestCase{{ExpectedOutput: "ok"}},
+		FileChanges: &FileChangePolicy{Allowed: []string{"out.txt"}}}
+
+	if err := ValidateTaskCatalog(TaskCatalog{Tasks: []Task{task}}); err == nil {
+		t.Fatal("ValidateTaskCatalog() error = nil, want relative pattern rejected")
+	}
+}
@@ -10,23 +10,123 @@ import (
 	"strings"
 
 	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/sandbox"
 )
 
-// DefaultGrader preserves the existing stdout-based scoring contract used by code tasks.
+// DefaultGrader preserves the existing stdout-based scoring contract used by
+// code tasks. Markdown and CSV tables that do not match exactly earn the
+// fraction of cells that do as partial credit.
 type DefaultGrader struct{}
 
+// DefaultGraderVersion changes whenever DefaultGrader would score the same
+// execution differently.
+const DefaultGraderVersion = "default@3"
+
+func (DefaultGrader) Version() string {
+	return DefaultGraderVersion
+}
+
 func (DefaultGrader) Grade(task Task, resp api.ExecutionResponse, tc TestCase) Outcome {
-	passed := compareExpectedOutput(task, resp.Stdout, tc.ExpectedOutput)
+	if task.GradingMode == GradingModeTestsPass {
+		return gradeTestReport(resp)
+	}
+	if task.Interactor != nil {
+		return gradeInteraction(resp)
+	}
 
-	score := 0.0
+	passed := compareExpectedOutput(task, resp.Stdout, tc.ExpectedOutput)
 	if passed {
-		score = 1.0
+		return Outcome{Passed: true, Score: 1}
+	}
+
+	outcome := Outcome{Score: partialCredit(task, resp.Stdout, tc.ExpectedOutput)}
+	if outcome.Score > 0 {
+		outcome.Verdict = VerdictPartial
+	}
+	return outcome
+}
+
+// partialCredit scores a table that is not exactly right by its share of
+// cells that match in place.
+func partialCredit(task Task, actual, expected string) float64 {
+	if task.ArtifactExpectation == nil {
+		return 0
+	}
+
+	var actualRows, expectedRows [][]string
+	var ok bool
+	switch task.ArtifactExpectation.Format {
+	case "markdown":
+		if actualRows, ok = parseMarkdownTable(actual); !ok {
+			return 0
+		}
+		if expectedRows, ok = parseMarkdownTable(expected); !ok {
+			return 0
+		}
+	case "csv":
+		var err error
+		if actualRows, err = parseCSV(actual); err != nil {
+			return 0
+		}
+		if expectedRows, err = parseCSV(expected); err != nil {
+			return 0
+		}
+	default:
+		return 0
+	}
+	return cellAccuracy(actualRows, expectedRows)
+}
+
+// cellAccuracy is the share of cells that match in place. Missing and extra
+// rows and cells count against it.
+func cellAccuracy(actual, expected [][]string) float64 {
+	matched, total := 0, 0
+	for i := range max(len(actual), len(expected)) {
+		var actualRow, expectedRow []string
+		if i < len(actual) {
+			actualRow = actual[i]
+		}
+		if i < len(expected) {
+			expectedRow = expected[i]
+		}
+		total += max(len(actualRow), len(expectedRow))
+		for j := range min(len(actualRow), len(expectedRow)) {
+			if actualRow[j] == expectedRow[j] {
+				matched++
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(matched) / float64(total)
+}
+
+// gradeInteraction takes the verdict from the interactor's exit code once the
+// submission itself has finished cleanly. The interactor's stdout is the
+// conversation, so a partial-credit score is read from its last line.
+func gradeInteraction(resp api.ExecutionResponse) Outcome {
+	switch {
+	case resp.Error == sandbox.TimeLimitExceeded:
+		return Outcome{Verdict: VerdictTimeLimitExceeded, Message: resp.Error}
+	case resp.Interactor == nil:
+		return Outcome{Verdict: VerdictCheckerError, Message: "interactive execution returned no interactor result"}
+	case resp.Interactor.Error != "":
+		return Outcome{Verdict: VerdictCheckerError, Message: "interactor: " + resp.Interactor.Error}
+	case resp.ExitCode != 0:
+		return Outcome{Verdict: VerdictRuntimeError, Message: fmt.Sprintf("submission exited with code %d", resp.ExitCode)}
 	}
 
-	return Outcome{
-		Passed: passed,
-		Score:  score,
+	stdout := ""
+	if resp.Interactor.ExitCode == 7 {
+		lines := strings.Split(strings.TrimSpace(resp.Interactor.Stdout), "\n")
+		stdout = lines[len(lines)-1]
 	}
+	return interpretCheckerResult(api.ExecutionResponse{
+		ExitCode: resp.Interactor.ExitCode,
+		Stdout:   stdout,
+		Stderr:   resp.Interactor.Stderr,
+	})
 }
 
 func compareExpectedOutput(task Task, actual, expected string) bool {
@@ -0,0 +1,105 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/sandbox"
+)
+
+func TestRunTaskWithInteractorCrossWiresAndRecordsTranscript(t *testing.T) {
+	exec := &fakeInteractiveExecutor{resp: api.ExecutionResponse{
+		Interactor: &api.ExecutionResponse{ExitCode: 0},
+		Transcript: []api.TranscriptEntry{
+			{Source: sandbox.TranscriptInteractor, Data: "100\n"},
+			{Source: sandbox.TranscriptSubmission, Data: "? 50\n"},
+		},
+	}}
+	task := Task{
+		ID:          "guess",
+		Description: "guess the number",
+		Language:    "python",
+		TestCases:   []TestCase{{Input: "42", ExpectedOutput: "3"}},
+		Interactor:  &Interactor{Language: "python", Source: "import sys", TimeoutMS: 900},
+	}
+
+	run := RunTask(context.Background(), task, Scaffold{Name: "baseline"}, RunModeBaseline, &fakeLLMClient{code: "print(1)"}, exec, config.Config{DefaultTimeoutMS: 1234})
+
+	if !run.Passed {
+		t.Fatalf("run = %#v, want pass from interactor verdict", run)
+	}
+	if exec.submission.SourceCode != "print(1)" || exec.submission.Stdin != "" || exec.submission.TimeoutMS != 1234 {
+		t.Fatalf("submission = %#v, want generated code without fixed stdin", exec.submission)
+	}
+	if exec.interactor.SourceCode != "import sys" || exec.interactor.TimeoutMS != 900 {
+		t.Fatalf("interactor = %#v, want task interactor with its own limit", exec.interactor)
+	}
+	if exec.interactor.Files["input.txt"] != "42" || exec.interactor.Files["expected.txt"] != "3" {
+		t.Fatalf("interactor files = %#v, want test case input and expected output", exec.interactor.Files)
+	}
+	if len(run.Trace) != 1 || run.Trace[0].TestCase != 0 || len(run.Trace[0].Transcript) != 2 {
+		t.Fatalf("Trace = %#v, want one transcript for test case 0", run.Trace)
+	}
+	if run.Outcomes[0].Verdict != VerdictAccepted {
+		t.Fatalf("verdict = %q, want accepted", run.Outcomes[0].Verdict)
+	}
+}
+
+func TestRunTaskWithInteractorRequiresInteractiveExecutor(t *testing.T) {
+	task := Task{
+		ID:          "guess",
+		Description: "guess",
+		Language:    "python",
+		TestCases:   []TestCase{{Input: "1"}},
+		Interactor:  &Interactor{Language: "python", Source: "pass"},
+	}
+
+	run := RunTask(context.Background(), task, Scaffold{Name: "baseline"}, RunModeBaseline, &fakeLLMClient{code: "print(1)"}, &fakeExecutor{}, config.Config{})
+
+	if run.Passed || run.Error != errInteractiveUnsupported.Error() {
+		t.Fatalf("run = %#v, want interactive unsupported error", run)
+	}
+}
+
+func TestDefaultGraderGradesInteraction(t *testing.T) {
+	task := Task{Interactor: &Interactor{Language: "python", Source: "pass"}}
+	tests := []struct {
+		name    string
+		resp    api.ExecutionResponse
+		verdict string
+	}{
+		{name: "accepted", resp: api.ExecutionResponse{Interactor: &api.ExecutionResponse{ExitCode: 0}}, verdict: VerdictAccepted},
+		{name: "accepted after a conversation", resp: api.ExecutionResponse{Interactor: &api.ExecutionResponse{ExitCode: 0, Stdout: "? 5\n"}}, verdict: VerdictAccepted},
+		{name: "wrong answer", resp: api.ExecutionResponse{Interactor: &api.ExecutionResponse{ExitCode: 1}}, verdict: VerdictWrongAnswer},
+		{name: "partial credit", resp: api.ExecutionResponse{Interactor: &api.ExecutionResponse{ExitCode: 7, Stdout: "? 5\n? 7\n0.5\n"}}, verdict: VerdictPartial},
+		{name: "partial credit without a score", resp: api.ExecutionResponse{Interactor: &api.ExecutionResponse{ExitCode: 7, Stdout: "? 5\n"}}, verdict: VerdictCheckerError},
+		{name: "submission timeout", resp: api.ExecutionResponse{Error: sandbox.TimeLimitExceeded, Interactor: &api.ExecutionResponse{ExitCode: 0}}, verdict: VerdictTimeLimitExceeded},
+		{name: "submission crash", resp: api.ExecutionResponse{ExitCode: 1, Interactor: &api.ExecutionResponse{ExitCode: 0}}, verdict: VerdictRuntimeError},
+		{name: "interactor timeout", resp: api.ExecutionResponse{Interactor: &api.ExecutionResponse{Error: sandbox.TimeLimitExceeded}}, verdict: VerdictCheckerError},
+		{name: "missing interactor", resp: api.ExecutionResponse{}, verdict: VerdictCheckerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := DefaultGrader{}.Grade(task, tt.resp, TestCase{})
+			if outcome.Verdict != tt.verdict || outcome.Passed != (tt.verdict == VerdictAccepted) {
+				t.Fatalf("outcome = %#v, want verdict %q", outcome, tt.verdict)
+			}
+		})
+	}
+}
+
+type fakeInteractiveExecutor struct {
+	fakeExecutor
+	resp       api.ExecutionResponse
+	submission api.ExecutionRequest
+	interactor api.ExecutionRequest
+}
+
+func (f *fakeInteractiveExecutor) ExecuteInteractive(ctx context.Context, submission api.ExecutionRequest, interactor api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	f.submission = submission
+	f.interactor = interactor
+	return f.resp, nil
+}
@@ -1,5 +1,7 @@
 package benchmark
 
+import "gexec-sandbox/internal/api"
+
 type ArtifactExpectation struct {
 	Type           string `json:"type" yaml:"type"`
 	Format         string `json:"format,omitempty" yaml:"format,omitempty"`
@@ -8,14 +10,53 @@ type ArtifactExpectation struct {
 	ExpectedOutput string `json:"expected_output,omitempty" yaml:"expected_output,omitempty"`
 }
 
+type Checker struct {
+	Language  string `json:"language" yaml:"language"`
+	Source    string `json:"source" yaml:"source"`
+	TimeoutMS int    `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+}
+
+// Interactor converses with the submission over stdin/stdout. It receives the
+// test case input and expected output as file arguments and reports its
+// verdict through the checker exit code convention.
+type Interactor struct {
+	Language  string `json:"language" yaml:"language"`
+	Source    string `json:"source" yaml:"source"`
+	TimeoutMS int    `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+}
+
+const (
+	GradingModeOutput    = "output"
+	GradingModeTestsPass = "tests_pass"
+)
+
+// TestSuite holds hidden test files placed next to the submission when a task
+// is graded in tests_pass mode.
+type TestSuite struct {
+	Files     map[string]string `json:"files" yaml:"files"`
+	TimeoutMS int               `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+}
+
+// FileChangePolicy turns on filesystem diff capture for a task. Any path the
+// submission touches outside the allowed glob patterns fails the test case.
+type FileChangePolicy struct {
+	Allowed []string `json:"allowed,omitempty" yaml:"allowed,omitempty"`
+}
+
 type Task struct {
 	ID                  string               `json:"id"`
 	Title               string               `json:"title"`
 	Description         string               `json:"description"`
 	TaskFamily          string               `json:"task_family"`
 	Language            string               `json:"language"`
+	Tags                []string             `json:"tags,omitempty"`
 	ArtifactExpectation *ArtifactExpectation `json:"artifact_expectation,omitempty"`
 	TestCases           []TestCase           `json:"test_cases"`
+	Checker             *Checker             `json:"checker,omitempty"`
+	Interactor          *Interactor          `json:"interactor,omitempty"`
+	GradingMode         string               `json:"grading_mode,omitempty"`
+	Tests               *TestSuite           `json:"tests,omitempty"`
+	FileChanges         *FileChangePolicy    `json:"file_changes,omitempty"`
 }
 
 type Scaffold struct {
@@ -34,19 +75,58 @@ const (
 )
 
 type Run struct {
-	TaskID   string    `json:"task_id"`
-	ModelID  string    `json:"model_id,omitempty"`
-	Mode     RunMode   `json:"mode"`
-	Scaffold Scaffold  `json:"scaffold"`
-	Passed   bool      `json:"passed"`
-	Outcomes []Outcome `json:"outcomes,omitempty"`
-	Output   string    `json:"output,omitempty"`
-	Error    string    `json:"error,omitempty"`
+	TaskID   string   `json:"task_id"`
+	ModelID  string   `json:"model_id,omitempty"`
+	Mode     RunMode  `json:"mode"`
+	Scaffold Scaffold `json:"scaffold"`
+	// Epoch numbers repeated samples of the same task from zero.
+	Epoch  int  `json:"epoch,omitempty"`
+	Passed bool `json:"passed"`
+	// Score is the mean of the outcome scores: the fraction of test cases
+	// passed, with partial credit where a grader gives it.
+	Score    float64          `json:"score"`
+	Outcomes []Outcome        `json:"outcomes,omitempty"`
+	Trace    []ExecutionTrace `json:"trace,omitempty"`
+	Output   string           `json:"output,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	// Response is the model's raw answer and Code the source extracted from
+	// it. Executions holds each executed test case's result in order, less
+	// the evidence Trace keeps, so the run can be graded again without
+	// calling the model or the sandbox. Only run logs store them.
+	Response   string                  `json:"response,omitempty"`
+	Code       string                  `json:"code,omitempty"`
+	Executions []api.ExecutionResponse `json:"executions,omitempty"`
+	// Grader is the version of the grading logic that scored the run.
+	Grader string `json:"grader,omitempty"`
+}
+
+// ExecutionTrace records sandbox evidence for one executed test case.
+type ExecutionTrace struct {
+	TestCase    int                   `json:"test_case"`
+	Transcript  []api.TranscriptEntry `json:"transcript,omitempty"`
+	FileChanges []api.FileChange      `json:"file_changes,omitempty"`
+	Audit       *api.AuditSummary     `json:"audit,omitempty"`
 }
 
 type Outcome struct {
-	Passed bool    `json:"passed"`
-	Score  float64 `json:"score"`
+	Passed  bool         `json:"passed"`
+	Score   float64      `json:"score"`
+	Verdict string       `json:"verdict,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Tests   []TestResult `json:"tests,omitempty"`
+}
+
+const (
+	TestStatusPassed  = "passed"
+	TestStatusFailed  = "failed"
+	TestStatusSkipped = "skipped"
+)
+
+// TestResult is one test reported by a language test runner.
+type TestResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
 }
 
 func (s Scaffold) ApplyPrompt(prompt string) string {
@@ -0,0 +1,154 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"gexec-sandbox/internal/logging"
+	"gexec-sandbox/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// runJob is one sample of a cell of the benchmark matrix. Jobs are planned
+// in report order: by model, then task, then the baseline before each
+// scaffold, then epoch.
+type runJob struct {
+	model    int
+	task     Task
+	scaffold Scaffold
+	mode     RunMode
+	epoch    int
+}
+
+func (s BenchmarkService) planRuns(models []ModelClient, baselineScaffold Scaffold, scaffoldVariants []Scaffold) []runJob {
+	epochs := s.Config.EpochCount()
+	jobs := make([]runJob, 0, len(models)*len(s.Tasks.Tasks)*(1+len(scaffoldVariants))*epochs)
+	for model := range models {
+		for _, task := range s.Tasks.Tasks {
+			for epoch := range epochs {
+				jobs = append(jobs, runJob{model: model, task: task, scaffold: baselineScaffold, mode: RunModeBaseline, epoch: epoch})
+			}
+			for _, scaffold := range scaffoldVariants {
+				for epoch := range epochs {
+					jobs = append(jobs, runJob{model: model, task: task, scaffold: scaffold, mode: RunModeScaffolded, epoch: epoch})
+				}
+			}
+		}
+	}
+	return jobs
+}
+
+// runPool executes the matrix with up to Config.BenchmarkConcurrency runs in
+// flight, holding each model to its own Concurrency, and returns the runs in
+// planned order. With a run log in opts, runs the log already holds without
+// an error are reused instead of repeated, and each new run is appended to
+// it. Executions are kept only in the log: the returned and observed runs
+// leave them out. Once ctx is done no further runs start and runPool returns
+// ctx's error.
+func (s BenchmarkService) runPool(ctx context.Context, opts RunOptions, models []ModelClient, baselineScaffold Scaffold, scaffoldVariants []Scaffold, grader Grader) ([]Run, error) {
+	jobs := s.planRuns(models, baselineScaffold, scaffoldVariants)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	runs := make([]Run, len(jobs))
+	pending := make([]int, 0, len(jobs))
+	log := opts.Log
+	observer := opts.RunObserver()
+	var logged map[runKey]Run
+	if log != nil {
+		logged = runsByKey(log.Runs())
+	}
+	for i, job := range jobs {
+		// A run that errored, such as on a model outage, is retried rather
+		// than reused.
+		run, ok := logged[runKey{models[job.model].ID, job.task.ID, job.scaffold.Name, job.epoch}]
+		if !ok || run.Error != "" {
+			pending = append(pending, i)
+			continue
+		}
+		run.Executions = nil
+		runs[i] = run
+		observer.RunFinished(run)
+	}
+	if log != nil {
+		slog.InfoContext(ctx, "benchmark resumed from run log", "dir", log.Dir(), "reused", len(jobs)-len(pending))
+	}
+
+	modelCtxs := make([]context.Context, len(models))
+	queues := make([][]int, len(models))
+	for i, model := range models {
+		modelCtx := logging.With(ctx, slog.String(logging.ModelIDKey, model.ID))
+		modelCtx, span := tracing.Start(modelCtx, "benchmark.model", attribute.String("model.id", model.ID))
+		defer span.End()
+		modelCtxs[i] = modelCtx
+	}
+	for _, i := range pending {
+		queues[jobs[i].model] = append(queues[jobs[i].model], i)
+	}
+
+	// Jobs are dispatched only once their model has a free slot, so a model
+	// at its limit never holds up workers that another model could use.
+	workers := max(s.Config.BenchmarkConcurrency, 1)
+	active := make([]int, len(models))
+	running := 0
+	done := make(chan int)
+	for ctx.Err() == nil {
+		model := nextModel(models, queues, active)
+		if model >= 0 && running < workers {
+			i := queues[model][0]
+			queues[model] = queues[model][1:]
+			active[model]++
+			running++
+			go func() {
+				job := jobs[i]
+				taskCtx := logging.With(modelCtxs[job.model], slog.String(logging.TaskIDKey, job.task.ID))
+				run := runLogged(taskCtx, job.task, job.scaffold, job.mode, job.epoch, models[job.model], s.Executor, grader, s.Config)
+				// A run cut short by cancellation is left out so that
+				// resuming repeats it.
+				if log != nil && ctx.Err() == nil {
+					if err := log.Append(run); err != nil {
+						cancel(fmt.Errorf("append to run log: %w", err))
+					}
+				}
+				run.Executions = nil
+				runs[i] = run
+				observer.RunFinished(run)
+				done <- job.model
+			}()
+			continue
+		}
+		if running == 0 {
+			break
+		}
+		select {
+		case model := <-done:
+			active[model]--
+			running--
+		case <-ctx.Done():
+		}
+	}
+	for ; running > 0; running-- {
+		<-done
+	}
+
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	return runs, nil
+}
+
+// nextModel returns the model whose next pending job comes first in the plan
+// among models below their Concurrency, or -1 if none can start a job.
+func nextModel(models []ModelClient, queues [][]int, active []int) int {
+	next := -1
+	for model, queue := range queues {
+		if len(queue) == 0 || (models[model].Concurrency > 0 && active[model] >= models[model].Concurrency) {
+			continue
+		}
+		if next < 0 || queue[0] < queues[next][0] {
+			next = model
+		}
+	}
+	return next
+}
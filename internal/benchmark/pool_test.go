@@ -0,0 +1,184 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+)
+
+// inFlight records the most calls that overlapped, overall and per model.
+type inFlight struct {
+	mu      sync.Mutex
+	now     map[string]int
+	peak    map[string]int
+	total   int
+	maxSeen int
+}
+
+func (f *inFlight) enter(model string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now[model]++
+	f.total++
+	f.peak[model] = max(f.peak[model], f.now[model])
+	f.maxSeen = max(f.maxSeen, f.total)
+}
+
+func (f *inFlight) leave(model string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now[model]--
+	f.total--
+}
+
+type slowLLMClient struct {
+	model    string
+	inFlight *inFlight
+}
+
+func (c slowLLMClient) GenerateCode(ctx context.Context, problem string, language string) (string, error) {
+	c.inFlight.enter(c.model)
+	defer c.inFlight.leave(c.model)
+	time.Sleep(20 * time.Millisecond)
+	return "print('ok')", nil
+}
+
+func poolService(concurrency int, models ...ModelClient) BenchmarkService {
+	var tasks []Task
+	for i := range 3 {
+		tasks = append(tasks, Task{ID: fmt.Sprintf("task-%d", i), Description: "demo", TaskFamily: "software_engineering", Language: "python", TestCases: []TestCase{{ExpectedOutput: "ok"}}})
+	}
+	return BenchmarkService{
+		Tasks: TaskCatalog{Tasks: tasks},
+		Scaffolds: ScaffoldCatalog{Scaffolds: []Scaffold{
+			{Baseline: true, Name: "baseline"},
+			{Name: "tool-assisted", PromptPrefix: "tool: "},
+		}},
+		Models:   models,
+		Executor: benchmarkServiceExecutor{responseBySource: map[string]api.ExecutionResponse{"print('ok')": {Stdout: "ok"}}},
+		Config:   config.Config{BenchmarkConcurrency: concurrency},
+	}
+}
+
+func TestBenchmarkServiceRunExecutesInParallelInReportOrder(t *testing.T) {
+	flight := &inFlight{now: map[string]int{}, peak: map[string]int{}}
+	svc := poolService(4,
+		ModelClient{ID: "alpha", Client: slowLLMClient{"alpha", flight}, Concurrency: 1},
+		ModelClient{ID: "beta", Client: slowLLMClient{"beta", flight}},
+	)
+
+	report, err := svc.Run(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if flight.maxSeen < 2 || flight.maxSeen > 4 {
+		t.Fatalf("peak concurrent runs = %d, want parallel runs capped at 4", flight.maxSeen)
+	}
+	if flight.peak["alpha"] != 1 {
+		t.Fatalf("peak alpha runs = %d, want its limit of 1", flight.peak["alpha"])
+	}
+
+	var got []string
+	for _, run := range report.Runs {
+		got = append(got, run.ModelID+"/"+run.TaskID+"/"+run.Scaffold.Name)
+	}
+	var want []string
+	for _, model := range []string{"alpha", "beta"} {
+		for i := range 3 {
+			want = append(want, fmt.Sprintf("%s/task-%d/baseline", model, i), fmt.Sprintf("%s/task-%d/tool-assisted", model, i))
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("runs = %v, want planned order %v", got, want)
+	}
+}
+
+type cancelAfterRuns struct {
+	ignoreProgress
+	finished atomic.Int32
+	after    int32
+	cancel   context.CancelFunc
+}
+
+func (o *cancelAfterRuns) RunFinished(Run) {
+	if o.finished.Add(1) == o.after {
+		o.cancel()
+	}
+}
+
+func TestBenchmarkServiceRunStopsStartingRunsWhenCanceled(t *testing.T) {
+	flight := &inFlight{now: map[string]int{}, peak: map[string]int{}}
+	svc := poolService(2, ModelClient{ID: "alpha", Client: slowLLMClient{"alpha", flight}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	observer := &cancelAfterRuns{after: 1, cancel: cancel}
+	_, err := svc.Run(ctx, RunOptions{Observer: observer})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	// Only the runs already in flight when the first finished may complete.
+	if n := observer.finished.Load(); n > 2 {
+		t.Fatalf("finished runs = %d, want no new runs after cancellation", n)
+	}
+}
+
+// gatedLLMClient holds each call until release is closed.
+type gatedLLMClient struct {
+	release <-chan struct{}
+	expired *atomic.Bool
+}
+
+func (c gatedLLMClient) GenerateCode(ctx context.Context, problem string, language string) (string, error) {
+	select {
+	case <-c.release:
+	case <-time.After(2 * time.Second):
+		c.expired.Store(true)
+	}
+	return "print('ok')", nil
+}
+
+// countingDoneClient closes done after its nth call returns.
+type countingDoneClient struct {
+	slowLLMClient
+	calls *atomic.Int32
+	n     int32
+	done  chan struct{}
+}
+
+func (c countingDoneClient) GenerateCode(ctx context.Context, problem string, language string) (string, error) {
+	code, err := c.slowLLMClient.GenerateCode(ctx, problem, language)
+	if c.calls.Add(1) == c.n {
+		close(c.done)
+	}
+	return code, err
+}
+
+func TestBenchmarkServiceRunKeepsOtherModelsBusyWhileOneIsAtItsLimit(t *testing.T) {
+	flight := &inFlight{now: map[string]int{}, peak: map[string]int{}}
+	betaDone := make(chan struct{})
+	var expired atomic.Bool
+	var betaCalls atomic.Int32
+	// alpha is planned first and only finishes once beta has run every
+	// task, so beta has to run alongside it rather than queue behind it.
+	svc := poolService(4,
+		ModelClient{ID: "alpha", Client: gatedLLMClient{betaDone, &expired}, Concurrency: 1},
+		ModelClient{ID: "beta", Client: countingDoneClient{slowLLMClient{"beta", flight}, &betaCalls, 6, betaDone}},
+	)
+
+	if _, err := svc.Run(context.Background(), RunOptions{}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if expired.Load() {
+		t.Fatal("alpha waited out its gate, want beta's runs to proceed while alpha is at its limit")
+	}
+	if flight.peak["beta"] < 2 {
+		t.Fatalf("peak beta runs = %d, want beta's runs in parallel", flight.peak["beta"])
+	}
+}
@@ -7,6 +7,9 @@ type FamilySummary struct {
 	BaselineSuccessRate   float64 `json:"baseline_success_rate"`
 	ScaffoldedSuccessRate float64 `json:"scaffolded_success_rate"`
 	Lift                  float64 `json:"lift"`
+	BaselineMeanScore     float64 `json:"baseline_mean_score"`
+	ScaffoldedMeanScore   float64 `json:"scaffolded_mean_score"`
+	ScoreLift             float64 `json:"score_lift"`
 }
 
 type ScaffoldSummary struct {
@@ -14,20 +17,42 @@ type ScaffoldSummary struct {
 	BaselineSuccessRate   float64 `json:"baseline_success_rate"`
 	ScaffoldedSuccessRate float64 `json:"scaffolded_success_rate"`
 	Lift                  float64 `json:"lift"`
+	BaselineMeanScore     float64 `json:"baseline_mean_score"`
+	ScaffoldedMeanScore   float64 `json:"scaffolded_mean_score"`
+	ScoreLift             float64 `json:"score_lift"`
 }
 
 type ModelSummary struct {
-	TotalTasks            int     `json:"total_tasks"`
-	BaselineSuccessRate   float64 `json:"baseline_success_rate"`
-	ScaffoldedSuccessRate float64 `json:"scaffolded_success_rate"`
-	Lift                  float64 `json:"lift"`
-	ScaffoldedScaffold    string  `json:"scaffolded_scaffold,omitempty"`
+	TotalTasks            int            `json:"total_tasks"`
+	BaselineSuccessRate   float64        `json:"baseline_success_rate"`
+	ScaffoldedSuccessRate float64        `json:"scaffolded_success_rate"`
+	Lift                  float64        `json:"lift"`
+	BaselineMeanScore     float64        `json:"baseline_mean_score"`
+	ScaffoldedMeanScore   float64        `json:"scaffolded_mean_score"`
+	ScoreLift             float64        `json:"score_lift"`
+	ScaffoldedScaffold    string         `json:"scaffolded_scaffold,omitempty"`
+	Safety                *SafetySummary `json:"safety,omitempty"`
 }
 
+// SafetySummary counts audited runs whose submission made each kind of
+// notable syscall on any test case.
+type SafetySummary struct {
+	AuditedRuns        int `json:"audited_runs"`
+	NetworkAttemptRuns int `json:"network_attempt_runs"`
+	ProcessSpawnRuns   int `json:"process_spawn_runs"`
+	SensitivePathRuns  int `json:"sensitive_path_runs"`
+	BlockedSyscallRuns int `json:"blocked_syscall_runs"`
+}
+
+// BenchmarkRunGroup's SuccessRate and MeanScore average each task's samples
+// before averaging over tasks, so SuccessRate is pass@1 and a task sampled
+// over several epochs weighs the same as one sampled once. PassedTasks counts
+// tasks that passed on at least one sample.
 type BenchmarkRunGroup struct {
 	Runs        []Run   `json:"runs,omitempty"`
 	PassedTasks int     `json:"passed_tasks"`
 	SuccessRate float64 `json:"success_rate"`
+	MeanScore   float64 `json:"mean_score"`
 }
 
 type BenchmarkScaffoldReport struct {
@@ -37,20 +62,33 @@ type BenchmarkScaffoldReport struct {
 }
 
 type BenchmarkReport struct {
+	// RunID matches the run_id attribute on the run's log lines.
+	RunID string `json:"run_id,omitempty"`
+	// RescoredFrom is the run whose stored runs were graded again for this
+	// report, and Grader the version of the grading logic that scored it.
+	RescoredFrom          string                     `json:"rescored_from,omitempty"`
+	Grader                string                     `json:"grader,omitempty"`
 	TotalTasks            int                        `json:"total_tasks"`
 	TotalModelTasks       int                        `json:"total_model_tasks,omitempty"`
 	BaselineSuccessRate   float64                    `json:"baseline_success_rate"`
 	ScaffoldedSuccessRate float64                    `json:"scaffolded_success_rate"`
 	Lift                  float64                    `json:"lift"`
+	BaselineMeanScore     float64                    `json:"baseline_mean_score"`
+	ScaffoldedMeanScore   float64                    `json:"scaffolded_mean_score"`
+	ScoreLift             float64                    `json:"score_lift"`
 	ScaffoldedScaffold    string                     `json:"scaffolded_scaffold,omitempty"`
 	ByFamily              map[string]FamilySummary   `json:"by_family"`
 	ByScaffold            map[string]ScaffoldSummary `json:"by_scaffold"`
 	ByModel               map[string]ModelSummary    `json:"by_model,omitempty"`
 	DefaultModelRoles     map[string]string          `json:"default_model_roles,omitempty"`
+	Selection             *Selection                 `json:"selection,omitempty"`
 	Runs                  []Run                      `json:"runs"`
 	Baseline              BenchmarkRunGroup          `json:"baseline"`
 	Scaffolded            BenchmarkRunGroup          `json:"scaffolded"`
 	Scaffolds             []BenchmarkScaffoldReport  `json:"scaffolds,omitempty"`
+	Safety                *SafetySummary             `json:"safety,omitempty"`
+	Sampling              *SamplingReport            `json:"sampling,omitempty"`
+	Statistics            *StatisticsReport          `json:"statistics,omitempty"`
 }
 
 func BuildBenchmarkReport(tasks []Task, runs []Run) BenchmarkReport {
@@ -71,49 +109,25 @@ func buildBenchmarkReport(tasks []Task, runs []Run, includeModels bool) Benchmar
 	baselineRuns := make([]Run, 0, len(runs))
 	scaffoldRunsByName := make(map[string][]Run)
 	scaffoldTasksByName := make(map[string]map[string]struct{})
-	scaffoldPassedByName := make(map[string]map[string]struct{})
-
-	baselinePassedByTask := make(map[string]bool)
-	bestScaffoldName := ""
-	bestScaffoldSuccess := -1.0
-	bestScaffoldPassedByTask := make(map[string]bool)
-	bestScaffoldRuns := []Run(nil)
 
 	for _, run := range runs {
 		switch run.Mode {
 		case RunModeBaseline:
 			baselineRuns = append(baselineRuns, run)
-			if run.Passed {
-				baselinePassedByTask[runIdentity(run)] = true
-			}
 		case RunModeScaffolded:
 			scaffoldRunsByName[run.Scaffold.Name] = append(scaffoldRunsByName[run.Scaffold.Name], run)
 			if scaffoldTasksByName[run.Scaffold.Name] == nil {
 				scaffoldTasksByName[run.Scaffold.Name] = map[string]struct{}{}
 			}
 			scaffoldTasksByName[run.Scaffold.Name][runIdentity(run)] = struct{}{}
-			if run.Passed {
-				if scaffoldPassedByName[run.Scaffold.Name] == nil {
-					scaffoldPassedByName[run.Scaffold.Name] = map[string]struct{}{}
-				}
-				scaffoldPassedByName[run.Scaffold.Name][runIdentity(run)] = struct{}{}
-			}
 		}
 	}
 
 	report.Baseline = buildBenchmarkRunGroup(totalModelTasks, baselineRuns)
 	report.BaselineSuccessRate = report.Baseline.SuccessRate
-
-	for _, task := range tasks {
-		summary := report.ByFamily[task.TaskFamily]
-		summary.TotalTasks += len(modelIDs)
-		for _, modelID := range modelIDs {
-			if baselinePassedByTask[modelTaskIdentity(modelID, task.ID)] {
-				summary.BaselineSuccessRate++
-			}
-		}
-		report.ByFamily[task.TaskFamily] = summary
-	}
+	report.BaselineMeanScore = report.Baseline.MeanScore
+	baselinePasses := passRatesByTask(baselineRuns)
+	baselineScores := scoresByTask(baselineRuns)
 
 	scaffoldNames := make([]string, 0, len(scaffoldTasksByName))
 	for name := range scaffoldTasksByName {
@@ -121,31 +135,32 @@ func buildBenchmarkReport(tasks []Task, runs []Run, includeModels bool) Benchmar
 	}
 	sort.Strings(scaffoldNames)
 
+	bestScaffoldName := ""
+	bestScaffoldSuccess := -1.0
+	bestScaffoldRuns := []Run(nil)
 	report.Scaffolds = make([]BenchmarkScaffoldReport, 0, len(scaffoldNames))
 	for _, name := range scaffoldNames {
 		totalTasks := len(scaffoldTasksByName[name])
-		baselinePassed := 0
-		scaffoldPassed := len(scaffoldPassedByName[name])
+		scaffoldGroup := buildBenchmarkRunGroup(totalTasks, scaffoldRunsByName[name])
+		baselineRate, baselineScore := 0.0, 0.0
 		for taskID := range scaffoldTasksByName[name] {
-			if baselinePassedByTask[taskID] {
-				baselinePassed++
-			}
-		}
-
-		scaffoldGroup := BenchmarkRunGroup{
-			Runs:        scaffoldRunsByName[name],
-			PassedTasks: scaffoldPassed,
+			baselineRate += baselinePasses[taskID]
+			baselineScore += baselineScores[taskID]
 		}
 		if totalTasks > 0 {
-			scaffoldGroup.SuccessRate = float64(scaffoldPassed) / float64(totalTasks)
+			baselineRate /= float64(totalTasks)
+			baselineScore /= float64(totalTasks)
 		}
 
-		lift := rate(scaffoldPassed, totalTasks) - rate(baselinePassed, totalTasks)
+		lift := scaffoldGroup.SuccessRate - baselineRate
 		report.ByScaffold[name] = ScaffoldSummary{
 			TotalTasks:            totalTasks,
-			BaselineSuccessRate:   rate(baselinePassed, totalTasks),
-			ScaffoldedSuccessRate: rate(scaffoldPassed, totalTasks),
+			BaselineSuccessRate:   baselineRate,
+			ScaffoldedSuccessRate: scaffoldGroup.SuccessRate,
 			Lift:                  lift,
+			BaselineMeanScore:     baselineScore,
+			ScaffoldedMeanScore:   scaffoldGroup.MeanScore,
+			ScoreLift:             scaffoldGroup.MeanScore - baselineScore,
 		}
 		scaffoldReport := BenchmarkScaffoldReport{
 			Scaffold: scaffoldRunsByName[name][0].Scaffold,
@@ -157,35 +172,44 @@ func buildBenchmarkReport(tasks []Task, runs []Run, includeModels bool) Benchmar
 		if bestScaffoldName == "" || scaffoldGroup.SuccessRate > bestScaffoldSuccess || (scaffoldGroup.SuccessRate == bestScaffoldSuccess && name < bestScaffoldName) {
 			bestScaffoldName = name
 			bestScaffoldSuccess = scaffoldGroup.SuccessRate
-			bestScaffoldPassedByTask = make(map[string]bool, len(scaffoldPassedByName[name]))
-			for taskID := range scaffoldPassedByName[name] {
-				bestScaffoldPassedByTask[taskID] = true
-			}
 			bestScaffoldRuns = scaffoldRunsByName[name]
 		}
 	}
 
+	// A run of the baseline alone has nothing to measure a lift against.
 	report.ScaffoldedScaffold = bestScaffoldName
 	report.Scaffolded = buildBenchmarkRunGroup(totalModelTasks, bestScaffoldRuns)
 	report.ScaffoldedSuccessRate = report.Scaffolded.SuccessRate
-	report.Lift = report.ScaffoldedSuccessRate - report.BaselineSuccessRate
+	report.ScaffoldedMeanScore = report.Scaffolded.MeanScore
+	if bestScaffoldName != "" {
+		report.Lift = report.ScaffoldedSuccessRate - report.BaselineSuccessRate
+		report.ScoreLift = report.ScaffoldedMeanScore - report.BaselineMeanScore
+	}
+	bestScaffoldPasses := passRatesByTask(bestScaffoldRuns)
+	bestScaffoldScores := scoresByTask(bestScaffoldRuns)
 
+	for _, task := range tasks {
+		summary := report.ByFamily[task.TaskFamily]
+		summary.TotalTasks += len(modelIDs)
+		for _, modelID := range modelIDs {
+			id := modelTaskIdentity(modelID, task.ID)
+			summary.BaselineSuccessRate += baselinePasses[id]
+			summary.ScaffoldedSuccessRate += bestScaffoldPasses[id]
+			summary.BaselineMeanScore += baselineScores[id]
+			summary.ScaffoldedMeanScore += bestScaffoldScores[id]
+		}
+		report.ByFamily[task.TaskFamily] = summary
+	}
 	for family, summary := range report.ByFamily {
-		total := float64(summary.TotalTasks)
-		if total > 0 {
-			baselineRate := summary.BaselineSuccessRate / total
-			scaffoldPassed := 0
-			for _, task := range tasks {
-				for _, modelID := range modelIDs {
-					if task.TaskFamily == family && bestScaffoldPassedByTask[modelTaskIdentity(modelID, task.ID)] {
-						scaffoldPassed++
-					}
-				}
-			}
-			scaffoldRate := float64(scaffoldPassed) / total
-			summary.BaselineSuccessRate = baselineRate
-			summary.ScaffoldedSuccessRate = scaffoldRate
+		if total := float64(summary.TotalTasks); total > 0 {
+			summary.BaselineSuccessRate /= total
+			summary.ScaffoldedSuccessRate /= total
+			summary.BaselineMeanScore /= total
+			summary.ScaffoldedMeanScore /= total
+		}
+		if bestScaffoldName != "" {
 			summary.Lift = summary.ScaffoldedSuccessRate - summary.BaselineSuccessRate
+			summary.ScoreLift = summary.ScaffoldedMeanScore - summary.BaselineMeanScore
 		}
 		report.ByFamily[family] = summary
 	}
@@ -193,10 +217,47 @@ func buildBenchmarkReport(tasks []Task, runs []Run, includeModels bool) Benchmar
 	if includeModels {
 		report.ByModel = buildModelSummaries(tasks, runs)
 	}
+	report.Safety = buildSafetySummary(runs)
 
 	return report
 }
 
+func buildSafetySummary(runs []Run) *SafetySummary {
+	summary := SafetySummary{}
+	for _, run := range runs {
+		var network, spawns, sensitive, blocked, audited bool
+		for _, trace := range run.Trace {
+			if trace.Audit == nil {
+				continue
+			}
+			audited = true
+			network = network || trace.Audit.NetworkAttempts > 0
+			spawns = spawns || trace.Audit.ProcessSpawns > 0
+			sensitive = sensitive || trace.Audit.SensitivePaths > 0
+			blocked = blocked || trace.Audit.Blocked > 0
+		}
+		if !audited {
+			continue
+		}
+		summary.AuditedRuns++
+		summary.NetworkAttemptRuns += boolCount(network)
+		summary.ProcessSpawnRuns += boolCount(spawns)
+		summary.SensitivePathRuns += boolCount(sensitive)
+		summary.BlockedSyscallRuns += boolCount(blocked)
+	}
+	if summary.AuditedRuns == 0 {
+		return nil
+	}
+	return &summary
+}
+
+func boolCount(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
 func buildBenchmarkRunGroup(totalTasks int, runs []Run) BenchmarkRunGroup {
 	passedTaskIDs := map[string]struct{}{}
 	for _, run := range runs {
@@ -205,15 +266,12 @@ func buildBenchmarkRunGroup(totalTasks int, runs []Run) BenchmarkRunGroup {
 		}
 	}
 
-	group := BenchmarkRunGroup{
+	return BenchmarkRunGroup{
 		Runs:        runs,
 		PassedTasks: len(passedTaskIDs),
+		SuccessRate: meanTaskScore(passRatesByTask(runs), totalTasks),
+		MeanScore:   meanTaskScore(scoresByTask(runs), totalTasks),
 	}
-	if totalTasks > 0 {
-		group.SuccessRate = float64(group.PassedTasks) / float64(totalTasks)
-	}
-
-	return group
 }
 
 func buildModelSummaries(tasks []Task, runs []Run) map[string]ModelSummary {
@@ -235,7 +293,11 @@ func buildModelSummaries(tasks []Task, runs []Run) map[string]ModelSummary {
 			BaselineSuccessRate:   modelReport.BaselineSuccessRate,
 			ScaffoldedSuccessRate: modelReport.ScaffoldedSuccessRate,
 			Lift:                  modelReport.Lift,
+			BaselineMeanScore:     modelReport.BaselineMeanScore,
+			ScaffoldedMeanScore:   modelReport.ScaffoldedMeanScore,
+			ScoreLift:             modelReport.ScoreLift,
 			ScaffoldedScaffold:    modelReport.ScaffoldedScaffold,
+			Safety:                modelReport.Safety,
 		}
 	}
 	return summaries
@@ -265,9 +327,39 @@ func modelTaskIdentity(modelID string, taskID string) string {
 	return modelID + "\x00" + taskID
 }
 
-func rate(passed int, total int) float64 {
+// scoresByTask averages the run scores of each model and task.
+func scoresByTask(runs []Run) map[string]float64 {
+	return averageByTask(runs, func(run Run) float64 { return run.Score })
+}
+
+// passRatesByTask is the fraction of each model and task's samples that
+// passed.
+func passRatesByTask(runs []Run) map[string]float64 {
+	return averageByTask(runs, func(run Run) float64 { return float64(boolCount(run.Passed)) })
+}
+
+func averageByTask(runs []Run, value func(Run) float64) map[string]float64 {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	for _, run := range runs {
+		sums[runIdentity(run)] += value(run)
+		counts[runIdentity(run)]++
+	}
+	for id, n := range counts {
+		sums[id] /= float64(n)
+	}
+	return sums
+}
+
+// meanTaskScore averages per-task values over total tasks, counting tasks
+// without runs as zero.
+func meanTaskScore(scores map[string]float64, total int) float64 {
 	if total == 0 {
 		return 0
 	}
-	return float64(passed) / float64(total)
+	sum := 0.0
+	for _, score := range scores {
+		sum += score
+	}
+	return sum / float64(total)
 }
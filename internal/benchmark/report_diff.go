@@ -0,0 +1,142 @@
+package benchmark
+
+import (
+	"maps"
+	"slices"
+	"sort"
+)
+
+const (
+	RunChangeFixed     = "fixed"
+	RunChangeRegressed = "regressed"
+	RunChangeAdded     = "added"
+	RunChangeRemoved   = "removed"
+)
+
+// MetricDiff compares one rate or mean score between two reports. Name is the
+// metric's path in the report, such as "lift" or
+// "by_family.finance_workflows.lift".
+type MetricDiff struct {
+	Name   string  `json:"name"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Delta  float64 `json:"delta"`
+}
+
+// RunChange is a run whose outcome differs between two reports, or that only
+// one of them made.
+type RunChange struct {
+	ModelID  string `json:"model_id,omitempty"`
+	TaskID   string `json:"task_id"`
+	Scaffold string `json:"scaffold"`
+	Epoch    int    `json:"epoch,omitempty"`
+	Change   string `json:"change"`
+}
+
+// ReportDiff is what changed from one benchmark report to another. Groups
+// such as families appear in Metrics only when both reports have them.
+type ReportDiff struct {
+	Before  string       `json:"before,omitempty"`
+	After   string       `json:"after,omitempty"`
+	Metrics []MetricDiff `json:"metrics"`
+	Changes []RunChange  `json:"changes"`
+}
+
+// DiffReports compares after against before.
+func DiffReports(before BenchmarkReport, after BenchmarkReport) ReportDiff {
+	diff := ReportDiff{Before: before.RunID, After: after.RunID, Changes: []RunChange{}}
+
+	diff.addRates("", successRates{before.BaselineSuccessRate, before.ScaffoldedSuccessRate, before.Lift, before.BaselineMeanScore, before.ScaffoldedMeanScore, before.ScoreLift},
+		successRates{after.BaselineSuccessRate, after.ScaffoldedSuccessRate, after.Lift, after.BaselineMeanScore, after.ScaffoldedMeanScore, after.ScoreLift})
+	for _, name := range sharedKeys(before.ByFamily, after.ByFamily) {
+		b, a := before.ByFamily[name], after.ByFamily[name]
+		diff.addRates("by_family."+name+".", successRates{b.BaselineSuccessRate, b.ScaffoldedSuccessRate, b.Lift, b.BaselineMeanScore, b.ScaffoldedMeanScore, b.ScoreLift},
+			successRates{a.BaselineSuccessRate, a.ScaffoldedSuccessRate, a.Lift, a.BaselineMeanScore, a.ScaffoldedMeanScore, a.ScoreLift})
+	}
+	for _, name := range sharedKeys(before.ByScaffold, after.ByScaffold) {
+		b, a := before.ByScaffold[name], after.ByScaffold[name]
+		diff.addRates("by_scaffold."+name+".", successRates{b.BaselineSuccessRate, b.ScaffoldedSuccessRate, b.Lift, b.BaselineMeanScore, b.ScaffoldedMeanScore, b.ScoreLift},
+			successRates{a.BaselineSuccessRate, a.ScaffoldedSuccessRate, a.Lift, a.BaselineMeanScore, a.ScaffoldedMeanScore, a.ScoreLift})
+	}
+	for _, name := range sharedKeys(before.ByModel, after.ByModel) {
+		b, a := before.ByModel[name], after.ByModel[name]
+		diff.addRates("by_model."+name+".", successRates{b.BaselineSuccessRate, b.ScaffoldedSuccessRate, b.Lift, b.BaselineMeanScore, b.ScaffoldedMeanScore, b.ScoreLift},
+			successRates{a.BaselineSuccessRate, a.ScaffoldedSuccessRate, a.Lift, a.BaselineMeanScore, a.ScaffoldedMeanScore, a.ScoreLift})
+	}
+
+	beforeRuns := runsByKey(before.Runs)
+	afterRuns := runsByKey(after.Runs)
+	for key, b := range beforeRuns {
+		a, ok := afterRuns[key]
+		switch {
+		case !ok:
+			diff.Changes = append(diff.Changes, key.change(RunChangeRemoved))
+		case !b.Passed && a.Passed:
+			diff.Changes = append(diff.Changes, key.change(RunChangeFixed))
+		case b.Passed && !a.Passed:
+			diff.Changes = append(diff.Changes, key.change(RunChangeRegressed))
+		}
+	}
+	for key := range afterRuns {
+		if _, ok := beforeRuns[key]; !ok {
+			diff.Changes = append(diff.Changes, key.change(RunChangeAdded))
+		}
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		x, y := diff.Changes[i], diff.Changes[j]
+		if x.ModelID != y.ModelID {
+			return x.ModelID < y.ModelID
+		}
+		if x.TaskID != y.TaskID {
+			return x.TaskID < y.TaskID
+		}
+		if x.Scaffold != y.Scaffold {
+			return x.Scaffold < y.Scaffold
+		}
+		return x.Epoch < y.Epoch
+	})
+	return diff
+}
+
+type successRates struct {
+	baseline, scaffolded, lift                float64
+	baselineScore, scaffoldedScore, scoreLift float64
+}
+
+func (d *ReportDiff) addRates(prefix string, before successRates, after successRates) {
+	d.Metrics = append(d.Metrics,
+		MetricDiff{Name: prefix + "baseline_success_rate", Before: before.baseline, After: after.baseline, Delta: after.baseline - before.baseline},
+		MetricDiff{Name: prefix + "scaffolded_success_rate", Before: before.scaffolded, After: after.scaffolded, Delta: after.scaffolded - before.scaffolded},
+		MetricDiff{Name: prefix + "lift", Before: before.lift, After: after.lift, Delta: after.lift - before.lift},
+		MetricDiff{Name: prefix + "baseline_mean_score", Before: before.baselineScore, After: after.baselineScore, Delta: after.baselineScore - before.baselineScore},
+		MetricDiff{Name: prefix + "scaffolded_mean_score", Before: before.scaffoldedScore, After: after.scaffoldedScore, Delta: after.scaffoldedScore - before.scaffoldedScore},
+		MetricDiff{Name: prefix + "score_lift", Before: before.scoreLift, After: after.scoreLift, Delta: after.scoreLift - before.scoreLift},
+	)
+}
+
+type runKey struct {
+	model, task, scaffold string
+	epoch                 int
+}
+
+func (k runKey) change(change string) RunChange {
+	return RunChange{ModelID: k.model, TaskID: k.task, Scaffold: k.scaffold, Epoch: k.epoch, Change: change}
+}
+
+func runsByKey(runs []Run) map[runKey]Run {
+	byKey := make(map[runKey]Run, len(runs))
+	for _, run := range runs {
+		byKey[runKey{run.ModelID, run.TaskID, run.Scaffold.Name, run.Epoch}] = run
+	}
+	return byKey
+}
+
+func sharedKeys[V any](a map[string]V, b map[string]V) []string {
+	var keys []string
+	for _, key := range slices.Sorted(maps.Keys(a)) {
+		if _, ok := b[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
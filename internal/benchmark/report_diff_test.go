@@ -0,0 +1,62 @@
+package benchmark
+
+import "testing"
+
+func TestDiffReportsComparesRatesAndRunOutcomes(t *testing.T) {
+	baseline := Scaffold{Baseline: true, Name: "baseline"}
+	before := BenchmarkReport{
+		RunID:               "old",
+		BaselineSuccessRate: 0.5,
+		Lift:                0.25,
+		ScoreLift:           0.5,
+		ByFamily:            map[string]FamilySummary{"finance": {Lift: 0.5}, "support": {}},
+		Runs: []Run{
+			{ModelID: "m", TaskID: "a", Scaffold: baseline, Passed: false},
+			{ModelID: "m", TaskID: "b", Scaffold: baseline, Passed: true},
+			{ModelID: "m", TaskID: "c", Scaffold: baseline, Passed: true},
+		},
+	}
+	after := BenchmarkReport{
+		RunID:               "new",
+		BaselineSuccessRate: 0.75,
+		Lift:                0.25,
+		ScoreLift:           0.375,
+		ByFamily:            map[string]FamilySummary{"finance": {Lift: 0.25}},
+		Runs: []Run{
+			{ModelID: "m", TaskID: "a", Scaffold: baseline, Passed: true},
+			{ModelID: "m", TaskID: "b", Scaffold: baseline, Passed: false},
+			{ModelID: "m", TaskID: "d", Scaffold: baseline, Passed: true},
+		},
+	}
+
+	diff := DiffReports(before, after)
+	if diff.Before != "old" || diff.After != "new" {
+		t.Fatalf("diff run IDs = %q, %q, want old and new", diff.Before, diff.After)
+	}
+	metrics := map[string]MetricDiff{}
+	for _, metric := range diff.Metrics {
+		metrics[metric.Name] = metric
+	}
+	if got := metrics["baseline_success_rate"]; got.Before != 0.5 || got.After != 0.75 || got.Delta != 0.25 {
+		t.Fatalf("baseline_success_rate = %+v, want 0.5 -> 0.75", got)
+	}
+	if got := metrics["score_lift"]; got.Before != 0.5 || got.After != 0.375 || got.Delta != -0.125 {
+		t.Fatalf("score_lift = %+v, want 0.5 -> 0.375", got)
+	}
+	if got := metrics["by_family.finance.lift"]; got.Delta != -0.25 {
+		t.Fatalf("by_family.finance.lift = %+v, want delta -0.25", got)
+	}
+	if _, ok := metrics["by_family.support.lift"]; ok {
+		t.Fatal("diff compares a family only one report has")
+	}
+
+	want := []string{"a:fixed", "b:regressed", "c:removed", "d:added"}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("changes = %+v, want %v", diff.Changes, want)
+	}
+	for i, change := range diff.Changes {
+		if got := change.TaskID + ":" + change.Change; got != want[i] {
+			t.Fatalf("change %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
@@ -3,6 +3,8 @@ package benchmark
 import (
 	"encoding/json"
 	"testing"
+
+	"gexec-sandbox/internal/api"
 )
 
 func TestBenchmarkReportCarriesFamilyAndScaffoldBreakdowns(t *testing.T) {
@@ -227,3 +229,100 @@ func TestBuildBenchmarkReportComputesFamilyAndScaffoldSummaries(t *testing.T) {
 		t.Fatalf("scaffold Lift = %v, want 0.5", scaffold.Lift)
 	}
 }
+
+func TestBuildBenchmarkReportAveragesPartialScores(t *testing.T) {
+	tasks := []Task{
+		{ID: "task-1", TaskFamily: "data"},
+		{ID: "task-2", TaskFamily: "data"},
+	}
+	runs := []Run{
+		{ModelID: "m", TaskID: "task-1", Mode: RunModeBaseline, Scaffold: Scaffold{Name: "baseline", Baseline: true}, Score: 0.5},
+		{ModelID: "m", TaskID: "task-1", Mode: RunModeBaseline, Scaffold: Scaffold{Name: "baseline", Baseline: true}, Epoch: 1, Score: 0.25},
+		{ModelID: "m", TaskID: "task-2", Mode: RunModeBaseline, Scaffold: Scaffold{Name: "baseline", Baseline: true}, Score: 0},
+		{ModelID: "m", TaskID: "task-1", Mode: RunModeScaffolded, Scaffold: Scaffold{Name: "tool-assisted"}, Passed: true, Score: 1},
+		{ModelID: "m", TaskID: "task-2", Mode: RunModeScaffolded, Scaffold: Scaffold{Name: "tool-assisted"}, Score: 0.5},
+	}
+
+	report := buildBenchmarkReport(tasks, runs, true)
+
+	// Task 1 averages its two baseline epochs to 0.375.
+	if report.BaselineMeanScore != 0.1875 || report.ScaffoldedMeanScore != 0.75 || report.ScoreLift != 0.5625 {
+		t.Fatalf("mean scores = %v/%v lift %v, want 0.1875/0.75 lift 0.5625", report.BaselineMeanScore, report.ScaffoldedMeanScore, report.ScoreLift)
+	}
+	if report.Baseline.MeanScore != 0.1875 || report.Scaffolded.MeanScore != 0.75 {
+		t.Fatalf("group mean scores = %v/%v, want 0.1875/0.75", report.Baseline.MeanScore, report.Scaffolded.MeanScore)
+	}
+	if got := report.ByFamily["data"]; got.BaselineMeanScore != 0.1875 || got.ScaffoldedMeanScore != 0.75 {
+		t.Fatalf("ByFamily[data] = %+v, want mean scores 0.1875/0.75", got)
+	}
+	if got := report.ByScaffold["tool-assisted"]; got.BaselineMeanScore != 0.1875 || got.ScoreLift != 0.5625 {
+		t.Fatalf("ByScaffold[tool-assisted] = %+v, want baseline 0.1875 lift 0.5625", got)
+	}
+	if got := report.ByModel["m"]; got.ScaffoldedMeanScore != 0.75 || got.ScoreLift != 0.5625 {
+		t.Fatalf("ByModel[m] = %+v, want scaffolded 0.75 lift 0.5625", got)
+	}
+}
+
+func TestBuildBenchmarkReportRatesAreMeansOverSamples(t *testing.T) {
+	tasks := []Task{
+		{ID: "task-1", TaskFamily: "data"},
+		{ID: "task-2", TaskFamily: "data"},
+	}
+	baseline := Scaffold{Name: "baseline", Baseline: true}
+	tool := Scaffold{Name: "tool-assisted"}
+	var runs []Run
+	// Baseline passes task 1 on one of four samples; the scaffold passes it
+	// on three and task 2 on two.
+	for epoch := range 4 {
+		runs = append(runs,
+			Run{ModelID: "m", TaskID: "task-1", Mode: RunModeBaseline, Scaffold: baseline, Epoch: epoch, Passed: epoch == 0},
+			Run{ModelID: "m", TaskID: "task-2", Mode: RunModeBaseline, Scaffold: baseline, Epoch: epoch},
+			Run{ModelID: "m", TaskID: "task-1", Mode: RunModeScaffolded, Scaffold: tool, Epoch: epoch, Passed: epoch < 3},
+			Run{ModelID: "m", TaskID: "task-2", Mode: RunModeScaffolded, Scaffold: tool, Epoch: epoch, Passed: epoch < 2},
+		)
+	}
+
+	report := buildBenchmarkReport(tasks, runs, true)
+
+	if report.BaselineSuccessRate != 0.125 || report.ScaffoldedSuccessRate != 0.625 || report.Lift != 0.5 {
+		t.Fatalf("rates = %v/%v lift %v, want pass@1 of 0.125/0.625 lift 0.5", report.BaselineSuccessRate, report.ScaffoldedSuccessRate, report.Lift)
+	}
+	if report.Baseline.PassedTasks != 1 || report.Scaffolded.PassedTasks != 2 {
+		t.Fatalf("passed tasks = %d/%d, want tasks passed on any sample 1/2", report.Baseline.PassedTasks, report.Scaffolded.PassedTasks)
+	}
+	if got := report.ByFamily["data"]; got.BaselineSuccessRate != 0.125 || got.ScaffoldedSuccessRate != 0.625 || got.Lift != 0.5 {
+		t.Fatalf("ByFamily[data] = %+v, want 0.125/0.625 lift 0.5", got)
+	}
+	if got := report.ByScaffold["tool-assisted"]; got.BaselineSuccessRate != 0.125 || got.ScaffoldedSuccessRate != 0.625 || got.Lift != 0.5 {
+		t.Fatalf("ByScaffold[tool-assisted] = %+v, want 0.125/0.625 lift 0.5", got)
+	}
+	if got := report.ByModel["m"]; got.BaselineSuccessRate != 0.125 || got.Lift != 0.5 {
+		t.Fatalf("ByModel[m] = %+v, want baseline 0.125 lift 0.5", got)
+	}
+}
+
+func TestBuildBenchmarkReportSummarizesAuditedRuns(t *testing.T) {
+	tasks := []Task{{ID: "a", TaskFamily: "f"}, {ID: "b", TaskFamily: "f"}}
+	runs := []Run{
+		{TaskID: "a", ModelID: "m", Mode: RunModeBaseline, Trace: []ExecutionTrace{
+			{TestCase: 0, Audit: &api.AuditSummary{}},
+			{TestCase: 1, Audit: &api.AuditSummary{NetworkAttempts: 2, Blocked: 1}},
+		}},
+		{TaskID: "b", ModelID: "m", Mode: RunModeBaseline, Trace: []ExecutionTrace{
+			{TestCase: 0, Audit: &api.AuditSummary{ProcessSpawns: 1, SensitivePaths: 1}},
+		}},
+	}
+
+	report := BuildBenchmarkReport(tasks, runs)
+
+	want := SafetySummary{AuditedRuns: 2, NetworkAttemptRuns: 1, ProcessSpawnRuns: 1, SensitivePathRuns: 1, BlockedSyscallRuns: 1}
+	if report.Safety == nil || *report.Safety != want {
+		t.Fatalf("Safety = %+v, want %+v", report.Safety, want)
+	}
+	if got := report.ByModel["m"].Safety; got == nil || *got != want {
+		t.Fatalf("ByModel[m].Safety = %+v, want %+v", got, want)
+	}
+	if BuildBenchmarkReport(tasks, []Run{{TaskID: "a", Mode: RunModeBaseline}}).Safety != nil {
+		t.Fatal("Safety != nil for a report without audited runs")
+	}
+}
@@ -0,0 +1,232 @@
+package benchmark
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/logging"
+	"gexec-sandbox/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Names NamedGrader accepts.
+const (
+	GraderNameDefault = "default"
+	GraderNameChecker = "checker"
+)
+
+var (
+	// ErrUnknownGrader is returned for a grader name NamedGrader does not
+	// know.
+	ErrUnknownGrader = errors.New("unknown grader")
+	// ErrNotRescorable is returned for a run that cannot be graded again,
+	// such as one stored before runs kept their executions.
+	ErrNotRescorable = errors.New("run cannot be rescored")
+)
+
+// VersionedGrader reports the version of its grading logic, which runs and
+// reports record so scores from different logic are told apart.
+type VersionedGrader interface {
+	Grader
+	Version() string
+}
+
+// GraderVersion returns grader's version, or its type name when it has none.
+func GraderVersion(grader Grader) string {
+	if versioned, ok := grader.(VersionedGrader); ok {
+		return versioned.Version()
+	}
+	return fmt.Sprintf("%T", grader)
+}
+
+// GraderNames lists the graders NamedGrader knows.
+func GraderNames() []string {
+	return []string{GraderNameDefault, GraderNameChecker}
+}
+
+// NamedGrader returns the grader called name. The checker grader runs task
+// checkers with exec.
+func NamedGrader(name string, exec Executor, cfg config.Config) (Grader, error) {
+	switch name {
+	case GraderNameDefault:
+		return DefaultGrader{}, nil
+	case GraderNameChecker:
+		return NewCheckerGrader(exec, cfg), nil
+	}
+	return nil, fmt.Errorf("%w %q: want one of %s", ErrUnknownGrader, name, strings.Join(GraderNames(), ", "))
+}
+
+// RescoreRequest names the grader to rescore with; empty means the service's
+// own.
+type RescoreRequest struct {
+	Grader string `json:"grader,omitempty"`
+}
+
+// Rescorer grades stored runs again. BenchmarkService implements it.
+type Rescorer interface {
+	Rescore(ctx context.Context, runs []Run, grader string, opts RunOptions) (BenchmarkReport, error)
+}
+
+// Rescore grades runs again from their stored executions and reports them,
+// without calling a model or running a submission. grader names the grader
+// to use, or is empty for the service's. The report covers the manifest
+// tasks the runs include, and a run repeated in runs counts once. Of opts,
+// only RunID and Selection apply.
+func (s BenchmarkService) Rescore(ctx context.Context, runs []Run, grader string, opts RunOptions) (BenchmarkReport, error) {
+	g := s.Grader
+	if grader != "" {
+		var err error
+		if g, err = NamedGrader(grader, s.Executor, s.Config); err != nil {
+			return BenchmarkReport{}, err
+		}
+	}
+	if g == nil {
+		g = DefaultGrader{}
+	}
+
+	runID := opts.ID()
+	ctx = logging.With(ctx, slog.String(logging.RunIDKey, runID))
+	ctx, span := tracing.Start(ctx, "benchmark.rescore",
+		attribute.String("run.id", runID),
+		attribute.String("grader", GraderVersion(g)),
+	)
+	report, err := s.rescore(ctx, opts.Selection, runs, g)
+	tracing.End(span, err)
+	if err != nil {
+		return BenchmarkReport{}, err
+	}
+	report.RunID = runID
+	return report, nil
+}
+
+func (s BenchmarkService) rescore(ctx context.Context, selection Selection, runs []Run, grader Grader) (BenchmarkReport, error) {
+	started := time.Now()
+	tasksByID := make(map[string]Task, len(s.Tasks.Tasks))
+	for _, task := range s.Tasks.Tasks {
+		tasksByID[task.ID] = task
+	}
+
+	rescored := make([]Run, 0, len(runs))
+	covered := map[string]bool{}
+	for _, run := range runsByKey(runs) {
+		if err := ctx.Err(); err != nil {
+			return BenchmarkReport{}, err
+		}
+		task, ok := tasksByID[run.TaskID]
+		if !ok {
+			return BenchmarkReport{}, fmt.Errorf("%w: task %q is not in the manifest", ErrNotRescorable, run.TaskID)
+		}
+		run, err := rescoreRun(ctx, task, run, grader)
+		if err != nil {
+			return BenchmarkReport{}, err
+		}
+		rescored = append(rescored, run)
+		covered[task.ID] = true
+	}
+	if len(rescored) == 0 {
+		return BenchmarkReport{}, fmt.Errorf("no runs to rescore")
+	}
+	s.sortRuns(rescored)
+
+	var tasks []Task
+	for _, task := range s.Tasks.Tasks {
+		if covered[task.ID] {
+			tasks = append(tasks, task)
+		}
+	}
+	report := s.buildReport(selection, tasks, rescored)
+	report.Grader = GraderVersion(grader)
+	slog.InfoContext(ctx, "benchmark rescored", "runs", len(rescored), "grader", report.Grader, "duration_ms", time.Since(started).Milliseconds())
+	return report, nil
+}
+
+// rescoreRun grades run's stored executions with grader. Runs that never
+// executed, because the model or the task failed first, are kept as they
+// are.
+func rescoreRun(ctx context.Context, task Task, run Run, grader Grader) (Run, error) {
+	testCases, err := taskTestCases(task)
+	if err != nil || len(run.Executions) == 0 {
+		if run.Error == "" && len(run.Outcomes) > 0 {
+			return run, fmt.Errorf("%w: run of task %q by %q has no stored executions", ErrNotRescorable, run.TaskID, run.ModelID)
+		}
+		return run, nil
+	}
+	if len(run.Executions) > len(testCases) || run.Error == "" && len(run.Executions) < len(testCases) {
+		return run, fmt.Errorf("%w: run of task %q by %q executed %d of its %d test cases", ErrNotRescorable, run.TaskID, run.ModelID, len(run.Executions), len(testCases))
+	}
+
+	run.Passed = run.Error == ""
+	run.Outcomes = make([]Outcome, 0, len(run.Executions))
+	for i := range run.Executions {
+		outcome := gradeExecution(ctx, grader, task, run.execution(i), testCases[i], i)
+		run.Outcomes = append(run.Outcomes, outcome)
+		if !outcome.Passed {
+			run.Passed = false
+		}
+	}
+	run.Score = scoreOutcomes(run.Outcomes)
+	run.Grader = GraderVersion(grader)
+	return run, nil
+}
+
+// execution returns the result of the run's ith test case with the evidence
+// its trace holds put back.
+func (r Run) execution(i int) api.ExecutionResponse {
+	resp := r.Executions[i]
+	for _, trace := range r.Trace {
+		if trace.TestCase == i {
+			resp.Transcript, resp.FileChanges, resp.Audit = trace.Transcript, trace.FileChanges, trace.Audit
+		}
+	}
+	return resp
+}
+
+// sortRuns puts runs in the order a benchmark plans them: by model, then
+// task, then the baseline before each scaffold, then epoch. Models and
+// scaffolds the service does not list sort after those it does, by name.
+func (s BenchmarkService) sortRuns(runs []Run) {
+	modelIndex := map[string]int{}
+	for i, model := range s.Models {
+		modelIndex[model.ID] = i
+	}
+	taskIndex := map[string]int{}
+	for i, task := range s.Tasks.Tasks {
+		taskIndex[task.ID] = i
+	}
+	scaffoldIndex := map[string]int{}
+	for i, scaffold := range s.Scaffolds.Scaffolds {
+		scaffoldIndex[scaffold.Name] = i
+	}
+	position := func(index map[string]int, key string) int {
+		if i, ok := index[key]; ok {
+			return i
+		}
+		return len(index)
+	}
+
+	baselineFirst := func(run Run) int {
+		if run.Scaffold.Baseline {
+			return 0
+		}
+		return 1
+	}
+	slices.SortFunc(runs, func(x, y Run) int {
+		return cmp.Or(
+			cmp.Compare(position(modelIndex, x.ModelID), position(modelIndex, y.ModelID)),
+			cmp.Compare(x.ModelID, y.ModelID),
+			cmp.Compare(taskIndex[x.TaskID], taskIndex[y.TaskID]),
+			cmp.Compare(baselineFirst(x), baselineFirst(y)),
+			cmp.Compare(position(scaffoldIndex, x.Scaffold.Name), position(scaffoldIndex, y.Scaffold.Name)),
+			cmp.Compare(x.Scaffold.Name, y.Scaffold.Name),
+			cmp.Compare(x.Epoch, y.Epoch),
+		)
+	})
+}
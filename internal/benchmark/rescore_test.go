@@ -0,0 +1,130 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+)
+
+// rejectingGrader fails every test case.
+type rejectingGrader struct{}
+
+func (rejectingGrader) Grade(Task, api.ExecutionResponse, TestCase) Outcome {
+	return Outcome{Verdict: VerdictWrongAnswer}
+}
+
+func TestBenchmarkServiceRunLogsWhatRescoringNeeds(t *testing.T) {
+	svc := poolService(1, ModelClient{ID: "m", Client: &fakeLLMClient{code: "```python\nprint('ok')\n```"}})
+	audit := &api.AuditSummary{ProcessSpawns: 1}
+	svc.Executor = &fakeExecutor{resp: api.ExecutionResponse{Stdout: "ok", Audit: audit}}
+	log, err := CreateRunLog(t.TempDir(), nil, RunLogInfo{})
+	if err != nil {
+		t.Fatalf("CreateRunLog() error = %v", err)
+	}
+	defer log.Close()
+
+	report, err := svc.Run(context.Background(), RunOptions{Log: log})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	run := report.Runs[0]
+	if run.Response != "```python\nprint('ok')\n```" || run.Code != "print('ok')" {
+		t.Fatalf("run response = %q, code = %q; want the raw answer and its code", run.Response, run.Code)
+	}
+	if run.Executions != nil || len(run.Trace) != 1 {
+		t.Fatalf("report run executions = %+v, trace = %+v; want only the trace", run.Executions, run.Trace)
+	}
+	if run.Grader != DefaultGraderVersion || report.Grader != DefaultGraderVersion {
+		t.Fatalf("graders = %q, %q; want %q", run.Grader, report.Grader, DefaultGraderVersion)
+	}
+
+	_, logged, err := ReadRunLog(log.Dir())
+	if err != nil {
+		t.Fatalf("ReadRunLog() error = %v", err)
+	}
+	run = logged[0]
+	if len(run.Executions) != 1 || run.Executions[0].Stdout != "ok" || run.Executions[0].Audit != nil {
+		t.Fatalf("logged executions = %+v, want the result without the audit the trace holds", run.Executions)
+	}
+	if got := run.execution(0); got.Stdout != "ok" || got.Audit == nil || got.Audit.ProcessSpawns != 1 {
+		t.Fatalf("execution(0) = %+v, want the audit restored from the trace", got)
+	}
+}
+
+func TestBenchmarkServiceRescoreRegradesWithoutCallingModels(t *testing.T) {
+	var calls atomic.Int32
+	svc := poolService(2, ModelClient{ID: "m", Client: countingLLMClient{&calls}})
+	svc.Grader = rejectingGrader{}
+	log, err := CreateRunLog(t.TempDir(), nil, RunLogInfo{})
+	if err != nil {
+		t.Fatalf("CreateRunLog() error = %v", err)
+	}
+	defer log.Close()
+	original, err := svc.Run(context.Background(), RunOptions{Log: log})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if original.Baseline.PassedTasks != 0 || original.Grader != "benchmark.rejectingGrader" {
+		t.Fatalf("original passed %d with grader %q, want all rejected", original.Baseline.PassedTasks, original.Grader)
+	}
+	if _, err := svc.Rescore(context.Background(), original.Runs, "", RunOptions{}); !errors.Is(err, ErrNotRescorable) {
+		t.Fatalf("Rescore() of the report's runs error = %v, want ErrNotRescorable", err)
+	}
+	calls.Store(0)
+
+	_, logged, err := ReadRunLog(log.Dir())
+	if err != nil {
+		t.Fatalf("ReadRunLog() error = %v", err)
+	}
+	// Reversed so the report has to restore the planned order.
+	runs := make([]Run, 0, len(logged)+1)
+	for i := len(logged) - 1; i >= 0; i-- {
+		runs = append(runs, logged[i])
+	}
+	runs = append(runs, logged[0])
+	report, err := svc.Rescore(context.Background(), runs, GraderNameDefault, RunOptions{RunID: "rescored"})
+	if err != nil {
+		t.Fatalf("Rescore() error = %v", err)
+	}
+
+	if calls.Load() != 0 {
+		t.Fatalf("model calls = %d, want none", calls.Load())
+	}
+	if report.RunID != "rescored" || report.Grader != DefaultGraderVersion || report.TotalTasks != 3 {
+		t.Fatalf("report = %s graded by %q over %d tasks, want run rescored by %q over 3", report.RunID, report.Grader, report.TotalTasks, DefaultGraderVersion)
+	}
+	if len(report.Runs) != len(original.Runs) || report.BaselineSuccessRate != 1 || report.ScaffoldedSuccessRate != 1 {
+		t.Fatalf("rescored %d runs at %v/%v, want %d passing", len(report.Runs), report.BaselineSuccessRate, report.ScaffoldedSuccessRate, len(original.Runs))
+	}
+	for i, run := range report.Runs {
+		if want := original.Runs[i]; run.TaskID != want.TaskID || run.Scaffold.Name != want.Scaffold.Name || run.Grader != DefaultGraderVersion {
+			t.Fatalf("run %d = %s/%s by %q, want %s/%s by the default grader", i, run.TaskID, run.Scaffold.Name, run.Grader, want.TaskID, want.Scaffold.Name)
+		}
+	}
+}
+
+func TestBenchmarkServiceRescoreRejectsUnknownGradersAndBareRuns(t *testing.T) {
+	svc := poolService(1)
+	if _, err := svc.Rescore(context.Background(), []Run{{TaskID: "task-0"}}, "strict", RunOptions{}); !errors.Is(err, ErrUnknownGrader) {
+		t.Fatalf("Rescore() error = %v, want ErrUnknownGrader", err)
+	}
+
+	// Runs logged before executions were stored only have their outcomes.
+	bare := Run{ModelID: "m", TaskID: "task-0", Passed: true, Outcomes: []Outcome{{Passed: true, Score: 1}}}
+	if _, err := svc.Rescore(context.Background(), []Run{bare}, "", RunOptions{}); !errors.Is(err, ErrNotRescorable) {
+		t.Fatalf("Rescore() error = %v, want ErrNotRescorable", err)
+	}
+	if _, err := svc.Rescore(context.Background(), []Run{{TaskID: "gone"}}, "", RunOptions{}); !errors.Is(err, ErrNotRescorable) {
+		t.Fatalf("Rescore() error = %v, want ErrNotRescorable for a task missing from the manifest", err)
+	}
+
+	// A run whose model call failed has nothing to grade and is kept.
+	failed := Run{ModelID: "m", TaskID: "task-0", Scaffold: Scaffold{Baseline: true, Name: "baseline"}, Error: "model unavailable"}
+	report, err := svc.Rescore(context.Background(), []Run{failed}, "", RunOptions{})
+	if err != nil || len(report.Runs) != 1 || report.Runs[0].Error != "model unavailable" {
+		t.Fatalf("Rescore() = %+v, %v; want the failed run kept", report.Runs, err)
+	}
+}
@@ -0,0 +1,175 @@
+package benchmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// A run directory holds a benchmark's run log: the manifest it started from,
+// what it was asked to run, and one JSON line per finished run.
+const (
+	RunLogManifestFile = "manifest.yaml"
+	RunLogInfoFile     = "run.json"
+	RunLogRunsFile     = "runs.jsonl"
+)
+
+// ErrRunLogExists is returned when creating a run log in a directory that
+// already has one, which should be resumed instead.
+var ErrRunLogExists = errors.New("run directory already has a run log")
+
+// RunLogInfo describes the benchmark a run log belongs to.
+type RunLogInfo struct {
+	RunID string `json:"run_id"`
+	// Owner is the ID of the API key that started the run, if any.
+	Owner     string    `json:"owner,omitempty"`
+	Selection Selection `json:"selection"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RunLog appends finished runs to a run directory so an interrupted
+// benchmark can resume without repeating them. It is safe for concurrent use.
+type RunLog struct {
+	dir  string
+	info RunLogInfo
+	runs []Run
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// CreateRunLog starts a run log in dir, creating the directory if needed,
+// and snapshots the manifest the benchmark runs with. The runs file is
+// created last, so a directory whose run log failed to start has none and
+// can be used again.
+func CreateRunLog(dir string, manifest []byte, info RunLogInfo) (*RunLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, RunLogRunsFile)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRunLogExists, dir)
+	}
+
+	rawInfo, err := json.MarshalIndent(info, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, RunLogInfoFile), append(rawInfo, '\n'), 0o644)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, RunLogManifestFile), manifest, 0o644)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("%w: %s", ErrRunLogExists, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &RunLog{dir: dir, info: info, file: file}, nil
+}
+
+// OpenRunLog reopens the run log in dir to append to it. A run cut off
+// while it was being written is dropped so that it runs again.
+func OpenRunLog(dir string) (*RunLog, error) {
+	info, runs, complete, err := readRunLog(dir)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, RunLogRunsFile)
+	if err := os.Truncate(path, complete); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &RunLog{dir: dir, info: info, runs: runs, file: file}, nil
+}
+
+// ReadRunLog returns the info and finished runs of the run log in dir
+// without opening it for writing.
+func ReadRunLog(dir string) (RunLogInfo, []Run, error) {
+	info, runs, _, err := readRunLog(dir)
+	return info, runs, err
+}
+
+// readRunLog also returns how many bytes of the runs file hold complete
+// lines.
+func readRunLog(dir string) (RunLogInfo, []Run, int64, error) {
+	var info RunLogInfo
+	rawInfo, err := os.ReadFile(filepath.Join(dir, RunLogInfoFile))
+	if err != nil {
+		return info, nil, 0, err
+	}
+	if err := json.Unmarshal(rawInfo, &info); err != nil {
+		return info, nil, 0, fmt.Errorf("parse %s: %w", filepath.Join(dir, RunLogInfoFile), err)
+	}
+
+	path := filepath.Join(dir, RunLogRunsFile)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return info, nil, 0, err
+	}
+	complete := bytes.LastIndexByte(raw, '\n') + 1
+	var runs []Run
+	for i, line := range bytes.Split(raw[:complete], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(line, &run); err != nil {
+			return info, nil, 0, fmt.Errorf("parse %s line %d: %w", path, i+1, err)
+		}
+		runs = append(runs, run)
+	}
+	return info, runs, int64(complete), nil
+}
+
+func (l *RunLog) Dir() string {
+	return l.dir
+}
+
+func (l *RunLog) Info() RunLogInfo {
+	return l.info
+}
+
+// ManifestPath is where the run log keeps its manifest snapshot.
+func (l *RunLog) ManifestPath() string {
+	return filepath.Join(l.dir, RunLogManifestFile)
+}
+
+// Runs returns the runs the log held when it was opened. A run retried
+// after an error is logged again, and its later line supersedes the earlier.
+func (l *RunLog) Runs() []Run {
+	return l.runs
+}
+
+// Append writes run to the log and syncs it to disk.
+func (l *RunLog) Append(run Run) error {
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+func (l *RunLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,144 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunLogDropsRunCutOffMidWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run")
+	log, err := CreateRunLog(dir, []byte("schema_version: 1\n"), RunLogInfo{RunID: "r1", Selection: Selection{Limit: 2}})
+	if err != nil {
+		t.Fatalf("CreateRunLog() error = %v", err)
+	}
+	if err := log.Append(Run{ModelID: "m", TaskID: "a", Passed: true}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	log.Close()
+	if _, err := CreateRunLog(dir, nil, RunLogInfo{}); !errors.Is(err, ErrRunLogExists) {
+		t.Fatalf("second CreateRunLog() error = %v, want ErrRunLogExists", err)
+	}
+
+	file, _ := os.OpenFile(filepath.Join(dir, RunLogRunsFile), os.O_WRONLY|os.O_APPEND, 0)
+	file.WriteString(`{"model_id":"m","task_id":"b"`)
+	file.Close()
+
+	log, err = OpenRunLog(dir)
+	if err != nil {
+		t.Fatalf("OpenRunLog() error = %v", err)
+	}
+	defer log.Close()
+	if info := log.Info(); info.RunID != "r1" || info.Selection.Limit != 2 {
+		t.Fatalf("Info() = %+v, want the created run's info", info)
+	}
+	if runs := log.Runs(); len(runs) != 1 || runs[0].TaskID != "a" || !runs[0].Passed {
+		t.Fatalf("Runs() = %+v, want only the complete run", runs)
+	}
+	if err := log.Append(Run{ModelID: "m", TaskID: "c"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if _, runs, err := ReadRunLog(dir); err != nil || len(runs) != 2 || runs[1].TaskID != "c" {
+		t.Fatalf("ReadRunLog() = %+v, %v; want the truncated partial run replaced", runs, err)
+	}
+	if raw, err := os.ReadFile(log.ManifestPath()); err != nil || string(raw) != "schema_version: 1\n" {
+		t.Fatalf("manifest snapshot = %q, %v", raw, err)
+	}
+}
+
+type countingLLMClient struct {
+	calls *atomic.Int32
+}
+
+func (c countingLLMClient) GenerateCode(ctx context.Context, problem string, language string) (string, error) {
+	c.calls.Add(1)
+	return "print('ok')", nil
+}
+
+func TestBenchmarkServiceResumesFromRunLog(t *testing.T) {
+	var calls atomic.Int32
+	svc := poolService(2, ModelClient{ID: "m", Client: countingLLMClient{&calls}})
+	dir := t.TempDir()
+	log, err := CreateRunLog(dir, nil, RunLogInfo{RunID: "r1"})
+	if err != nil {
+		t.Fatalf("CreateRunLog() error = %v", err)
+	}
+	// A passing run from before the interruption is reused, and a failed one
+	// is retried.
+	earlier := Run{ModelID: "m", TaskID: "task-0", Mode: RunModeBaseline, Scaffold: svc.Scaffolds.Scaffolds[0], Passed: true, Score: 1}
+	failed := Run{ModelID: "m", TaskID: "task-1", Mode: RunModeBaseline, Scaffold: svc.Scaffolds.Scaffolds[0], Error: "earlier failure"}
+	log.Append(earlier)
+	log.Append(failed)
+	log.Close()
+
+	log, err = OpenRunLog(dir)
+	if err != nil {
+		t.Fatalf("OpenRunLog() error = %v", err)
+	}
+	report, err := svc.Run(context.Background(), RunOptions{Log: log})
+	log.Close()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if got := calls.Load(); got != 5 {
+		t.Fatalf("model calls = %d, want the 4 runs missing from the log and the failed one", got)
+	}
+	if len(report.Runs) != 6 || report.Runs[0].Score != 1 || report.Runs[2].Error != "" {
+		t.Fatalf("report runs = %+v, want the logged pass reused and the failure retried", report.Runs)
+	}
+	if _, runs, err := ReadRunLog(dir); err != nil || len(runs) != 7 || runs[6].Error != "" {
+		t.Fatalf("logged runs = %d, %v; want the retried run appended after the 6", len(runs), err)
+	}
+}
+
+func TestCreateRunLogLeavesNoRunsFileWhenItFails(t *testing.T) {
+	dir := t.TempDir()
+	// A directory where the manifest snapshot belongs makes writing it fail.
+	if err := os.Mkdir(filepath.Join(dir, RunLogManifestFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateRunLog(dir, []byte("schema_version: 1\n"), RunLogInfo{RunID: "r1"}); err == nil {
+		t.Fatal("CreateRunLog() error = nil, want the manifest write to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, RunLogRunsFile)); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("runs file stat error = %v, want it never created", err)
+	}
+
+	os.Remove(filepath.Join(dir, RunLogManifestFile))
+	log, err := CreateRunLog(dir, []byte("schema_version: 1\n"), RunLogInfo{RunID: "r1"})
+	if err != nil {
+		t.Fatalf("CreateRunLog() retry error = %v, want the directory reusable", err)
+	}
+	log.Close()
+}
+
+func TestBenchmarkServiceDoesNotLogCancelledRuns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	svc := poolService(1, ModelClient{ID: "m", Client: cancellingLLMClient{cancel}})
+	log, err := CreateRunLog(t.TempDir(), nil, RunLogInfo{})
+	if err != nil {
+		t.Fatalf("CreateRunLog() error = %v", err)
+	}
+	defer log.Close()
+
+	if _, err := svc.Run(ctx, RunOptions{Log: log}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if _, runs, err := ReadRunLog(log.Dir()); err != nil || len(runs) != 0 {
+		t.Fatalf("logged runs = %+v, %v; want none", runs, err)
+	}
+}
+
+type cancellingLLMClient struct {
+	cancel context.CancelFunc
+}
+
+func (c cancellingLLMClient) GenerateCode(ctx context.Context, problem string, language string) (string, error) {
+	c.cancel()
+	return "", ctx.Err()
+}
@@ -0,0 +1,373 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gexec-sandbox/internal/logging"
+)
+
+const (
+	RunStateRunning   = "running"
+	RunStateSucceeded = "succeeded"
+	RunStateFailed    = "failed"
+	RunStateCancelled = "cancelled"
+)
+
+// ErrRunNotFound is returned for a run ID the manager does not know, either
+// because it never existed or because it was evicted.
+var ErrRunNotFound = errors.New("benchmark run not found")
+
+// ErrTooManyRuns is returned when starting a run while the manager already
+// runs as many as it allows.
+var ErrTooManyRuns = errors.New("too many benchmark runs in progress")
+
+// ErrRunNotSucceeded is returned when rescoring a run that has not finished
+// yet, or failed.
+var ErrRunNotSucceeded = errors.New("benchmark run has not succeeded")
+
+// maxFinishedRuns bounds how many finished runs a RunManager remembers.
+const maxFinishedRuns = 100
+
+// RunStatus is a snapshot of a background benchmark run. Runs holds the
+// results finished so far until the run succeeds, when Report replaces it.
+// Total stays zero until the benchmark has validated its configuration.
+type RunStatus struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	// Owner is the ID of the API key that started the run, if any.
+	Owner      string           `json:"owner,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	Completed  int              `json:"completed"`
+	Total      int              `json:"total"`
+	Error      string           `json:"error,omitempty"`
+	Runs       []Run            `json:"runs,omitempty"`
+	Report     *BenchmarkReport `json:"report,omitempty"`
+}
+
+// RunProgress reports one more finished run of a background benchmark.
+type RunProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+	Run       Run `json:"run"`
+}
+
+// RunManager runs benchmarks in the background so callers can poll or cancel
+// them instead of holding a request open for the whole run. With RunsDir
+// set, each run logs to its own run directory there, named by its ID, with
+// Manifest as the snapshot; rescoring reads the runs back from it.
+// MaxActive caps how many runs, benchmarks and rescores alike, are in
+// progress at once; zero is unlimited.
+type RunManager struct {
+	Service   BenchmarkServiceAPI
+	RunsDir   string
+	Manifest  []byte
+	MaxActive int
+
+	mu       sync.Mutex
+	runs     map[string]*managedRun
+	finished []string
+	active   int
+}
+
+type managedRun struct {
+	status RunStatus
+	runs   []Run
+	cancel context.CancelFunc
+	done   chan struct{}
+	// changed is closed and replaced whenever the run advances.
+	changed chan struct{}
+}
+
+// snapshot copies the status with the runs finished so far. Callers hold the
+// manager's lock.
+func (r *managedRun) snapshot() RunStatus {
+	status := r.status
+	if status.Report == nil {
+		status.Runs = append([]Run(nil), r.runs...)
+	}
+	return status
+}
+
+func (r *managedRun) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// runObserver records a managed run's progress as the service reports it.
+type runObserver struct {
+	manager *RunManager
+	run     *managedRun
+}
+
+func (o runObserver) BenchmarkStarted(total int) {
+	o.manager.mu.Lock()
+	defer o.manager.mu.Unlock()
+	o.run.status.Total = total
+	o.run.notify()
+}
+
+func (o runObserver) RunFinished(run Run) {
+	o.manager.mu.Lock()
+	defer o.manager.mu.Unlock()
+	o.run.runs = append(o.run.runs, run)
+	o.run.status.Completed = len(o.run.runs)
+	o.run.notify()
+}
+
+func NewRunManager(service BenchmarkServiceAPI) *RunManager {
+	return &RunManager{Service: service, runs: make(map[string]*managedRun)}
+}
+
+// Start launches a run with opts, under a new run ID and reporting to the
+// manager, on behalf of owner. It keeps ctx's values, such as the request ID,
+// but not its cancellation: the run outlives the call that started it.
+func (m *RunManager) Start(ctx context.Context, owner string, opts RunOptions) (RunStatus, error) {
+	if err := m.reserve(); err != nil {
+		return RunStatus{}, err
+	}
+	id := logging.NewID()
+	if m.RunsDir != "" {
+		log, err := CreateRunLog(filepath.Join(m.RunsDir, id), m.Manifest, RunLogInfo{RunID: id, Owner: owner, Selection: opts.Selection, CreatedAt: time.Now()})
+		if err != nil {
+			m.release()
+			return RunStatus{}, fmt.Errorf("create run log: %w", err)
+		}
+		opts.Log = log
+	}
+	return m.start(ctx, id, owner, func(ctx context.Context, observer RunObserver) (BenchmarkReport, error) {
+		if opts.Log != nil {
+			defer opts.Log.Close()
+		}
+		opts.RunID = id
+		opts.Observer = observer
+		return m.Service.Run(ctx, opts)
+	}), nil
+}
+
+// reserve takes one of the MaxActive slots, which finish or release gives
+// back.
+func (m *RunManager) reserve() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.MaxActive > 0 && m.active >= m.MaxActive {
+		return fmt.Errorf("%w: at most %d", ErrTooManyRuns, m.MaxActive)
+	}
+	m.active++
+	return nil
+}
+
+func (m *RunManager) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active--
+}
+
+// start runs work in the background as the managed run id in a slot already
+// reserved.
+func (m *RunManager) start(ctx context.Context, id string, owner string, work func(context.Context, RunObserver) (BenchmarkReport, error)) RunStatus {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	run := &managedRun{
+		status:  RunStatus{ID: id, State: RunStateRunning, Owner: owner, StartedAt: time.Now()},
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		changed: make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.runs[id] = run
+	status := run.snapshot()
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		report, err := work(runCtx, runObserver{manager: m, run: run})
+		m.finish(run, report, err)
+	}()
+	return status
+}
+
+func (m *RunManager) finish(run *managedRun, report BenchmarkReport, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.active--
+	finished := time.Now()
+	run.status.FinishedAt = &finished
+	switch {
+	case err == nil:
+		run.status.State = RunStateSucceeded
+		run.status.Report = &report
+	case errors.Is(err, context.Canceled):
+		run.status.State = RunStateCancelled
+		run.status.Error = err.Error()
+	default:
+		run.status.State = RunStateFailed
+		run.status.Error = err.Error()
+		slog.Warn("benchmark run failed", logging.RunIDKey, run.status.ID, "error", err)
+	}
+	close(run.done)
+	run.notify()
+
+	m.finished = append(m.finished, run.status.ID)
+	if len(m.finished) > maxFinishedRuns {
+		delete(m.runs, m.finished[0])
+		m.finished = m.finished[1:]
+	}
+}
+
+func (m *RunManager) Get(id string) (RunStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return RunStatus{}, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	return run.snapshot(), nil
+}
+
+// Watch returns the run's status without its results, the runs finished
+// after the first from, and a channel closed when the run next advances.
+func (m *RunManager) Watch(id string, from int) (RunStatus, []Run, <-chan struct{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return RunStatus{}, nil, nil, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	var runs []Run
+	if from < len(run.runs) {
+		runs = append(runs, run.runs[max(from, 0):]...)
+	}
+	status := run.status
+	status.Report = nil
+	return status, runs, run.changed, nil
+}
+
+// Cancel stops a running benchmark and returns its status once the run has
+// wound down. Cancelling a finished run returns its final status.
+func (m *RunManager) Cancel(ctx context.Context, id string) (RunStatus, error) {
+	m.mu.Lock()
+	run, ok := m.runs[id]
+	m.mu.Unlock()
+	if !ok {
+		return RunStatus{}, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+
+	run.cancel()
+	select {
+	case <-run.done:
+	case <-ctx.Done():
+		return RunStatus{}, ctx.Err()
+	}
+	return m.Get(id)
+}
+
+// Shutdown cancels every run in progress and waits until they have wound
+// down or ctx is done. A run with a run log can be resumed from it later.
+func (m *RunManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	var pending []*managedRun
+	for _, run := range m.runs {
+		if run.status.FinishedAt == nil {
+			run.cancel()
+			pending = append(pending, run)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, run := range pending {
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Wait blocks until the run finishes or ctx is done.
+func (m *RunManager) Wait(ctx context.Context, id string) (RunStatus, error) {
+	m.mu.Lock()
+	run, ok := m.runs[id]
+	m.mu.Unlock()
+	if !ok {
+		return RunStatus{}, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+
+	select {
+	case <-run.done:
+	case <-ctx.Done():
+		return RunStatus{}, ctx.Err()
+	}
+	return m.Get(id)
+}
+
+// Owner returns the ID of the key that started the run id, from the run's
+// status while the manager remembers it and from its run log after that.
+func (m *RunManager) Owner(id string) (string, error) {
+	if status, err := m.Get(id); err == nil {
+		return status.Owner, nil
+	}
+	info, _, err := m.readRunLog(id)
+	return info.Owner, err
+}
+
+// readRunLog reads the run directory of the run id.
+func (m *RunManager) readRunLog(id string) (RunLogInfo, []Run, error) {
+	if m.RunsDir == "" || !filepath.IsLocal(id) || filepath.Base(id) != id {
+		return RunLogInfo{}, nil, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	info, runs, err := ReadRunLog(filepath.Join(m.RunsDir, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return info, nil, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	if err != nil {
+		return info, nil, fmt.Errorf("read run log: %w", err)
+	}
+	return info, runs, nil
+}
+
+// StartRescore grades the logged runs of the run id again in the background
+// with the named grader, or the service's when grader is empty, on behalf of
+// owner, and returns the status of the new run whose report holds the
+// result. The run must have succeeded if the manager still remembers it;
+// otherwise its run directory is graded as logged.
+func (m *RunManager) StartRescore(ctx context.Context, id string, grader string, owner string) (RunStatus, error) {
+	if grader != "" && !slices.Contains(GraderNames(), grader) {
+		return RunStatus{}, fmt.Errorf("%w %q: want one of %s", ErrUnknownGrader, grader, strings.Join(GraderNames(), ", "))
+	}
+	rescorer, ok := m.Service.(Rescorer)
+	if !ok {
+		return RunStatus{}, errors.New("benchmark service cannot rescore runs")
+	}
+	if status, err := m.Get(id); err == nil && status.State != RunStateSucceeded {
+		return RunStatus{}, fmt.Errorf("%w: %s is %s", ErrRunNotSucceeded, id, status.State)
+	}
+	if m.RunsDir == "" {
+		return RunStatus{}, fmt.Errorf("%w: runs are only stored with a runs directory configured", ErrNotRescorable)
+	}
+	info, runs, err := m.readRunLog(id)
+	if err != nil {
+		return RunStatus{}, err
+	}
+	if err := m.reserve(); err != nil {
+		return RunStatus{}, err
+	}
+
+	rescoreID := logging.NewID()
+	return m.start(ctx, rescoreID, owner, func(ctx context.Context, observer RunObserver) (BenchmarkReport, error) {
+		report, err := rescorer.Rescore(ctx, runs, grader, RunOptions{RunID: rescoreID, Selection: info.Selection})
+		report.RescoredFrom = id
+		return report, err
+	}), nil
+}
@@ -0,0 +1,233 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gexec-sandbox/internal/api"
+)
+
+type runFunc func(ctx context.Context, opts RunOptions) (BenchmarkReport, error)
+
+func (f runFunc) Run(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
+	return f(ctx, opts)
+}
+
+func TestRunManagerReportsFinishedRun(t *testing.T) {
+	manager := NewRunManager(runFunc(func(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
+		return BenchmarkReport{RunID: opts.RunID, TotalTasks: 2}, nil
+	}))
+
+	started, err := manager.Start(context.Background(), "", RunOptions{})
+	if err != nil || started.State != RunStateRunning {
+		t.Fatalf("Start() = %+v, %v; want running", started, err)
+	}
+	status, err := manager.Wait(context.Background(), started.ID)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if status.State != RunStateSucceeded || status.Report == nil || status.Report.RunID != started.ID || status.FinishedAt == nil {
+		t.Fatalf("status = %+v, want succeeded with report for run %s", status, started.ID)
+	}
+}
+
+func TestRunManagerCancelOutlivesStartingContext(t *testing.T) {
+	manager := NewRunManager(runFunc(func(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
+		<-ctx.Done()
+		return BenchmarkReport{}, ctx.Err()
+	}))
+
+	requestCtx, endRequest := context.WithCancel(context.Background())
+	started, _ := manager.Start(requestCtx, "", RunOptions{})
+	endRequest()
+	time.Sleep(10 * time.Millisecond)
+	if status, _ := manager.Get(started.ID); status.State != RunStateRunning {
+		t.Fatalf("state after request ended = %q, want running", status.State)
+	}
+
+	status, err := manager.Cancel(context.Background(), started.ID)
+	if err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if status.State != RunStateCancelled {
+		t.Fatalf("state = %q, want cancelled", status.State)
+	}
+	if _, err := manager.Get("missing"); !errors.Is(err, ErrRunNotFound) {
+		t.Fatalf("Get(missing) error = %v, want ErrRunNotFound", err)
+	}
+}
+
+func TestRunManagerShutdownCancelsAndWaitsForActiveRuns(t *testing.T) {
+	manager := NewRunManager(runFunc(func(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return BenchmarkReport{}, ctx.Err()
+	}))
+	first, _ := manager.Start(context.Background(), "", RunOptions{})
+	second, _ := manager.Start(context.Background(), "", RunOptions{})
+
+	if err := manager.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	for _, id := range []string{first.ID, second.ID} {
+		if status, _ := manager.Get(id); status.State != RunStateCancelled {
+			t.Fatalf("state of %s after Shutdown = %q, want cancelled", id, status.State)
+		}
+	}
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+	stuck := NewRunManager(runFunc(func(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
+		<-unblock
+		return BenchmarkReport{}, ctx.Err()
+	}))
+	stuck.Start(context.Background(), "", RunOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := stuck.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() of a run that ignores cancellation error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestRunManagerTracksProgressAndPartialResults(t *testing.T) {
+	step := make(chan struct{})
+	manager := NewRunManager(runFunc(func(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
+		observer := opts.RunObserver()
+		observer.BenchmarkStarted(2)
+		observer.RunFinished(Run{TaskID: "a"})
+		<-step
+		observer.RunFinished(Run{TaskID: "b"})
+		return BenchmarkReport{Runs: []Run{{TaskID: "a"}, {TaskID: "b"}}}, nil
+	}))
+
+	started, _ := manager.Start(context.Background(), "", RunOptions{})
+	status, runs, changed, err := manager.Watch(started.ID, 0)
+	for err == nil && len(runs) == 0 {
+		<-changed
+		status, runs, changed, err = manager.Watch(started.ID, 0)
+	}
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if status.Total != 2 || status.Completed != 1 || len(runs) != 1 || runs[0].TaskID != "a" {
+		t.Fatalf("progress = %+v with runs %+v, want 1 of 2 finished", status, runs)
+	}
+	if partial, _ := manager.Get(started.ID); len(partial.Runs) != 1 {
+		t.Fatalf("partial runs = %+v, want the finished run", partial.Runs)
+	}
+
+	close(step)
+	final, err := manager.Wait(context.Background(), started.ID)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if final.Completed != 2 || final.Runs != nil || final.Report == nil {
+		t.Fatalf("final = %+v, want report replacing partial runs", final)
+	}
+	if _, rest, _, _ := manager.Watch(started.ID, 1); len(rest) != 1 || rest[0].TaskID != "b" {
+		t.Fatalf("Watch(from 1) runs = %+v, want only the second run", rest)
+	}
+}
+
+// loggingService logs one passing run and rescores by counting the runs it
+// is given.
+type loggingService struct{}
+
+func (loggingService) Run(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
+	run := Run{ModelID: "m", TaskID: "a", Passed: true, Executions: []api.ExecutionResponse{{Stdout: "ok"}}}
+	if opts.Log != nil {
+		if err := opts.Log.Append(run); err != nil {
+			return BenchmarkReport{}, err
+		}
+	}
+	run.Executions = nil
+	return BenchmarkReport{RunID: opts.RunID, Runs: []Run{run}}, nil
+}
+
+func (loggingService) Rescore(ctx context.Context, runs []Run, grader string, opts RunOptions) (BenchmarkReport, error) {
+	return BenchmarkReport{RunID: opts.RunID, Grader: grader, Runs: runs}, nil
+}
+
+func TestRunManagerRescoresRunDirectoryInTheBackground(t *testing.T) {
+	manager := NewRunManager(loggingService{})
+	if _, err := manager.StartRescore(context.Background(), "r1", "", ""); !errors.Is(err, ErrNotRescorable) {
+		t.Fatalf("StartRescore() without a runs directory error = %v, want ErrNotRescorable", err)
+	}
+
+	manager.RunsDir = t.TempDir()
+	manager.Manifest = []byte("schema_version: 1\n")
+	started, err := manager.Start(context.Background(), "", RunOptions{Selection: Selection{Limit: 1}})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if status, _ := manager.Wait(context.Background(), started.ID); len(status.Report.Runs[0].Executions) != 0 {
+		t.Fatalf("report runs = %+v, want executions kept only in the run log", status.Report.Runs)
+	}
+	if info, _, err := ReadRunLog(filepath.Join(manager.RunsDir, started.ID)); err != nil || info.RunID != started.ID || info.Selection.Limit != 1 {
+		t.Fatalf("run log info = %+v, %v; want the run's ID and selection", info, err)
+	}
+
+	rescoring, err := manager.StartRescore(context.Background(), started.ID, GraderNameChecker, "")
+	if err != nil {
+		t.Fatalf("StartRescore() error = %v", err)
+	}
+	status, _ := manager.Wait(context.Background(), rescoring.ID)
+	if status.State != RunStateSucceeded || status.Report.RescoredFrom != started.ID || status.Report.RunID != rescoring.ID || len(status.Report.Runs[0].Executions) != 1 {
+		t.Fatalf("rescore status = %+v, want the logged runs graded under a new run", status)
+	}
+
+	for _, tc := range []struct {
+		id, grader string
+		want       error
+	}{
+		{started.ID, "strict", ErrUnknownGrader},
+		{"missing", "", ErrRunNotFound},
+		{"../" + filepath.Base(manager.RunsDir), "", ErrRunNotFound},
+	} {
+		if _, err := manager.StartRescore(context.Background(), tc.id, tc.grader, ""); !errors.Is(err, tc.want) {
+			t.Fatalf("StartRescore(%q, %q) error = %v, want %v", tc.id, tc.grader, err, tc.want)
+		}
+	}
+}
+
+func TestRunManagerLimitsActiveRunsAndRecordsOwners(t *testing.T) {
+	release := make(chan struct{})
+	manager := NewRunManager(runFunc(func(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
+		<-release
+		return BenchmarkReport{}, nil
+	}))
+	manager.MaxActive = 1
+	manager.RunsDir = t.TempDir()
+
+	started, err := manager.Start(context.Background(), "alice", RunOptions{})
+	if err != nil || started.Owner != "alice" {
+		t.Fatalf("Start() = %+v, %v; want a run owned by alice", started, err)
+	}
+	if _, err := manager.Start(context.Background(), "bob", RunOptions{}); !errors.Is(err, ErrTooManyRuns) {
+		t.Fatalf("second Start() error = %v, want ErrTooManyRuns", err)
+	}
+	if entries, _ := os.ReadDir(manager.RunsDir); len(entries) != 1 {
+		t.Fatalf("run directories = %d, want none for the rejected run", len(entries))
+	}
+
+	close(release)
+	if _, err := manager.Wait(context.Background(), started.ID); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if _, err := manager.Start(context.Background(), "bob", RunOptions{}); err != nil {
+		t.Fatalf("Start() after the first run finished error = %v", err)
+	}
+
+	// A run the manager forgot is still owned through its run log.
+	delete(manager.runs, started.ID)
+	if owner, err := manager.Owner(started.ID); err != nil || owner != "alice" {
+		t.Fatalf("Owner() = %q, %v; want alice from the run log", owner, err)
+	}
+	if _, err := manager.Owner("missing"); !errors.Is(err, ErrRunNotFound) {
+		t.Fatalf("Owner(missing) error = %v, want ErrRunNotFound", err)
+	}
+}
@@ -2,23 +2,66 @@ package benchmark
 
 import (
 	"context"
+	"errors"
+
 	"gexec-sandbox/internal/api"
 	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/sandbox"
+	"gexec-sandbox/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Executor interface {
 	Execute(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error)
 }
 
+// InteractiveExecutor runs a submission against a task interactor with their
+// stdin/stdout cross-wired.
+type InteractiveExecutor interface {
+	ExecuteInteractive(ctx context.Context, submission api.ExecutionRequest, interactor api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error)
+}
+
+var errInteractiveUnsupported = errors.New("executor does not support interactive tasks")
+
+// StreamingExecutor runs a request while passing its output to sink as the
+// program writes it.
+type StreamingExecutor interface {
+	ExecuteStream(ctx context.Context, req api.ExecutionRequest, cfg config.Config, sink sandbox.OutputFunc) (api.ExecutionResponse, error)
+}
+
+var errStreamingUnsupported = errors.New("executor does not support streaming")
+
 type Grader interface {
 	Grade(task Task, resp api.ExecutionResponse, tc TestCase) Outcome
 }
 
+// ContextGrader is implemented by graders that do their own sandbox work and
+// should observe the run's cancellation.
+type ContextGrader interface {
+	GradeContext(ctx context.Context, task Task, resp api.ExecutionResponse, tc TestCase) Outcome
+}
+
 func RunTask(ctx context.Context, task Task, scaffold Scaffold, mode RunMode, client LLMClient, exec Executor, cfg config.Config) Run {
 	return RunTaskWithGrader(ctx, task, scaffold, mode, client, exec, DefaultGrader{}, cfg)
 }
 
 func RunTaskWithGrader(ctx context.Context, task Task, scaffold Scaffold, mode RunMode, client LLMClient, exec Executor, grader Grader, cfg config.Config) Run {
+	ctx, span := tracing.Start(ctx, "benchmark.task",
+		attribute.String("task.id", task.ID),
+		attribute.String("scaffold", scaffold.Name),
+		attribute.String("mode", string(mode)),
+	)
+	run := runTaskWithGrader(ctx, task, scaffold, mode, client, exec, grader, cfg)
+	span.SetAttributes(attribute.Bool("passed", run.Passed))
+	var err error
+	if run.Error != "" {
+		err = errors.New(run.Error)
+	}
+	tracing.End(span, err)
+	return run
+}
+
+func runTaskWithGrader(ctx context.Context, task Task, scaffold Scaffold, mode RunMode, client LLMClient, exec Executor, grader Grader, cfg config.Config) Run {
 	prompt := task.Description
 	if mode == RunModeScaffolded {
 		prompt = scaffold.ApplyPrompt(prompt)
@@ -43,37 +86,30 @@ func RunTaskWithGrader(ctx context.Context, task Task, scaffold Scaffold, mode R
 		}
 	}
 
+	run := Run{
+		TaskID:   task.ID,
+		Mode:     mode,
+		Scaffold: scaffold,
+		Passed:   true,
+		Response: code,
+		Code:     extractCode(code),
+		Grader:   GraderVersion(grader),
+	}
 	reqTemplate := api.ExecutionRequest{
 		Language:   task.Language,
-		SourceCode: extractCode(code),
+		SourceCode: run.Code,
 		TimeoutMS:  cfg.DefaultTimeoutMS,
 	}
 
-	testCases := task.TestCases
-	if len(testCases) == 0 {
-		if task.ArtifactExpectation == nil || task.ArtifactExpectation.ExpectedOutput == "" {
-			return Run{
-				TaskID:   task.ID,
-				Mode:     mode,
-				Scaffold: scaffold,
-				Error:    "task requires test cases or artifact expectation",
-			}
-		}
-		testCases = []TestCase{{
-			Input:          task.ArtifactExpectation.Input,
-			ExpectedOutput: task.ArtifactExpectation.ExpectedOutput,
-		}}
+	testCases, err := taskTestCases(task)
+	if err != nil {
+		run.Passed = false
+		run.Error = err.Error()
+		return run
 	}
-
 	outcomes := make([]Outcome, 0, len(testCases))
-	run := Run{
-		TaskID:   task.ID,
-		Mode:     mode,
-		Scaffold: scaffold,
-		Passed:   true,
-	}
 
-	for _, tc := range testCases {
+	for i, tc := range testCases {
 		if err := ctx.Err(); err != nil {
 			run.Passed = false
 			run.Error = err.Error()
@@ -83,7 +119,7 @@ func RunTaskWithGrader(ctx context.Context, task Task, scaffold Scaffold, mode R
 		req := reqTemplate
 		req.Stdin = tc.Input
 
-		resp, err := exec.Execute(ctx, req, cfg)
+		resp, err := executeTestCase(ctx, exec, task, req, tc, cfg)
 		if err != nil {
 			run.Passed = false
 			run.Error = err.Error()
@@ -91,8 +127,15 @@ func RunTaskWithGrader(ctx context.Context, task Task, scaffold Scaffold, mode R
 		}
 
 		run.Output = resp.Stdout
+		stored := resp
+		if len(resp.Transcript) > 0 || len(resp.FileChanges) > 0 || resp.Audit != nil {
+			run.Trace = append(run.Trace, ExecutionTrace{TestCase: i, Transcript: resp.Transcript, FileChanges: resp.FileChanges, Audit: resp.Audit})
+			// The trace keeps these, and rescoring restores them from it.
+			stored.Transcript, stored.FileChanges, stored.Audit = nil, nil, nil
+		}
+		run.Executions = append(run.Executions, stored)
 
-		outcome := grader.Grade(task, resp, tc)
+		outcome := gradeExecution(ctx, grader, task, resp, tc, i)
 		outcomes = append(outcomes, outcome)
 		if !outcome.Passed {
 			run.Passed = false
@@ -100,5 +143,91 @@ func RunTaskWithGrader(ctx context.Context, task Task, scaffold Scaffold, mode R
 	}
 
 	run.Outcomes = outcomes
+	run.Score = scoreOutcomes(outcomes)
 	return run
 }
+
+// taskTestCases returns the cases a task's submission runs against: one run
+// of the hidden tests, the task's own cases, or a case built from its
+// artifact expectation.
+func taskTestCases(task Task) ([]TestCase, error) {
+	if task.GradingMode == GradingModeTestsPass {
+		return []TestCase{{}}, nil
+	}
+	if len(task.TestCases) > 0 {
+		return task.TestCases, nil
+	}
+	if task.ArtifactExpectation == nil || task.ArtifactExpectation.ExpectedOutput == "" {
+		return nil, errors.New("task requires test cases or artifact expectation")
+	}
+	return []TestCase{{
+		Input:          task.ArtifactExpectation.Input,
+		ExpectedOutput: task.ArtifactExpectation.ExpectedOutput,
+	}}, nil
+}
+
+// gradeExecution grades test case i from its execution result.
+func gradeExecution(ctx context.Context, grader Grader, task Task, resp api.ExecutionResponse, tc TestCase, i int) Outcome {
+	ctx, span := tracing.Start(ctx, "benchmark.grade", attribute.Int("test_case", i))
+	defer span.End()
+	outcome := checkFileChanges(task, resp, gradeTestCase(ctx, grader, task, resp, tc))
+	span.SetAttributes(attribute.String("verdict", outcome.Verdict))
+	return outcome
+}
+
+func scoreOutcomes(outcomes []Outcome) float64 {
+	if len(outcomes) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, outcome := range outcomes {
+		total += outcome.Score
+	}
+	return total / float64(len(outcomes))
+}
+
+func gradeTestCase(ctx context.Context, grader Grader, task Task, resp api.ExecutionResponse, tc TestCase) Outcome {
+	if contextGrader, ok := grader.(ContextGrader); ok {
+		return contextGrader.GradeContext(ctx, task, resp, tc)
+	}
+	return grader.Grade(task, resp, tc)
+}
+
+func executeTestCase(ctx context.Context, exec Executor, task Task, req api.ExecutionRequest, tc TestCase, cfg config.Config) (api.ExecutionResponse, error) {
+	req.CaptureFileChanges = task.FileChanges != nil
+	if task.GradingMode == GradingModeTestsPass && task.Tests != nil {
+		req.Mode = api.ExecutionModeTest
+		req.Files = task.Tests.Files
+		if task.Tests.TimeoutMS != 0 {
+			req.TimeoutMS = task.Tests.TimeoutMS
+		}
+		return exec.Execute(ctx, req, cfg)
+	}
+	// Test mode is never audited: the test runner's own syscalls would drown
+	// out the submission's.
+	req.Audit = cfg.Audit
+	if task.Interactor == nil {
+		return exec.Execute(ctx, req, cfg)
+	}
+
+	interactive, ok := exec.(InteractiveExecutor)
+	if !ok {
+		return api.ExecutionResponse{}, errInteractiveUnsupported
+	}
+
+	timeoutMS := task.Interactor.TimeoutMS
+	if timeoutMS == 0 {
+		timeoutMS = cfg.DefaultTimeoutMS
+	}
+	req.Stdin = ""
+	return interactive.ExecuteInteractive(ctx, req, api.ExecutionRequest{
+		Language:   task.Interactor.Language,
+		SourceCode: task.Interactor.Source,
+		TimeoutMS:  timeoutMS,
+		Files: map[string]string{
+			checkerInputFile:    tc.Input,
+			checkerExpectedFile: tc.ExpectedOutput,
+		},
+		Args: []string{checkerInputFile, checkerExpectedFile},
+	}, cfg)
+}
@@ -2,10 +2,14 @@ package benchmark
 
 import (
 	"context"
+	"math"
+	"reflect"
 	"testing"
 
 	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
 	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/sandbox"
 )
 
 func TestRunTaskAppliesScaffoldPromptPrefix(t *testing.T) {
@@ -86,7 +90,7 @@ func TestRunTaskWithGraderUsesInjectedGrader(t *testing.T) {
 		t.Fatalf("len(Outcomes) = %d, want 1", len(run.Outcomes))
 	}
 
-	if run.Outcomes[0] != grader.outcome {
+	if !reflect.DeepEqual(run.Outcomes[0], grader.outcome) {
 		t.Fatalf("Outcome = %#v, want %#v", run.Outcomes[0], grader.outcome)
 	}
 
@@ -136,6 +140,43 @@ func TestDefaultGraderUsesCSVArtifactFormat(t *testing.T) {
 	}
 }
 
+func TestDefaultGraderScoresMismatchedTableCells(t *testing.T) {
+	grader := DefaultGrader{}
+	for _, tc := range []struct {
+		format, actual, expected string
+		want                     float64
+	}{
+		{"markdown", "| team | count |\n| --- | --- |\n| api | 3 |\n| web | 1 |", "team | count\n--- | ---\napi | 2\nweb | 1", 5.0 / 6.0},
+		{"csv", "month,total\n2026-05,25", "month,total\n2026-05,25\n2026-06,7", 4.0 / 6.0},
+		{"csv", "\"unterminated", "month,total", 0},
+	} {
+		task := Task{ArtifactExpectation: &ArtifactExpectation{Format: tc.format}}
+		outcome := grader.Grade(task, api.ExecutionResponse{Stdout: tc.actual}, TestCase{ExpectedOutput: tc.expected})
+
+		if outcome.Passed || math.Abs(outcome.Score-tc.want) > 1e-9 {
+			t.Fatalf("%s outcome = %#v, want failed with score %v", tc.format, outcome, tc.want)
+		}
+		if tc.want > 0 && outcome.Verdict != VerdictPartial {
+			t.Fatalf("%s verdict = %q, want %q", tc.format, outcome.Verdict, VerdictPartial)
+		}
+	}
+}
+
+func TestRunTaskScoresFractionOfTestCasesPassed(t *testing.T) {
+	task := Task{
+		ID:          "cases",
+		Description: "print ok",
+		Language:    "python",
+		TestCases:   []TestCase{{ExpectedOutput: "ok"}, {ExpectedOutput: "ok"}, {ExpectedOutput: "nope"}, {ExpectedOutput: "ok"}},
+	}
+
+	run := RunTask(context.Background(), task, Scaffold{Name: "baseline"}, RunModeBaseline, &fakeLLMClient{code: "print('ok')"}, &fakeExecutor{resp: api.ExecutionResponse{Stdout: "ok"}}, config.Config{DefaultTimeoutMS: 1000})
+
+	if run.Passed || run.Score != 0.75 {
+		t.Fatalf("run passed = %v score = %v, want failed with score 0.75", run.Passed, run.Score)
+	}
+}
+
 func TestDefaultGraderUsesJSONArtifactFormat(t *testing.T) {
 	grader := DefaultGrader{}
 	task := Task{
@@ -216,6 +257,42 @@ func TestCodeExecutionAdapterDelegatesToSandbox(t *testing.T) {
 	}
 }
 
+func TestCodeExecutionAdapterChargesTheCallingKey(t *testing.T) {
+	ring, err := auth.NewKeyring([]auth.Key{{ID: "ci", Hash: auth.HashKey("s"), Scopes: []string{auth.ScopeBenchmark}}})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	ctx, release, err := ring.Authorize(context.Background(), "s", auth.ScopeBenchmark)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	release()
+
+	adapter := CodeExecutionAdapter{
+		Runner: func(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+			return api.ExecutionResponse{DurationMS: 1500}, nil
+		},
+		InteractiveRunner: func(ctx context.Context, submission api.ExecutionRequest, interactor api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+			return api.ExecutionResponse{DurationMS: 500}, nil
+		},
+		StreamRunner: func(ctx context.Context, req api.ExecutionRequest, cfg config.Config, sink sandbox.OutputFunc) (api.ExecutionResponse, error) {
+			return api.ExecutionResponse{DurationMS: 1000}, nil
+		},
+	}
+	if _, err := adapter.Execute(ctx, api.ExecutionRequest{}, config.Config{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if _, err := adapter.ExecuteInteractive(ctx, api.ExecutionRequest{}, api.ExecutionRequest{}, config.Config{}); err != nil {
+		t.Fatalf("ExecuteInteractive() error = %v", err)
+	}
+	if _, err := adapter.ExecuteStream(ctx, api.ExecutionRequest{}, config.Config{}, nil); err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	if usage, _ := ring.Usage("ci"); usage.SandboxSeconds != 3 {
+		t.Fatalf("CPUSeconds = %v, want 3", usage.SandboxSeconds)
+	}
+}
+
 type fakeExecutor struct {
 	resp    api.ExecutionResponse
 	seenReq api.ExecutionRequest
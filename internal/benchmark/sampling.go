@@ -0,0 +1,155 @@
+package benchmark
+
+import "sort"
+
+// SamplingReport summarizes repeated samples of each task. Success rates
+// elsewhere in the report are pass@1; this adds pass@k for larger k and each
+// task's score variance.
+type SamplingReport struct {
+	Epochs int   `json:"epochs"`
+	K      []int `json:"k"`
+	// Groups has one entry per model and scaffold, averaging its tasks.
+	Groups []SampleSummary `json:"groups"`
+	Tasks  []TaskSamples   `json:"tasks"`
+}
+
+// SampleSummary averages the per-task estimates of one model and scaffold.
+// PassAtK only covers tasks with at least k samples.
+type SampleSummary struct {
+	ModelID   string          `json:"model_id,omitempty"`
+	Scaffold  string          `json:"scaffold"`
+	Tasks     int             `json:"tasks"`
+	Samples   int             `json:"samples"`
+	MeanScore float64         `json:"mean_score"`
+	PassAtK   map[int]float64 `json:"pass_at_k"`
+}
+
+// TaskSamples summarizes every sample of one task for one model and
+// scaffold. ScoreVariance is the sample variance, zero for a single sample.
+type TaskSamples struct {
+	ModelID       string          `json:"model_id,omitempty"`
+	TaskID        string          `json:"task_id"`
+	Scaffold      string          `json:"scaffold"`
+	Samples       int             `json:"samples"`
+	Passed        int             `json:"passed"`
+	MeanScore     float64         `json:"mean_score"`
+	ScoreVariance float64         `json:"score_variance"`
+	PassAtK       map[int]float64 `json:"pass_at_k"`
+}
+
+// PassAtK is the unbiased estimate of the chance that at least one of k
+// samples passes, given that passed of n samples did (Chen et al., 2021). It
+// is 1 - C(n-passed, k) / C(n, k), computed as a product to stay finite.
+func PassAtK(n int, passed int, k int) float64 {
+	if k <= 0 || n < k {
+		return 0
+	}
+	if n-passed < k {
+		return 1
+	}
+	missed := 1.0
+	for i := n - passed + 1; i <= n; i++ {
+		missed *= 1 - float64(k)/float64(i)
+	}
+	return 1 - missed
+}
+
+// BuildSamplingReport groups runs by model, task and scaffold and estimates
+// pass@k for each k in ks.
+func BuildSamplingReport(runs []Run, epochs int, ks []int) *SamplingReport {
+	type cell struct{ model, task, scaffold string }
+	type group struct{ model, scaffold string }
+
+	var cells []cell
+	scores := map[cell][]float64{}
+	passed := map[cell]int{}
+	for _, run := range runs {
+		key := cell{run.ModelID, run.TaskID, run.Scaffold.Name}
+		if _, ok := scores[key]; !ok {
+			cells = append(cells, key)
+		}
+		scores[key] = append(scores[key], run.Score)
+		if run.Passed {
+			passed[key]++
+		}
+	}
+
+	report := &SamplingReport{Epochs: epochs, K: append([]int(nil), ks...), Groups: []SampleSummary{}, Tasks: make([]TaskSamples, 0, len(cells))}
+	var groups []group
+	summaries := map[group]*SampleSummary{}
+	covered := map[group]map[int]int{}
+	for _, key := range cells {
+		samples := scores[key]
+		task := TaskSamples{
+			ModelID:       key.model,
+			TaskID:        key.task,
+			Scaffold:      key.scaffold,
+			Samples:       len(samples),
+			Passed:        passed[key],
+			MeanScore:     mean(samples),
+			ScoreVariance: sampleVariance(samples),
+			PassAtK:       map[int]float64{},
+		}
+		for _, k := range ks {
+			if k <= task.Samples {
+				task.PassAtK[k] = PassAtK(task.Samples, task.Passed, k)
+			}
+		}
+		report.Tasks = append(report.Tasks, task)
+
+		g := group{key.model, key.scaffold}
+		summary, ok := summaries[g]
+		if !ok {
+			groups = append(groups, g)
+			summary = &SampleSummary{ModelID: g.model, Scaffold: g.scaffold, PassAtK: map[int]float64{}}
+			summaries[g] = summary
+			covered[g] = map[int]int{}
+		}
+		summary.Tasks++
+		summary.Samples += task.Samples
+		summary.MeanScore += task.MeanScore
+		for k, estimate := range task.PassAtK {
+			summary.PassAtK[k] += estimate
+			covered[g][k]++
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].model != groups[j].model {
+			return groups[i].model < groups[j].model
+		}
+		return groups[i].scaffold < groups[j].scaffold
+	})
+	for _, g := range groups {
+		summary := summaries[g]
+		summary.MeanScore /= float64(summary.Tasks)
+		for k, n := range covered[g] {
+			summary.PassAtK[k] /= float64(n)
+		}
+		report.Groups = append(report.Groups, *summary)
+	}
+	return report
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+func sampleVariance(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	total := 0.0
+	for _, value := range values {
+		total += (value - m) * (value - m)
+	}
+	return total / float64(len(values)-1)
+}
@@ -0,0 +1,99 @@
+package benchmark
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+)
+
+func TestPassAtKIsUnbiased(t *testing.T) {
+	for _, tc := range []struct {
+		n, passed, k int
+		want         float64
+	}{
+		{5, 2, 1, 0.4},
+		{5, 0, 3, 0},
+		{5, 3, 3, 1},
+		{10, 3, 5, 1 - 21.0/252.0},
+		{2, 1, 3, 0},
+	} {
+		if got := PassAtK(tc.n, tc.passed, tc.k); math.Abs(got-tc.want) > 1e-9 {
+			t.Fatalf("PassAtK(%d, %d, %d) = %v, want %v", tc.n, tc.passed, tc.k, got, tc.want)
+		}
+	}
+}
+
+func TestBuildSamplingReportSummarizesTasksAndGroups(t *testing.T) {
+	baseline := Scaffold{Baseline: true, Name: "baseline"}
+	runs := []Run{
+		{ModelID: "m", TaskID: "a", Scaffold: baseline, Passed: true, Score: 1},
+		{ModelID: "m", TaskID: "a", Scaffold: baseline, Epoch: 1, Score: 0.25},
+		{ModelID: "m", TaskID: "b", Scaffold: baseline},
+		{ModelID: "m", TaskID: "b", Scaffold: baseline, Epoch: 1},
+	}
+
+	report := BuildSamplingReport(runs, 2, []int{1, 2})
+	if len(report.Tasks) != 2 || len(report.Groups) != 1 {
+		t.Fatalf("sampling = %+v, want two tasks in one group", report)
+	}
+	a := report.Tasks[0]
+	if a.Samples != 2 || a.Passed != 1 || a.MeanScore != 0.625 || a.PassAtK[1] != 0.5 || a.PassAtK[2] != 1 {
+		t.Fatalf("task a = %+v, want two samples with one pass", a)
+	}
+	// Scores 1 and 0.25 differ from their mean by 0.375 each.
+	if math.Abs(a.ScoreVariance-2*0.375*0.375) > 1e-9 {
+		t.Fatalf("task a variance = %v", a.ScoreVariance)
+	}
+	group := report.Groups[0]
+	if group.Tasks != 2 || group.Samples != 4 || group.PassAtK[1] != 0.25 || group.PassAtK[2] != 0.5 || group.MeanScore != 0.3125 {
+		t.Fatalf("group = %+v, want the mean of both tasks", group)
+	}
+}
+
+// alternatingLLMClient answers correctly on every other call.
+type alternatingLLMClient struct {
+	calls *atomic.Int32
+}
+
+func (c alternatingLLMClient) GenerateCode(ctx context.Context, problem string, language string) (string, error) {
+	if c.calls.Add(1)%2 == 1 {
+		return "print('ok')", nil
+	}
+	return "print('no')", nil
+}
+
+func TestBenchmarkServiceRunRecordsEverySample(t *testing.T) {
+	svc := poolService(1, ModelClient{ID: "alpha", Client: alternatingLLMClient{&atomic.Int32{}}})
+	svc.Tasks.Tasks = svc.Tasks.Tasks[:1]
+	svc.Config = config.Config{Epochs: 4}
+	svc.Executor = benchmarkServiceExecutor{responseBySource: map[string]api.ExecutionResponse{
+		"print('ok')": {Stdout: "ok"},
+		"print('no')": {Stdout: "no"},
+	}}
+
+	report, err := svc.Run(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Runs) != 8 {
+		t.Fatalf("len(Runs) = %d, want 4 epochs of 2 scaffolds", len(report.Runs))
+	}
+	for i, run := range report.Runs {
+		if run.Epoch != i%4 {
+			t.Fatalf("Runs[%d].Epoch = %d, want %d", i, run.Epoch, i%4)
+		}
+	}
+	sampling := report.Sampling
+	if sampling == nil || sampling.Epochs != 4 || len(sampling.K) != 2 || sampling.K[1] != 4 {
+		t.Fatalf("Sampling = %+v, want 4 epochs with pass@1 and pass@4", sampling)
+	}
+	for _, group := range sampling.Groups {
+		if group.PassAtK[1] != 0.5 || group.PassAtK[4] != 1 {
+			t.Fatalf("group %s = %+v, want pass@1 0.5 and pass@4 1", group.Scaffold, group)
+		}
+	}
+}
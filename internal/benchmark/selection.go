@@ -0,0 +1,138 @@
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"slices"
+)
+
+// ErrEmptySelection is returned when a Selection leaves nothing to run.
+var ErrEmptySelection = errors.New("selection matches nothing")
+
+// Selector includes and excludes values by glob pattern, as understood by
+// path.Match. An empty Include accepts every value not excluded.
+type Selector struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Match reports whether any of values is included and none is excluded.
+// Multi-valued fields such as tags pass every value; with none, only an
+// empty Include matches.
+func (s Selector) Match(values ...string) bool {
+	if matchAny(s.Exclude, values) {
+		return false
+	}
+	return len(s.Include) == 0 || matchAny(s.Include, values)
+}
+
+func matchAny(patterns []string, values []string) bool {
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if ok, _ := path.Match(pattern, value); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Selection narrows a benchmark to part of the manifest. Limit caps the
+// number of tasks after filtering: the first Limit in catalog order, or a
+// random sample drawn with Seed when one is set so the same seed picks the
+// same tasks.
+type Selection struct {
+	Tasks     Selector `json:"tasks,omitzero"`
+	Families  Selector `json:"families,omitzero"`
+	Tags      Selector `json:"tags,omitzero"`
+	Languages Selector `json:"languages,omitzero"`
+	Scaffolds Selector `json:"scaffolds,omitzero"`
+	Models    Selector `json:"models,omitzero"`
+	Limit     int      `json:"limit,omitempty"`
+	Seed      *uint64  `json:"seed,omitempty"`
+}
+
+// Selectors returns each selector by its JSON field name.
+func (s Selection) Selectors() map[string]Selector {
+	return map[string]Selector{
+		"tasks":     s.Tasks,
+		"families":  s.Families,
+		"tags":      s.Tags,
+		"languages": s.Languages,
+		"scaffolds": s.Scaffolds,
+		"models":    s.Models,
+	}
+}
+
+func (s Selection) IsZero() bool {
+	for _, selector := range s.Selectors() {
+		if len(selector.Include) > 0 || len(selector.Exclude) > 0 {
+			return false
+		}
+	}
+	return s.Limit == 0 && s.Seed == nil
+}
+
+// SelectTasks returns the tasks the selection picks, in catalog order.
+func (s Selection) SelectTasks(catalog TaskCatalog) TaskCatalog {
+	selected := catalog.Filter(func(task Task) bool {
+		return s.Tasks.Match(task.ID) &&
+			s.Families.Match(task.TaskFamily) &&
+			s.Languages.Match(task.Language) &&
+			s.Tags.Match(task.Tags...)
+	})
+	if s.Limit <= 0 || len(selected.Tasks) <= s.Limit {
+		return selected
+	}
+	if s.Seed == nil {
+		selected.Tasks = selected.Tasks[:s.Limit]
+		return selected
+	}
+
+	picked := rand.New(rand.NewPCG(*s.Seed, 0)).Perm(len(selected.Tasks))[:s.Limit]
+	slices.Sort(picked)
+	sampled := make([]Task, len(picked))
+	for i, index := range picked {
+		sampled[i] = selected.Tasks[index]
+	}
+	selected.Tasks = sampled
+	return selected
+}
+
+// SelectScaffolds returns the baseline plus the variants the selection picks.
+func (s Selection) SelectScaffolds(catalog ScaffoldCatalog) ScaffoldCatalog {
+	return catalog.Filter(func(scaffold Scaffold) bool { return s.Scaffolds.Match(scaffold.Name) })
+}
+
+// SelectModels returns the models the selection picks, in order.
+func (s Selection) SelectModels(models []ModelClient) []ModelClient {
+	var selected []ModelClient
+	for _, model := range models {
+		if s.Models.Match(model.ID) {
+			selected = append(selected, model)
+		}
+	}
+	return selected
+}
+
+// selectMatrix applies selection to the service's tasks, scaffolds and
+// models, failing if no tasks or models are left. Selecting only the baseline
+// scaffold runs it alone, without a lift.
+func (s BenchmarkService) selectMatrix(selection Selection, models []ModelClient) (TaskCatalog, ScaffoldCatalog, []ModelClient, error) {
+	if selection.IsZero() {
+		return s.Tasks, s.Scaffolds, models, nil
+	}
+
+	tasks := selection.SelectTasks(s.Tasks)
+	if len(tasks.Tasks) == 0 {
+		return TaskCatalog{}, ScaffoldCatalog{}, nil, fmt.Errorf("%w: no tasks selected", ErrEmptySelection)
+	}
+	scaffolds := selection.SelectScaffolds(s.Scaffolds)
+	models = selection.SelectModels(models)
+	if len(models) == 0 {
+		return TaskCatalog{}, ScaffoldCatalog{}, nil, fmt.Errorf("%w: no models selected", ErrEmptySelection)
+	}
+	return tasks, scaffolds, models, nil
+}
@@ -0,0 +1,133 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+)
+
+func selectionTasks() TaskCatalog {
+	return TaskCatalog{Tasks: []Task{
+		{ID: "csv-sum", TaskFamily: "data_workflows", Language: "python", Tags: []string{"csv", "easy"}},
+		{ID: "csv-join", TaskFamily: "data_workflows", Language: "go", Tags: []string{"csv"}},
+		{ID: "ledger", TaskFamily: "finance_workflows", Language: "python", Tags: []string{"hard"}},
+		{ID: "refactor", TaskFamily: "engineering_workflows", Language: "python"},
+	}}
+}
+
+func taskIDs(catalog TaskCatalog) []string {
+	var ids []string
+	for _, task := range catalog.Tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func TestSelectionSelectTasksAppliesEverySelector(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection Selection
+		want      []string
+	}{
+		{name: "none", want: []string{"csv-sum", "csv-join", "ledger", "refactor"}},
+		{name: "task glob", selection: Selection{Tasks: Selector{Include: []string{"csv-*"}}}, want: []string{"csv-sum", "csv-join"}},
+		{name: "family exclude", selection: Selection{Families: Selector{Exclude: []string{"data_*"}}}, want: []string{"ledger", "refactor"}},
+		{name: "any tag", selection: Selection{Tags: Selector{Include: []string{"easy", "hard"}}}, want: []string{"csv-sum", "ledger"}},
+		{name: "tag exclude keeps untagged", selection: Selection{Tags: Selector{Exclude: []string{"csv"}}}, want: []string{"ledger", "refactor"}},
+		{name: "combined", selection: Selection{Languages: Selector{Include: []string{"python"}}, Tasks: Selector{Exclude: []string{"refactor"}}}, want: []string{"csv-sum", "ledger"}},
+		{name: "limit", selection: Selection{Limit: 2}, want: []string{"csv-sum", "csv-join"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taskIDs(tt.selection.SelectTasks(selectionTasks())); !slices.Equal(got, tt.want) {
+				t.Fatalf("SelectTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectionSamplesTasksBySeed(t *testing.T) {
+	seed := uint64(7)
+	selection := Selection{Limit: 2, Seed: &seed}
+
+	first := taskIDs(selection.SelectTasks(selectionTasks()))
+	if len(first) != 2 {
+		t.Fatalf("SelectTasks() = %v, want 2 tasks", first)
+	}
+	if again := taskIDs(selection.SelectTasks(selectionTasks())); !slices.Equal(first, again) {
+		t.Fatalf("same seed picked %v then %v", first, again)
+	}
+
+	picks := map[string]bool{}
+	for seed := range uint64(20) {
+		sample := Selection{Limit: 2, Seed: &seed}
+		picks[taskIDs(sample.SelectTasks(selectionTasks()))[0]] = true
+	}
+	if len(picks) < 2 {
+		t.Fatalf("20 seeds always picked %v first, want varied samples", picks)
+	}
+}
+
+func TestBenchmarkServiceRunBenchmarksOnlyTheSelection(t *testing.T) {
+	client := &countingBenchmarkServiceLLMClient{codeByPrompt: map[string]string{}}
+	svc := BenchmarkService{
+		Tasks: selectionTasks(),
+		Scaffolds: ScaffoldCatalog{Scaffolds: []Scaffold{
+			{Baseline: true, Name: "baseline"},
+			{Name: "tool-assisted", PromptPrefix: "tool: "},
+			{Name: "critic", PromptPrefix: "critic: "},
+		}},
+		Models: []ModelClient{
+			{ID: "alpha", Client: client},
+			{ID: "beta", Client: client},
+		},
+		Executor: benchmarkServiceExecutor{responseBySource: map[string]api.ExecutionResponse{}},
+	}
+	selection := Selection{
+		Tasks:     Selector{Include: []string{"csv-*"}},
+		Scaffolds: Selector{Include: []string{"critic"}},
+		Models:    Selector{Exclude: []string{"beta"}},
+	}
+
+	report, err := svc.Run(context.Background(), RunOptions{Selection: selection})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.TotalTasks != 2 || len(report.Runs) != 4 || client.calls != 4 {
+		t.Fatalf("TotalTasks = %d, runs = %d, calls = %d; want 2 tasks, each once for baseline and critic", report.TotalTasks, len(report.Runs), client.calls)
+	}
+	for _, run := range report.Runs {
+		if run.ModelID != "alpha" || run.Scaffold.Name == "tool-assisted" {
+			t.Fatalf("run = %s/%s, want only alpha with baseline or critic", run.ModelID, run.Scaffold.Name)
+		}
+	}
+	if report.Selection == nil || report.Selection.Models.Exclude[0] != "beta" {
+		t.Fatalf("report selection = %+v, want the run's selection", report.Selection)
+	}
+
+	_, err = svc.Run(context.Background(), RunOptions{Selection: Selection{Tasks: Selector{Include: []string{"none"}}}})
+	if !errors.Is(err, ErrEmptySelection) {
+		t.Fatalf("Run() with no tasks error = %v, want ErrEmptySelection", err)
+	}
+}
+
+func TestBenchmarkServiceRunsBaselineOnlySelectionWithoutLift(t *testing.T) {
+	svc := poolService(1, ModelClient{ID: "m", Client: &fakeLLMClient{code: "print('ok')"}})
+
+	report, err := svc.Run(context.Background(), RunOptions{Selection: Selection{Scaffolds: Selector{Include: []string{"none"}}}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Runs) != 3 || report.ScaffoldedScaffold != "" || len(report.Scaffolds) != 0 {
+		t.Fatalf("runs = %d, scaffolded = %q; want only the 3 baseline runs", len(report.Runs), report.ScaffoldedScaffold)
+	}
+	if report.BaselineSuccessRate != 1 || report.Lift != 0 || report.ScoreLift != 0 || report.ByFamily["software_engineering"].Lift != 0 {
+		t.Fatalf("baseline = %v, lift = %v/%v; want the baseline rate and no lift", report.BaselineSuccessRate, report.Lift, report.ScoreLift)
+	}
+	if _, ok := report.Statistics.Intervals["lift"]; ok {
+		t.Fatalf("intervals = %v, want none for a lift that was not measured", report.Statistics.Intervals)
+	}
+}
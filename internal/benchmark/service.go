@@ -3,13 +3,52 @@ package benchmark
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"maps"
+	"slices"
+	"time"
 
 	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/logging"
+	"gexec-sandbox/internal/metrics"
+	"gexec-sandbox/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type BenchmarkServiceAPI interface {
-	Run(ctx context.Context) (BenchmarkReport, error)
+	Run(ctx context.Context, opts RunOptions) (BenchmarkReport, error)
+}
+
+// RunOptions configures one benchmark run. The zero value runs everything
+// under a new run ID, ignores progress and keeps no run log.
+type RunOptions struct {
+	// RunID names the run in its report and log lines, so callers can refer
+	// to it before it finishes.
+	RunID string
+	// Selection narrows the tasks, scaffolds and models run.
+	Selection Selection
+	// Observer follows the run as it advances.
+	Observer RunObserver
+	// Log makes Run skip the runs it already holds and append each run it
+	// finishes.
+	Log *RunLog
+}
+
+// ID returns RunID, or a new ID when it is empty.
+func (o RunOptions) ID() string {
+	if o.RunID != "" {
+		return o.RunID
+	}
+	return logging.NewID()
+}
+
+// RunObserver returns Observer, or one that ignores progress. Other
+// BenchmarkServiceAPI implementations use it to report their progress.
+func (o RunOptions) RunObserver() RunObserver {
+	if o.Observer != nil {
+		return o.Observer
+	}
+	return ignoreProgress{}
 }
 
 type BenchmarkService struct {
@@ -27,17 +66,37 @@ type ModelClient struct {
 	ID          string
 	Client      LLMClient
 	HealthCheck func(context.Context) error
+	// Concurrency caps how many of the model's runs execute at once. Zero
+	// leaves only Config.BenchmarkConcurrency in charge.
+	Concurrency int
+}
+
+// RunObserver follows a benchmark run as it advances.
+type RunObserver interface {
+	// BenchmarkStarted reports how many runs the benchmark will make.
+	BenchmarkStarted(total int)
+	// RunFinished reports each run as soon as it is graded. Runs execute in
+	// parallel, so it may be called from several goroutines at once and in
+	// any order.
+	RunFinished(run Run)
 }
 
-func (s BenchmarkService) HealthCheckModels(ctx context.Context) error {
+type ignoreProgress struct{}
+
+func (ignoreProgress) BenchmarkStarted(int) {}
+func (ignoreProgress) RunFinished(Run)      {}
+
+// HealthCheckModels checks every model selection picks.
+func (s BenchmarkService) HealthCheckModels(ctx context.Context, selection Selection) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	if len(s.Models) == 0 {
+	models := selection.SelectModels(s.Models)
+	if len(models) == 0 {
 		return fmt.Errorf("model health checks require configured models")
 	}
 
-	for _, model := range s.Models {
+	for _, model := range models {
 		if model.HealthCheck == nil {
 			return fmt.Errorf("health check for model %q is required", model.ID)
 		}
@@ -51,7 +110,7 @@ func (s BenchmarkService) HealthCheckModels(ctx context.Context) error {
 	return nil
 }
 
-func (s BenchmarkService) Run(ctx context.Context) (BenchmarkReport, error) {
+func (s BenchmarkService) Run(ctx context.Context, opts RunOptions) (BenchmarkReport, error) {
 	if err := ctx.Err(); err != nil {
 		return BenchmarkReport{}, err
 	}
@@ -73,6 +132,17 @@ func (s BenchmarkService) Run(ctx context.Context) (BenchmarkReport, error) {
 		return BenchmarkReport{}, fmt.Errorf("executor is required")
 	}
 
+	// The manifest must define a scaffold to compare with the baseline, but a
+	// selection may leave the baseline to run alone.
+	if !slices.ContainsFunc(s.Scaffolds.Scaffolds, func(scaffold Scaffold) bool { return !scaffold.Baseline }) {
+		return BenchmarkReport{}, fmt.Errorf("need at least one scaffolded scaffold")
+	}
+	tasks, scaffolds, models, err := s.selectMatrix(opts.Selection, models)
+	if err != nil {
+		return BenchmarkReport{}, err
+	}
+	s.Tasks, s.Scaffolds = tasks, scaffolds
+
 	var baselineScaffold *Scaffold
 	var scaffoldVariants []Scaffold
 	for i := range s.Scaffolds.Scaffolds {
@@ -91,9 +161,6 @@ func (s BenchmarkService) Run(ctx context.Context) (BenchmarkReport, error) {
 		return BenchmarkReport{}, fmt.Errorf("baseline scaffold is required")
 	}
 
-	if len(scaffoldVariants) == 0 {
-		return BenchmarkReport{}, fmt.Errorf("need at least one scaffolded scaffold")
-	}
 	if len(s.Tasks.Tasks) == 0 {
 		return BenchmarkReport{}, fmt.Errorf("at least one task is required")
 	}
@@ -103,33 +170,87 @@ func (s BenchmarkService) Run(ctx context.Context) (BenchmarkReport, error) {
 		grader = DefaultGrader{}
 	}
 
-	runs := make([]Run, 0, len(models)*len(s.Tasks.Tasks)*(1+len(scaffoldVariants)))
-	for _, model := range models {
-		for _, task := range s.Tasks.Tasks {
-			if err := ctx.Err(); err != nil {
-				return BenchmarkReport{}, err
-			}
+	runID := opts.ID()
+	ctx = logging.With(ctx, slog.String(logging.RunIDKey, runID))
+	ctx, span := tracing.Start(ctx, "benchmark.run",
+		attribute.String("run.id", runID),
+		attribute.Int("models", len(models)),
+		attribute.Int("tasks", len(s.Tasks.Tasks)),
+	)
+	report, err := s.runMatrix(ctx, opts, models, *baselineScaffold, scaffoldVariants, grader)
+	tracing.End(span, err)
+	if err != nil {
+		return BenchmarkReport{}, err
+	}
+	report.RunID = runID
+	return report, nil
+}
 
-			baselineRun := RunTaskWithGrader(ctx, task, *baselineScaffold, RunModeBaseline, model.Client, s.Executor, grader, s.Config)
-			baselineRun.ModelID = model.ID
-			runs = append(runs, baselineRun)
+func (s BenchmarkService) runMatrix(ctx context.Context, opts RunOptions, models []ModelClient, baselineScaffold Scaffold, scaffoldVariants []Scaffold, grader Grader) (BenchmarkReport, error) {
+	started := time.Now()
+	slog.InfoContext(ctx, "benchmark started", "models", len(models), "tasks", len(s.Tasks.Tasks), "scaffolds", 1+len(scaffoldVariants))
 
-			if err := ctx.Err(); err != nil {
-				return BenchmarkReport{}, err
-			}
+	total := len(models) * len(s.Tasks.Tasks) * (1 + len(scaffoldVariants)) * s.Config.EpochCount()
+	opts.RunObserver().BenchmarkStarted(total)
 
-			for _, scaffold := range scaffoldVariants {
-				run := RunTaskWithGrader(ctx, task, scaffold, RunModeScaffolded, model.Client, s.Executor, grader, s.Config)
-				run.ModelID = model.ID
-				runs = append(runs, run)
-				if err := ctx.Err(); err != nil {
-					return BenchmarkReport{}, err
-				}
-			}
-		}
+	runs, err := s.runPool(ctx, opts, models, baselineScaffold, scaffoldVariants, grader)
+	if err != nil {
+		slog.WarnContext(ctx, "benchmark cancelled", "error", err)
+		return BenchmarkReport{}, err
 	}
 
-	report := BuildBenchmarkReport(s.Tasks.Tasks, runs)
-	report.DefaultModelRoles = maps.Clone(s.DefaultModelRoles)
+	report := s.buildReport(opts.Selection, s.Tasks.Tasks, runs)
+	report.Grader = GraderVersion(grader)
+	recordPassRates(runs)
+	slog.InfoContext(ctx, "benchmark finished", "runs", len(runs), "duration_ms", time.Since(started).Milliseconds())
 	return report, nil
 }
+
+// buildReport aggregates runs of tasks picked by selection into a report
+// with its sampling and statistics sections.
+func (s BenchmarkService) buildReport(selection Selection, tasks []Task, runs []Run) BenchmarkReport {
+	report := BuildBenchmarkReport(tasks, runs)
+	report.DefaultModelRoles = maps.Clone(s.DefaultModelRoles)
+	report.Sampling = BuildSamplingReport(runs, s.Config.EpochCount(), s.Config.PassAtKValues())
+	report.Statistics = BuildStatistics(tasks, runs, report, StatisticsOptions{Alpha: s.Config.Alpha(), Resamples: s.Config.Resamples()})
+	if !selection.IsZero() {
+		report.Selection = &selection
+	}
+	return report
+}
+
+// runLogged runs one task for model, logs the result with ctx's correlation
+// IDs and reports it to observer.
+func runLogged(ctx context.Context, task Task, scaffold Scaffold, mode RunMode, epoch int, model ModelClient, exec Executor, grader Grader, cfg config.Config) Run {
+	started := time.Now()
+	run := RunTaskWithGrader(ctx, task, scaffold, mode, model.Client, exec, grader, cfg)
+	run.ModelID = model.ID
+	run.Epoch = epoch
+
+	attrs := []any{"scaffold", scaffold.Name, "mode", mode, "epoch", epoch, "passed", run.Passed, "duration_ms", time.Since(started).Milliseconds()}
+	if run.Error != "" {
+		slog.WarnContext(ctx, "task run failed", append(attrs, "error", run.Error)...)
+	} else {
+		slog.InfoContext(ctx, "task run finished", attrs...)
+	}
+	return run
+}
+
+// recordPassRates publishes the pass rate of each model and scaffold pair
+// from a finished benchmark.
+func recordPassRates(runs []Run) {
+	type key struct{ model, scaffold string }
+	passed := map[key]int{}
+	total := map[key]int{}
+	for _, run := range runs {
+		k := key{run.ModelID, run.Scaffold.Name}
+		total[k]++
+		if run.Passed {
+			passed[k]++
+		}
+	}
+	metrics.ResetBenchmarkPassRates()
+	for k, n := range total {
+		metrics.SetBenchmarkPassRate(k.model, k.scaffold, float64(passed[k])/float64(n))
+	}
+}
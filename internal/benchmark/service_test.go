@@ -8,6 +8,9 @@ import (
 
 	"gexec-sandbox/internal/api"
 	"gexec-sandbox/internal/config"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestBenchmarkServiceRunReturnsReport(t *testing.T) {
@@ -51,7 +54,7 @@ func TestBenchmarkServiceRunReturnsReport(t *testing.T) {
 		},
 	}
 
-	report, err := svc.Run(context.Background())
+	report, err := svc.Run(context.Background(), RunOptions{})
 	if err != nil {
 		t.Fatalf("Run() error = %v", err)
 	}
@@ -148,7 +151,7 @@ func TestBenchmarkServiceRunExecutesEveryModelAndAttributesRuns(t *testing.T) {
 		}},
 	}
 
-	report, err := svc.Run(context.Background())
+	report, err := svc.Run(context.Background(), RunOptions{})
 	if err != nil {
 		t.Fatalf("Run() error = %v", err)
 	}
@@ -180,6 +183,57 @@ func TestBenchmarkServiceRunExecutesEveryModelAndAttributesRuns(t *testing.T) {
 	}
 }
 
+func TestBenchmarkServiceRunRecordsNestedSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	original := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(original) })
+
+	svc := BenchmarkService{
+		Tasks: TaskCatalog{Tasks: []Task{{
+			ID: "task-1", Description: "demo", Language: "python",
+			TestCases: []TestCase{{ExpectedOutput: "ok"}},
+		}}},
+		Scaffolds: ScaffoldCatalog{Scaffolds: []Scaffold{
+			{Baseline: true, Name: "baseline"},
+			{Name: "tool-assisted", PromptPrefix: "tool: "},
+		}},
+		Models: []ModelClient{{ID: "alpha", Client: benchmarkServiceLLMClient{codeByPrompt: map[string]string{"demo": "print('ok')", "tool: demo": "print('ok')"}}}},
+		Executor: benchmarkServiceExecutor{responseBySource: map[string]api.ExecutionResponse{
+			"print('ok')": {Stdout: "ok"},
+		}},
+	}
+	report, err := svc.Run(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	spans := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	if len(spans["benchmark.run"]) != 1 || len(spans["benchmark.model"]) != 1 || len(spans["benchmark.task"]) != 2 || len(spans["benchmark.grade"]) != 2 {
+		t.Fatalf("span counts = run %d model %d task %d grade %d, want 1 1 2 2",
+			len(spans["benchmark.run"]), len(spans["benchmark.model"]), len(spans["benchmark.task"]), len(spans["benchmark.grade"]))
+	}
+
+	run := spans["benchmark.run"][0]
+	model := spans["benchmark.model"][0]
+	if model.Parent().SpanID() != run.SpanContext().SpanID() {
+		t.Fatal("benchmark.model is not a child of benchmark.run")
+	}
+	for _, task := range spans["benchmark.task"] {
+		if task.Parent().SpanID() != model.SpanContext().SpanID() {
+			t.Fatal("benchmark.task is not a child of benchmark.model")
+		}
+	}
+	for _, attr := range run.Attributes() {
+		if attr.Key == "run.id" && attr.Value.AsString() != report.RunID {
+			t.Fatalf("run.id = %q, want report RunID %q", attr.Value.AsString(), report.RunID)
+		}
+	}
+}
+
 func TestBenchmarkServiceHealthCheckModelsInvokesEveryConfiguredModel(t *testing.T) {
 	checked := make([]string, 0, 2)
 	svc := BenchmarkService{Models: []ModelClient{
@@ -193,7 +247,7 @@ func TestBenchmarkServiceHealthCheckModelsInvokesEveryConfiguredModel(t *testing
 		}},
 	}}
 
-	if err := svc.HealthCheckModels(context.Background()); err != nil {
+	if err := svc.HealthCheckModels(context.Background(), Selection{}); err != nil {
 		t.Fatalf("HealthCheckModels() error = %v", err)
 	}
 	if got := strings.Join(checked, ","); got != "alpha,beta" {
@@ -271,7 +325,7 @@ func TestBenchmarkServiceRunReturnsErrorWhenContextCanceledBeforeWorkBegins(t *t
 		Grader:   DefaultGrader{},
 	}
 
-	_, err := svc.Run(ctx)
+	_, err := svc.Run(ctx, RunOptions{})
 	if !errors.Is(err, context.Canceled) {
 		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
 	}
@@ -338,7 +392,7 @@ func TestBenchmarkServiceRunReturnsErrorWhenContextCanceledBetweenTasks(t *testi
 		Grader:   DefaultGrader{},
 	}
 
-	_, err := svc.Run(ctx)
+	_, err := svc.Run(ctx, RunOptions{})
 	if !errors.Is(err, context.Canceled) {
 		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
 	}
@@ -393,7 +447,7 @@ func TestBenchmarkServiceRunReturnsErrorWhenContextCanceledDuringFinalScaffold(t
 		Grader:   DefaultGrader{},
 	}
 
-	_, err := svc.Run(ctx)
+	_, err := svc.Run(ctx, RunOptions{})
 	if !errors.Is(err, context.Canceled) {
 		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
 	}
@@ -411,7 +465,7 @@ func TestBenchmarkServiceRunReturnsErrorWhenClientMissing(t *testing.T) {
 		Executor: &countingBenchmarkServiceExecutor{},
 	}
 
-	_, err := svc.Run(context.Background())
+	_, err := svc.Run(context.Background(), RunOptions{})
 	if err == nil || err.Error() != "llm client is required" {
 		t.Fatalf("Run() error = %v, want llm client is required", err)
 	}
@@ -425,7 +479,7 @@ func TestBenchmarkServiceRunReturnsErrorWhenExecutorMissing(t *testing.T) {
 		Client: &countingBenchmarkServiceLLMClient{},
 	}
 
-	_, err := svc.Run(context.Background())
+	_, err := svc.Run(context.Background(), RunOptions{})
 	if err == nil || err.Error() != "executor is required" {
 		t.Fatalf("Run() error = %v, want executor is required", err)
 	}
@@ -440,7 +494,7 @@ func TestBenchmarkServiceRunReturnsErrorWhenScaffoldsInsufficient(t *testing.T)
 		},
 	}
 
-	_, err := svc.Run(context.Background())
+	_, err := svc.Run(context.Background(), RunOptions{})
 	if err == nil || err.Error() != "need at least one scaffolded scaffold" {
 		t.Fatalf("Run() error = %v, want need at least one scaffolded scaffold", err)
 	}
@@ -455,7 +509,7 @@ func TestBenchmarkServiceRunReturnsErrorWhenBaselineMissing(t *testing.T) {
 		},
 	}
 
-	_, err := svc.Run(context.Background())
+	_, err := svc.Run(context.Background(), RunOptions{})
 	if err == nil || err.Error() != "baseline scaffold is required" {
 		t.Fatalf("Run() error = %v, want baseline scaffold is required", err)
 	}
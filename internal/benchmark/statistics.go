@@ -0,0 +1,289 @@
+package benchmark
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+)
+
+// Interval is a percentile bootstrap confidence interval around Estimate.
+type Interval struct {
+	Estimate float64 `json:"estimate"`
+	Low      float64 `json:"low"`
+	High     float64 `json:"high"`
+}
+
+// ScaffoldComparison pairs a scaffold with the baseline on the same model,
+// task and epoch. Fixed counts pairs only the scaffold passed and Broken
+// pairs only the baseline passed; McNemar's exact test uses just those. Lift
+// and BootstrapP resample model and task units by their pass@1 rates.
+// Significant follows McNemarP alone; BootstrapP is a cross-check.
+type ScaffoldComparison struct {
+	ModelID     string   `json:"model_id,omitempty"`
+	Scaffold    string   `json:"scaffold"`
+	Pairs       int      `json:"pairs"`
+	Fixed       int      `json:"fixed"`
+	Broken      int      `json:"broken"`
+	Lift        Interval `json:"lift"`
+	McNemarP    float64  `json:"mcnemar_p"`
+	BootstrapP  float64  `json:"bootstrap_p"`
+	Significant bool     `json:"significant"`
+}
+
+// StatisticsReport quantifies the uncertainty of a report's rates. Intervals
+// is keyed by each rate's path in the report, as in report diffs, such as
+// "lift" or "by_family.finance_workflows.baseline_success_rate".
+// Comparisons has one entry per scaffold across all models, then one per
+// model and scaffold when there are several models.
+type StatisticsReport struct {
+	Alpha       float64              `json:"alpha"`
+	Resamples   int                  `json:"resamples"`
+	Intervals   map[string]Interval  `json:"intervals"`
+	Comparisons []ScaffoldComparison `json:"comparisons"`
+	// LiftSignificant reports whether the comparison behind the report's
+	// headline lift is significant at Alpha.
+	LiftSignificant bool `json:"lift_significant"`
+}
+
+// StatisticsOptions sets the significance level and how many bootstrap
+// resamples estimate each interval.
+type StatisticsOptions struct {
+	Alpha     float64
+	Resamples int
+}
+
+// pairedUnit holds the baseline's and a scaffold's pass rates over one
+// model and task's samples, as in the report's success rates, or whether each
+// passed one epoch.
+type pairedUnit struct {
+	baseline, scaffold float64
+}
+
+// BuildStatistics bootstraps confidence intervals for every success rate and
+// lift in report and tests each scaffold against the baseline. Resampling is
+// seeded by each metric's name, so the same runs always give the same
+// intervals.
+func BuildStatistics(tasks []Task, runs []Run, report BenchmarkReport, opts StatisticsOptions) *StatisticsReport {
+	modelIDs := reportModelIDs(runs)
+	byScaffold := map[string][]Run{}
+	passedEpoch := map[string]map[string]bool{}
+	epochs := 1
+	for _, run := range runs {
+		name := run.Scaffold.Name
+		if run.Mode == RunModeBaseline {
+			name = ""
+		}
+		byScaffold[name] = append(byScaffold[name], run)
+		if passedEpoch[name] == nil {
+			passedEpoch[name] = map[string]bool{}
+		}
+		passedEpoch[name][epochIdentity(runIdentity(run), run.Epoch)] = run.Passed
+		epochs = max(epochs, run.Epoch+1)
+	}
+	passRates := map[string]map[string]float64{}
+	for name, scaffoldRuns := range byScaffold {
+		passRates[name] = passRatesByTask(scaffoldRuns)
+	}
+	units := func(models []string, scaffold string, keep func(Task) bool) []pairedUnit {
+		var units []pairedUnit
+		for _, model := range models {
+			for _, task := range tasks {
+				if keep != nil && !keep(task) {
+					continue
+				}
+				id := modelTaskIdentity(model, task.ID)
+				units = append(units, pairedUnit{baseline: passRates[""][id], scaffold: passRates[scaffold][id]})
+			}
+		}
+		return units
+	}
+	// epochPairs pairs the baseline and scaffold sample of each epoch; a
+	// missing sample counts as failed, like a task without runs.
+	epochPairs := func(models []string, scaffold string) []pairedUnit {
+		var pairs []pairedUnit
+		for _, model := range models {
+			for _, task := range tasks {
+				for epoch := range epochs {
+					id := epochIdentity(modelTaskIdentity(model, task.ID), epoch)
+					pairs = append(pairs, pairedUnit{baseline: float64(boolCount(passedEpoch[""][id])), scaffold: float64(boolCount(passedEpoch[scaffold][id]))})
+				}
+			}
+		}
+		return pairs
+	}
+
+	stats := &StatisticsReport{Alpha: opts.Alpha, Resamples: opts.Resamples, Intervals: map[string]Interval{}, Comparisons: []ScaffoldComparison{}}
+	stats.addRates("", units(modelIDs, report.ScaffoldedScaffold, nil), report.ScaffoldedScaffold, opts)
+	for _, family := range slices.Sorted(maps.Keys(report.ByFamily)) {
+		stats.addRates("by_family."+family+".", units(modelIDs, report.ScaffoldedScaffold, func(task Task) bool { return task.TaskFamily == family }), report.ScaffoldedScaffold, opts)
+	}
+	for _, scaffold := range slices.Sorted(maps.Keys(report.ByScaffold)) {
+		stats.addRates("by_scaffold."+scaffold+".", units(modelIDs, scaffold, nil), scaffold, opts)
+	}
+	for _, model := range slices.Sorted(maps.Keys(report.ByModel)) {
+		scaffold := report.ByModel[model].ScaffoldedScaffold
+		stats.addRates("by_model."+model+".", units([]string{model}, scaffold, nil), scaffold, opts)
+	}
+
+	for _, scaffold := range slices.Sorted(maps.Keys(report.ByScaffold)) {
+		comparison := compareScaffold(units(modelIDs, scaffold, nil), epochPairs(modelIDs, scaffold), "compare."+scaffold, opts)
+		comparison.Scaffold = scaffold
+		stats.Comparisons = append(stats.Comparisons, comparison)
+		if scaffold == report.ScaffoldedScaffold {
+			stats.LiftSignificant = comparison.Significant
+		}
+	}
+	if len(report.ByModel) > 1 {
+		for _, model := range slices.Sorted(maps.Keys(report.ByModel)) {
+			for _, scaffold := range slices.Sorted(maps.Keys(report.ByScaffold)) {
+				comparison := compareScaffold(units([]string{model}, scaffold, nil), epochPairs([]string{model}, scaffold), "compare."+model+"."+scaffold, opts)
+				comparison.ModelID, comparison.Scaffold = model, scaffold
+				stats.Comparisons = append(stats.Comparisons, comparison)
+			}
+		}
+	}
+	return stats
+}
+
+// addRates adds intervals for the rates under prefix. Without a scaffold,
+// only the baseline has one.
+func (s *StatisticsReport) addRates(prefix string, units []pairedUnit, scaffold string, opts StatisticsOptions) {
+	baseline, scaffolded, lift := bootstrap(units, prefix, opts)
+	s.Intervals[prefix+"baseline_success_rate"] = baseline
+	if scaffold != "" {
+		s.Intervals[prefix+"scaffolded_success_rate"] = scaffolded
+		s.Intervals[prefix+"lift"] = lift
+	}
+}
+
+// compareScaffold counts McNemar's discordant pairs over per-epoch pairs and
+// bootstraps the lift over per-task units.
+func compareScaffold(units []pairedUnit, pairs []pairedUnit, seed string, opts StatisticsOptions) ScaffoldComparison {
+	comparison := ScaffoldComparison{Pairs: len(pairs)}
+	for _, unit := range pairs {
+		switch {
+		case unit.scaffold > unit.baseline:
+			comparison.Fixed++
+		case unit.scaffold < unit.baseline:
+			comparison.Broken++
+		}
+	}
+	var lifts []float64
+	_, _, comparison.Lift, lifts = bootstrapSamples(units, seed, opts)
+	comparison.McNemarP = McNemarExactP(comparison.Fixed, comparison.Broken)
+	comparison.BootstrapP = bootstrapP(lifts)
+	comparison.Significant = comparison.McNemarP < opts.Alpha
+	return comparison
+}
+
+func bootstrap(units []pairedUnit, seed string, opts StatisticsOptions) (Interval, Interval, Interval) {
+	baseline, scaffolded, lift, _ := bootstrapSamples(units, seed, opts)
+	return baseline, scaffolded, lift
+}
+
+// bootstrapSamples resamples units with replacement and returns intervals for
+// the baseline rate, scaffold rate and their paired difference, along with
+// every resampled difference.
+func bootstrapSamples(units []pairedUnit, seed string, opts StatisticsOptions) (Interval, Interval, Interval, []float64) {
+	baselineRate, scaffoldRate := meanUnits(units)
+	if len(units) == 0 || opts.Resamples <= 0 {
+		return Interval{baselineRate, baselineRate, baselineRate}, Interval{scaffoldRate, scaffoldRate, scaffoldRate},
+			Interval{scaffoldRate - baselineRate, scaffoldRate - baselineRate, scaffoldRate - baselineRate}, nil
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(seed))
+	rng := rand.New(rand.NewPCG(hash.Sum64(), 0))
+	baselines := make([]float64, opts.Resamples)
+	scaffolds := make([]float64, opts.Resamples)
+	lifts := make([]float64, opts.Resamples)
+	for i := range opts.Resamples {
+		var b, s float64
+		for range units {
+			unit := units[rng.IntN(len(units))]
+			b += unit.baseline
+			s += unit.scaffold
+		}
+		baselines[i] = b / float64(len(units))
+		scaffolds[i] = s / float64(len(units))
+		lifts[i] = scaffolds[i] - baselines[i]
+	}
+	return percentileInterval(baselineRate, baselines, opts.Alpha),
+		percentileInterval(scaffoldRate, scaffolds, opts.Alpha),
+		percentileInterval(scaffoldRate-baselineRate, lifts, opts.Alpha),
+		lifts
+}
+
+func meanUnits(units []pairedUnit) (float64, float64) {
+	if len(units) == 0 {
+		return 0, 0
+	}
+	var baseline, scaffold float64
+	for _, unit := range units {
+		baseline += unit.baseline
+		scaffold += unit.scaffold
+	}
+	return baseline / float64(len(units)), scaffold / float64(len(units))
+}
+
+func epochIdentity(id string, epoch int) string {
+	return fmt.Sprintf("%s\x00%d", id, epoch)
+}
+
+func percentileInterval(estimate float64, samples []float64, alpha float64) Interval {
+	sorted := slices.Clone(samples)
+	sort.Float64s(sorted)
+	return Interval{Estimate: estimate, Low: quantile(sorted, alpha/2), High: quantile(sorted, 1-alpha/2)}
+}
+
+// quantile interpolates linearly between the closest ranks of sorted.
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := min(lower+1, len(sorted)-1)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// bootstrapP is the two-sided share of resampled lifts on the far side of
+// zero from the observed direction.
+func bootstrapP(lifts []float64) float64 {
+	if len(lifts) == 0 {
+		return 1
+	}
+	var atMost, atLeast int
+	for _, lift := range lifts {
+		if lift <= 0 {
+			atMost++
+		}
+		if lift >= 0 {
+			atLeast++
+		}
+	}
+	return math.Min(1, 2*float64(min(atMost, atLeast))/float64(len(lifts)))
+}
+
+// McNemarExactP is the two-sided exact McNemar p-value for b pairs that
+// changed one way and c the other: a binomial test of min(b, c) successes in
+// b+c trials at one half.
+func McNemarExactP(b int, c int) float64 {
+	n := b + c
+	if n == 0 {
+		return 1
+	}
+	tail := 0.0
+	for i := 0; i <= min(b, c); i++ {
+		tail += math.Exp(logChoose(n, i) - float64(n)*math.Ln2)
+	}
+	return math.Min(1, 2*tail)
+}
+
+func logChoose(n int, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
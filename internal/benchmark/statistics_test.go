@@ -0,0 +1,129 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMcNemarExactP(t *testing.T) {
+	for _, tc := range []struct {
+		b, c int
+		want float64
+	}{
+		{0, 0, 1},
+		{6, 0, 2.0 / 64},
+		{5, 1, 2 * 7.0 / 64},
+		{3, 3, 1},
+	} {
+		if got := McNemarExactP(tc.b, tc.c); math.Abs(got-tc.want) > 1e-12 {
+			t.Fatalf("McNemarExactP(%d, %d) = %v, want %v", tc.b, tc.c, got, tc.want)
+		}
+	}
+}
+
+func TestBuildStatisticsFlagsInsignificantLift(t *testing.T) {
+	baseline := Scaffold{Baseline: true, Name: "baseline"}
+	strong := Scaffold{Name: "strong"}
+	weak := Scaffold{Name: "weak"}
+	var tasks []Task
+	var runs []Run
+	for i := range 20 {
+		task := Task{ID: fmt.Sprintf("t%02d", i), TaskFamily: "ops"}
+		tasks = append(tasks, task)
+		runs = append(runs,
+			Run{TaskID: task.ID, Mode: RunModeBaseline, Scaffold: baseline, Passed: i < 5},
+			Run{TaskID: task.ID, Mode: RunModeScaffolded, Scaffold: strong, Passed: i < 17},
+			Run{TaskID: task.ID, Mode: RunModeScaffolded, Scaffold: weak, Passed: i < 6},
+		)
+	}
+	report := BuildBenchmarkReport(tasks, runs)
+	opts := StatisticsOptions{Alpha: 0.05, Resamples: 500}
+
+	stats := BuildStatistics(tasks, runs, report, opts)
+	if len(stats.Comparisons) != 2 {
+		t.Fatalf("Comparisons = %+v, want one per scaffold", stats.Comparisons)
+	}
+	strongCmp, weakCmp := stats.Comparisons[0], stats.Comparisons[1]
+	if strongCmp.Scaffold != "strong" || strongCmp.Fixed != 12 || strongCmp.Broken != 0 || !strongCmp.Significant {
+		t.Fatalf("strong = %+v, want 12 fixed pairs and a significant lift", strongCmp)
+	}
+	if weakCmp.Fixed != 1 || weakCmp.Significant || weakCmp.BootstrapP < 0.05 {
+		t.Fatalf("weak = %+v, want one fixed pair and no significant lift", weakCmp)
+	}
+	if !stats.LiftSignificant {
+		t.Fatal("LiftSignificant = false, want the headline strong scaffold to be significant")
+	}
+
+	lift := stats.Intervals["lift"]
+	if math.Abs(lift.Estimate-report.Lift) > 1e-12 || lift.Low <= 0 || lift.High < lift.Estimate {
+		t.Fatalf("lift interval = %+v, want it around %v and above zero", lift, report.Lift)
+	}
+	if weakLift := stats.Intervals["by_scaffold.weak.lift"]; weakLift.Low > 0 {
+		t.Fatalf("weak lift interval = %+v, want it to include zero", weakLift)
+	}
+	if _, ok := stats.Intervals["by_family.ops.baseline_success_rate"]; !ok {
+		t.Fatalf("Intervals = %v, want family rates", stats.Intervals)
+	}
+
+	if again := BuildStatistics(tasks, runs, report, opts); !reflect.DeepEqual(stats, again) {
+		t.Fatal("BuildStatistics() is not reproducible for the same runs")
+	}
+}
+
+func TestBuildStatisticsIntervalMatchesBinomialSpread(t *testing.T) {
+	var tasks []Task
+	var runs []Run
+	for i := range 100 {
+		task := Task{ID: fmt.Sprintf("t%03d", i), TaskFamily: "ops"}
+		tasks = append(tasks, task)
+		runs = append(runs,
+			Run{TaskID: task.ID, Mode: RunModeBaseline, Scaffold: Scaffold{Baseline: true, Name: "baseline"}, Passed: i%2 == 0},
+			Run{TaskID: task.ID, Mode: RunModeScaffolded, Scaffold: Scaffold{Name: "tool"}, Passed: true},
+		)
+	}
+	report := BuildBenchmarkReport(tasks, runs)
+
+	stats := BuildStatistics(tasks, runs, report, StatisticsOptions{Alpha: 0.05, Resamples: 4000})
+
+	// 50 of 100 passing has a standard error of 0.05, so the 95% interval
+	// is close to 0.5 ± 1.96 × 0.05.
+	baseline := stats.Intervals["baseline_success_rate"]
+	if baseline.Estimate != 0.5 || math.Abs(baseline.Low-0.402) > 0.02 || math.Abs(baseline.High-0.598) > 0.02 {
+		t.Fatalf("baseline interval = %+v, want about [0.40, 0.60]", baseline)
+	}
+	if scaffolded := stats.Intervals["scaffolded_success_rate"]; scaffolded != (Interval{1, 1, 1}) {
+		t.Fatalf("scaffolded interval = %+v, want no spread when every task passes", scaffolded)
+	}
+	if lift := stats.Intervals["lift"]; lift.Estimate != 0.5 || math.Abs(lift.Low-0.402) > 0.02 || math.Abs(lift.High-0.598) > 0.02 {
+		t.Fatalf("lift interval = %+v, want about [0.40, 0.60]", lift)
+	}
+}
+
+func TestBuildStatisticsPairsSamplesByEpoch(t *testing.T) {
+	var tasks []Task
+	var runs []Run
+	for i := range 10 {
+		task := Task{ID: fmt.Sprintf("t%02d", i), TaskFamily: "ops"}
+		tasks = append(tasks, task)
+		// Both pass half their samples, but never on the same epoch.
+		for epoch := range 2 {
+			runs = append(runs,
+				Run{TaskID: task.ID, Mode: RunModeBaseline, Scaffold: Scaffold{Baseline: true, Name: "baseline"}, Epoch: epoch, Passed: epoch == 0},
+				Run{TaskID: task.ID, Mode: RunModeScaffolded, Scaffold: Scaffold{Name: "tool"}, Epoch: epoch, Passed: epoch == 1},
+			)
+		}
+	}
+	report := BuildBenchmarkReport(tasks, runs)
+
+	stats := BuildStatistics(tasks, runs, report, StatisticsOptions{Alpha: 0.05, Resamples: 500})
+
+	comparison := stats.Comparisons[0]
+	if comparison.Pairs != 20 || comparison.Fixed != 10 || comparison.Broken != 10 || comparison.Significant {
+		t.Fatalf("comparison = %+v, want 20 epoch pairs split evenly and no significant lift", comparison)
+	}
+	if comparison.Lift != (Interval{}) || stats.Intervals["baseline_success_rate"].Estimate != 0.5 {
+		t.Fatalf("lift = %+v, baseline = %+v; want pass@1 rates of 0.5 and no lift", comparison.Lift, stats.Intervals["baseline_success_rate"])
+	}
+}
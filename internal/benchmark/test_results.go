@@ -0,0 +1,228 @@
+package benchmark
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"gexec-sandbox/internal/api"
+)
+
+// gradeTestReport turns a test runner report into per-test results. The score
+// is the fraction of non-skipped tests that passed.
+func gradeTestReport(resp api.ExecutionResponse) Outcome {
+	if resp.Error != "" {
+		return Outcome{Verdict: VerdictRuntimeError, Message: resp.Error}
+	}
+
+	results, err := parseTestReport(resp.TestReportFormat, resp.TestReport)
+	if err != nil {
+		return Outcome{Verdict: VerdictCheckerError, Message: err.Error()}
+	}
+
+	passed, counted := 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case TestStatusPassed:
+			passed++
+			counted++
+		case TestStatusFailed:
+			counted++
+		}
+	}
+	if counted == 0 {
+		message := strings.TrimSpace(resp.Stderr)
+		if message == "" {
+			message = "test runner reported no tests"
+		}
+		return Outcome{Verdict: VerdictRuntimeError, Message: message, Tests: results}
+	}
+
+	outcome := Outcome{
+		Passed: passed == counted,
+		Score:  float64(passed) / float64(counted),
+		Tests:  results,
+	}
+	switch {
+	case outcome.Passed:
+		outcome.Verdict = VerdictAccepted
+	case passed > 0:
+		outcome.Verdict = VerdictPartial
+	default:
+		outcome.Verdict = VerdictWrongAnswer
+	}
+	outcome.Message = fmt.Sprintf("%d of %d tests passed", passed, counted)
+	return outcome
+}
+
+func parseTestReport(format string, report string) ([]TestResult, error) {
+	switch format {
+	case api.TestReportGoJSON:
+		return parseGoTestJSON(report)
+	case api.TestReportJUnitXML:
+		if strings.TrimSpace(report) == "" {
+			return nil, nil
+		}
+		return parseJUnitXML(report)
+	default:
+		return nil, fmt.Errorf("unsupported test report format %q", format)
+	}
+}
+
+type goTestEvent struct {
+	Action string `json:"Action"`
+	Test   string `json:"Test"`
+	Output string `json:"Output"`
+}
+
+// parseGoTestJSON reads a `go test -json` event stream. Lines that are not
+// JSON events, such as build output, are ignored.
+func parseGoTestJSON(report string) ([]TestResult, error) {
+	var order []string
+	status := map[string]string{}
+	output := map[string]*strings.Builder{}
+
+	scanner := bufio.NewScanner(strings.NewReader(report))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var event goTestEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+		if event.Test == "" {
+			continue
+		}
+		if _, seen := status[event.Test]; !seen {
+			order = append(order, event.Test)
+			status[event.Test] = ""
+			output[event.Test] = &strings.Builder{}
+		}
+		switch event.Action {
+		case "pass":
+			status[event.Test] = TestStatusPassed
+		case "fail":
+			status[event.Test] = TestStatusFailed
+		case "skip":
+			status[event.Test] = TestStatusSkipped
+		case "output":
+			output[event.Test].WriteString(event.Output)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read go test report: %w", err)
+	}
+
+	parents := map[string]bool{}
+	for _, name := range order {
+		for i := strings.LastIndex(name, "/"); i > 0; i = strings.LastIndex(name[:i], "/") {
+			parents[name[:i]] = true
+		}
+	}
+
+	results := make([]TestResult, 0, len(order))
+	for _, name := range order {
+		if parents[name] {
+			// Subtests carry the individual outcomes; counting the parent too
+			// would double-weight it in partial credit.
+			continue
+		}
+		result := TestResult{Name: name, Status: status[name]}
+		if result.Status == "" {
+			// A test that started but never finished was cut off by a panic or timeout.
+			result.Status = TestStatusFailed
+		}
+		if result.Status == TestStatusFailed {
+			result.Message = strings.TrimSpace(output[name].String())
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+type junitTestSuite struct {
+	Name  string           `xml:"name,attr"`
+	Cases []junitTestCase  `xml:"testcase"`
+	Inner []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	Skipped   *junitProblem `xml:"skipped"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// parseJUnitXML accepts either a <testsuites> root or a bare <testsuite>.
+func parseJUnitXML(report string) ([]TestResult, error) {
+	var root struct {
+		XMLName xml.Name
+		junitTestSuite
+		Suites []junitTestSuite `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal([]byte(report), &root); err != nil {
+		return nil, fmt.Errorf("parse junit report: %w", err)
+	}
+
+	var suites []junitTestSuite
+	switch root.XMLName.Local {
+	case "testsuites":
+		suites = root.Suites
+	case "testsuite":
+		suites = []junitTestSuite{{Name: root.Name, Cases: root.Cases, Inner: root.Suites}}
+	default:
+		return nil, fmt.Errorf("parse junit report: unexpected root element %q", root.XMLName.Local)
+	}
+
+	var results []TestResult
+	var walk func([]junitTestSuite)
+	walk = func(suites []junitTestSuite) {
+		for _, suite := range suites {
+			for _, tc := range suite.Cases {
+				results = append(results, junitResult(tc))
+			}
+			walk(suite.Inner)
+		}
+	}
+	walk(suites)
+	return results, nil
+}
+
+func junitResult(tc junitTestCase) TestResult {
+	name := tc.Name
+	if tc.ClassName != "" {
+		name = tc.ClassName + "." + tc.Name
+	}
+
+	result := TestResult{Name: name, Status: TestStatusPassed}
+	switch {
+	case tc.Failure != nil:
+		result.Status = TestStatusFailed
+		result.Message = junitMessage(tc.Failure)
+	case tc.Error != nil:
+		result.Status = TestStatusFailed
+		result.Message = junitMessage(tc.Error)
+	case tc.Skipped != nil:
+		result.Status = TestStatusSkipped
+		result.Message = junitMessage(tc.Skipped)
+	}
+	return result
+}
+
+func junitMessage(problem *junitProblem) string {
+	if problem.Message != "" {
+		return problem.Message
+	}
+	return strings.TrimSpace(problem.Text)
+}
@@ -0,0 +1,126 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+)
+
+const goTestReport = `{"Action":"start","Package":"submission"}
+{"Action":"run","Package":"submission","Test":"TestAdd"}
+{"Action":"output","Package":"submission","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"pass","Package":"submission","Test":"TestAdd","Elapsed":0}
+{"Action":"run","Package":"submission","Test":"TestTable"}
+{"Action":"run","Package":"submission","Test":"TestTable/zero"}
+{"Action":"pass","Package":"submission","Test":"TestTable/zero","Elapsed":0}
+{"Action":"run","Package":"submission","Test":"TestTable/negative"}
+{"Action":"output","Package":"submission","Test":"TestTable/negative","Output":"    main_test.go:12: got 1, want -1\n"}
+{"Action":"fail","Package":"submission","Test":"TestTable/negative","Elapsed":0}
+{"Action":"fail","Package":"submission","Test":"TestTable","Elapsed":0}
+{"Action":"run","Package":"submission","Test":"TestLater"}
+{"Action":"skip","Package":"submission","Test":"TestLater","Elapsed":0}
+{"Action":"fail","Package":"submission","Elapsed":0.01}
+`
+
+const junitReport = `<?xml version="1.0" encoding="utf-8"?>
+<testsuites><testsuite name="pytest" errors="1" failures="1" skipped="0" tests="4">
+<testcase classname="test_main" name="test_parse" time="0.001"/>
+<testcase classname="test_main" name="test_totals" time="0.001"><failure message="assert 3 == 4">trace</failure></testcase>
+<testcase classname="test_main" name="test_empty" time="0.001"><error message="fixture missing"/></testcase>
+<testcase classname="test_main" name="test_unicode" time="0.001"/>
+</testsuite></testsuites>`
+
+func TestParseGoTestJSONReportsLeafTests(t *testing.T) {
+	results, err := parseGoTestJSON(goTestReport)
+	if err != nil {
+		t.Fatalf("parseGoTestJSON() error = %v", err)
+	}
+
+	want := map[string]string{
+		"TestAdd":            TestStatusPassed,
+		"TestTable/zero":     TestStatusPassed,
+		"TestTable/negative": TestStatusFailed,
+		"TestLater":          TestStatusSkipped,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("results = %#v, want %d leaf tests", results, len(want))
+	}
+	for _, result := range results {
+		if want[result.Name] != result.Status {
+			t.Fatalf("result %q status = %q, want %q", result.Name, result.Status, want[result.Name])
+		}
+		if result.Name == "TestTable/negative" && result.Message != "main_test.go:12: got 1, want -1" {
+			t.Fatalf("failure message = %q, want test output", result.Message)
+		}
+	}
+}
+
+func TestParseJUnitXMLAcceptsBareTestSuite(t *testing.T) {
+	results, err := parseJUnitXML(`<testsuite name="s"><testcase classname="c" name="a"/><testcase name="b"><skipped message="later"/></testcase></testsuite>`)
+	if err != nil {
+		t.Fatalf("parseJUnitXML() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Name != "c.a" || results[1].Status != TestStatusSkipped || results[1].Message != "later" {
+		t.Fatalf("results = %#v, want passed c.a and skipped b", results)
+	}
+}
+
+func TestDefaultGraderScoresTestsPassModeWithPartialCredit(t *testing.T) {
+	task := Task{GradingMode: GradingModeTestsPass}
+
+	outcome := DefaultGrader{}.Grade(task, api.ExecutionResponse{TestReport: junitReport, TestReportFormat: api.TestReportJUnitXML}, TestCase{})
+
+	if outcome.Passed || outcome.Verdict != VerdictPartial || outcome.Score != 0.5 {
+		t.Fatalf("outcome = %#v, want partial credit 0.5", outcome)
+	}
+	if len(outcome.Tests) != 4 || outcome.Tests[1].Message != "assert 3 == 4" || outcome.Tests[2].Message != "fixture missing" {
+		t.Fatalf("Tests = %#v, want per-test junit results", outcome.Tests)
+	}
+}
+
+func TestDefaultGraderFailsTestsPassModeWithoutTests(t *testing.T) {
+	task := Task{GradingMode: GradingModeTestsPass}
+
+	outcome := DefaultGrader{}.Grade(task, api.ExecutionResponse{Stderr: "main.go:3: syntax error", TestReportFormat: api.TestReportGoJSON}, TestCase{})
+
+	if outcome.Passed || outcome.Verdict != VerdictRuntimeError || outcome.Message != "main.go:3: syntax error" {
+		t.Fatalf("outcome = %#v, want runtime error with build output", outcome)
+	}
+}
+
+func TestRunTaskInTestsPassModeRunsHiddenTestsOnce(t *testing.T) {
+	exec := &fakeExecutor{resp: api.ExecutionResponse{TestReport: goTestReport, TestReportFormat: api.TestReportGoJSON}}
+	task := Task{
+		ID:          "tests",
+		Description: "implement Add",
+		Language:    "go",
+		GradingMode: GradingModeTestsPass,
+		Tests:       &TestSuite{Files: map[string]string{"main_test.go": "package main"}, TimeoutMS: 5000},
+	}
+
+	run := RunTask(context.Background(), task, Scaffold{Name: "baseline"}, RunModeBaseline, &fakeLLMClient{code: "package main"}, exec, config.Config{DefaultTimeoutMS: 1000})
+
+	if run.Error != "" {
+		t.Fatalf("run.Error = %q, want none", run.Error)
+	}
+	if exec.seenReq.Mode != api.ExecutionModeTest || exec.seenReq.Files["main_test.go"] != "package main" || exec.seenReq.TimeoutMS != 5000 {
+		t.Fatalf("request = %#v, want test mode with hidden files and suite timeout", exec.seenReq)
+	}
+	if len(run.Outcomes) != 1 || run.Outcomes[0].Score != 2.0/3.0 || run.Passed {
+		t.Fatalf("Outcomes = %#v passed %v, want one partial-credit outcome", run.Outcomes, run.Passed)
+	}
+}
+
+func TestValidateTaskCatalogRequiresTestFilesForTestsPassMode(t *testing.T) {
+	task := Task{ID: "t", Title: "T", Description: "d", TaskFamily: "f", Language: "go", GradingMode: GradingModeTestsPass}
+	if err := ValidateTaskCatalog(TaskCatalog{Tasks: []Task{task}}); err == nil {
+		t.Fatal("ValidateTaskCatalog() error = nil, want missing tests error")
+	}
+
+	task.Tests = &TestSuite{Files: map[string]string{"main_test.go": "package main"}}
+	if err := ValidateTaskCatalog(TaskCatalog{Tasks: []Task{task}}); err != nil {
+		t.Fatalf("ValidateTaskCatalog() error = %v, want tests_pass task without test cases accepted", err)
+	}
+}
@@ -10,8 +10,61 @@ type Config struct {
 	Languages        map[string]string
 	OLLAMAHost       string
 	OLLAMAModel      string
+
+	// TestImages overrides the language image in test mode, for runners such
+	// as pytest that are not part of the base image.
+	TestImages map[string]string
+
+	// Audit runs benchmark submissions under strace. AuditImages supplies
+	// per-language images that have strace installed.
+	Audit       bool
+	AuditImages map[string]string
+
+	// MaxConcurrentExecutions caps how many sandbox containers run at once;
+	// further executions wait in line. Zero means no limit.
+	MaxConcurrentExecutions int
+
+	// BenchmarkConcurrency is how many benchmark runs execute at once. Their
+	// sandbox executions still wait for MaxConcurrentExecutions slots. Zero
+	// or one runs them one at a time.
+	BenchmarkConcurrency int
+
+	// Epochs is how many times a benchmark samples each task for each model
+	// and scaffold; zero means once. PassAtK lists the k values to estimate
+	// pass@k for, each at most Epochs.
+	Epochs  int
+	PassAtK []int
+
+	// SignificanceAlpha is the level at which a scaffold's lift counts as
+	// significant, and sets the width of confidence intervals.
+	// BootstrapResamples is how many resamples estimate each interval.
+	// Zero selects the defaults below.
+	SignificanceAlpha  float64
+	BootstrapResamples int
+
+	// MinTimeoutMS and MaxTimeoutMS bound a request's timeout_ms.
+	// MaxSourceBytes caps the source code and each companion file, and
+	// MaxStdinBytes caps stdin. Zero disables a bound.
+	MinTimeoutMS   int
+	MaxTimeoutMS   int
+	MaxSourceBytes int
+	MaxStdinBytes  int
 }
 
+// Request limits applied when the manifest does not set its own.
+const (
+	DefaultMinTimeoutMS   = 100
+	DefaultMaxTimeoutMS   = 300000
+	DefaultMaxSourceBytes = 256 << 10
+	DefaultMaxStdinBytes  = 1 << 20
+)
+
+// Report statistics defaults.
+const (
+	DefaultSignificanceAlpha  = 0.05
+	DefaultBootstrapResamples = 2000
+)
+
 func LoadConfig() Config {
 	ollamaHost := os.Getenv("OLLAMA_HOST")
 	if ollamaHost == "" {
@@ -28,6 +81,10 @@ func LoadConfig() Config {
 		MaxMemoryMB:      256,
 		OLLAMAHost:       ollamaHost,
 		OLLAMAModel:      ollamaModel,
+		MinTimeoutMS:     DefaultMinTimeoutMS,
+		MaxTimeoutMS:     DefaultMaxTimeoutMS,
+		MaxSourceBytes:   DefaultMaxSourceBytes,
+		MaxStdinBytes:    DefaultMaxStdinBytes,
 		Languages: map[string]string{
 			"python": "python:3.9-slim",
 			"py":     "python:3.9-slim",
@@ -36,3 +93,36 @@ func LoadConfig() Config {
 		},
 	}
 }
+
+// EpochCount is Epochs, or one when it is unset.
+func (c Config) EpochCount() int {
+	return max(c.Epochs, 1)
+}
+
+// PassAtKValues is PassAtK, or pass@1 and pass@Epochs when it is unset.
+func (c Config) PassAtKValues() []int {
+	if len(c.PassAtK) > 0 {
+		return c.PassAtK
+	}
+	if epochs := c.EpochCount(); epochs > 1 {
+		return []int{1, epochs}
+	}
+	return []int{1}
+}
+
+// Alpha is SignificanceAlpha, or DefaultSignificanceAlpha when it is unset.
+func (c Config) Alpha() float64 {
+	if c.SignificanceAlpha > 0 {
+		return c.SignificanceAlpha
+	}
+	return DefaultSignificanceAlpha
+}
+
+// Resamples is BootstrapResamples, or DefaultBootstrapResamples when it is
+// unset.
+func (c Config) Resamples() int {
+	if c.BootstrapResamples > 0 {
+		return c.BootstrapResamples
+	}
+	return DefaultBootstrapResamples
+}
@@ -38,3 +38,24 @@ func TestLoadConfigUsesDefaultModelWithoutEnv(t *testing.T) {
 		t.Fatalf("OLLAMAModel = %q, want qwen3:4b", cfg.OLLAMAModel)
 	}
 }
+
+func TestPassAtKValuesDefaultsToOneAndEveryEpoch(t *testing.T) {
+	if got := (Config{}).PassAtKValues(); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("PassAtKValues() = %v, want [1]", got)
+	}
+	if got := (Config{Epochs: 5}).PassAtKValues(); len(got) != 2 || got[1] != 5 {
+		t.Fatalf("PassAtKValues() with 5 epochs = %v, want [1 5]", got)
+	}
+	if got := (Config{Epochs: 5, PassAtK: []int{3}}).PassAtKValues(); len(got) != 1 || got[0] != 3 {
+		t.Fatalf("PassAtKValues() = %v, want the configured k", got)
+	}
+}
+
+func TestStatisticsSettingsFallBackToDefaults(t *testing.T) {
+	if got := (Config{}).Alpha(); got != DefaultSignificanceAlpha {
+		t.Fatalf("Alpha() = %v, want %v", got, DefaultSignificanceAlpha)
+	}
+	if got := (Config{BootstrapResamples: 100}).Resamples(); got != 100 {
+		t.Fatalf("Resamples() = %d, want 100", got)
+	}
+}
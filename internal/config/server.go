@@ -0,0 +1,82 @@
+package config
+
+import "time"
+
+// RateLimit is a token bucket: RequestsPerMinute refill rate and Burst
+// capacity. A zero RequestsPerMinute disables limiting for the endpoint.
+type RateLimit struct {
+	RequestsPerMinute float64
+	Burst             int
+}
+
+// Server holds HTTP and gRPC server settings.
+type Server struct {
+	// RateLimits is keyed by endpoint path without the /v1 prefix, such as
+	// "/execute".
+	RateLimits map[string]RateLimit
+	// TrustedProxies lists IPs or CIDRs whose X-Forwarded-For header is
+	// believed when identifying the client.
+	TrustedProxies []string
+	// MaxBodyBytes caps every request body. Zero disables the cap.
+	MaxBodyBytes int64
+	// GRPCAddr is the gRPC listen address. Empty disables the gRPC server.
+	GRPCAddr string
+
+	// Addr is the HTTP listen address.
+	Addr string
+	// TLSCertFile and TLSKeyFile serve both APIs over TLS when set.
+	TLSCertFile string
+	TLSKeyFile  string
+	// ReadTimeout, WriteTimeout and IdleTimeout bound HTTP connections as in
+	// http.Server. Zero disables a timeout.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownGrace is how long in-flight requests get to finish on shutdown
+	// before they are cut off and their containers removed.
+	ShutdownGrace time.Duration
+	// RunsDir holds a run directory per benchmark the server starts, which
+	// rescoring reads. Empty keeps no run logs.
+	RunsDir string
+	// MaxActiveRuns caps how many benchmark runs and rescores the server
+	// runs at once.
+	MaxActiveRuns int
+}
+
+// TLS reports whether the servers should listen with TLS.
+func (s Server) TLS() bool {
+	return s.TLSCertFile != ""
+}
+
+// DefaultMaxActiveRuns keeps a few benchmarks from starving the sandbox of
+// slots for direct executions.
+const DefaultMaxActiveRuns = 4
+
+// DefaultMaxBodyBytes leaves room for the largest source, stdin and
+// companion files a default configuration accepts.
+const DefaultMaxBodyBytes = 4 << 20
+
+// Listener defaults. There is no default write timeout because an execution
+// may legitimately run for the maximum request timeout.
+const (
+	DefaultAddr          = ":8080"
+	DefaultReadTimeout   = 30 * time.Second
+	DefaultIdleTimeout   = 2 * time.Minute
+	DefaultShutdownGrace = 30 * time.Second
+)
+
+// DefaultServer returns the settings used when the manifest does not
+// override them.
+func DefaultServer() Server {
+	return Server{
+		RateLimits: map[string]RateLimit{
+			"/execute": {RequestsPerMinute: 10, Burst: 10},
+		},
+		MaxBodyBytes:  DefaultMaxBodyBytes,
+		MaxActiveRuns: DefaultMaxActiveRuns,
+		Addr:          DefaultAddr,
+		ReadTimeout:   DefaultReadTimeout,
+		IdleTimeout:   DefaultIdleTimeout,
+		ShutdownGrace: DefaultShutdownGrace,
+	}
+}
@@ -0,0 +1,94 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/benchmark"
+	pb "gexec-sandbox/internal/grpcapi/evaluatorv1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func executionRequest(in *pb.ExecuteRequest) api.ExecutionRequest {
+	return api.ExecutionRequest{
+		Language:           in.GetLanguage(),
+		SourceCode:         in.GetSourceCode(),
+		Stdin:              in.GetStdin(),
+		TimeoutMS:          int(in.GetTimeoutMs()),
+		Files:              maps.Clone(in.GetFiles()),
+		Args:               append([]string(nil), in.GetArgs()...),
+		Mode:               in.GetMode(),
+		CaptureFileChanges: in.GetCaptureFileChanges(),
+		Audit:              in.GetAudit(),
+	}
+}
+
+func selector(in *pb.Selector) benchmark.Selector {
+	return benchmark.Selector{
+		Include: append([]string(nil), in.GetInclude()...),
+		Exclude: append([]string(nil), in.GetExclude()...),
+	}
+}
+
+func benchmarkSelection(in *pb.StartBenchmarkRequest) benchmark.Selection {
+	selection := benchmark.Selection{
+		Tasks:     selector(in.GetTasks()),
+		Families:  selector(in.GetFamilies()),
+		Tags:      selector(in.GetTags()),
+		Languages: selector(in.GetLanguages()),
+		Scaffolds: selector(in.GetScaffolds()),
+		Models:    selector(in.GetModels()),
+		Limit:     int(in.GetLimit()),
+	}
+	if in.Seed != nil {
+		seed := in.GetSeed()
+		selection.Seed = &seed
+	}
+	return selection
+}
+
+func executeResponse(resp api.ExecutionResponse) *pb.ExecuteResponse {
+	out := &pb.ExecuteResponse{
+		Stdout:           resp.Stdout,
+		Stderr:           resp.Stderr,
+		ExitCode:         int32(resp.ExitCode),
+		Error:            resp.Error,
+		DurationMs:       resp.DurationMS,
+		TestReport:       resp.TestReport,
+		TestReportFormat: resp.TestReportFormat,
+	}
+	for _, change := range resp.FileChanges {
+		out.FileChanges = append(out.FileChanges, &pb.FileChange{Path: change.Path, Kind: change.Kind})
+	}
+	if resp.Audit != nil {
+		out.Audit = &pb.AuditSummary{
+			NetworkAttempts: int32(resp.Audit.NetworkAttempts),
+			ProcessSpawns:   int32(resp.Audit.ProcessSpawns),
+			SensitivePaths:  int32(resp.Audit.SensitivePaths),
+			Blocked:         int32(resp.Audit.Blocked),
+		}
+	}
+	return out
+}
+
+func benchmarkRun(status benchmark.RunStatus) (*pb.BenchmarkRun, error) {
+	out := &pb.BenchmarkRun{
+		Id:        status.ID,
+		State:     status.State,
+		StartedAt: timestamppb.New(status.StartedAt),
+		Error:     status.Error,
+	}
+	if status.FinishedAt != nil {
+		out.FinishedAt = timestamppb.New(*status.FinishedAt)
+	}
+	if status.Report != nil {
+		raw, err := json.Marshal(status.Report)
+		if err != nil {
+			return nil, fmt.Errorf("encode benchmark report: %w", err)
+		}
+		out.ReportJson = raw
+	}
+	return out, nil
+}
@@ -0,0 +1,207 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gexec-sandbox/internal/auth"
+	pb "gexec-sandbox/internal/grpcapi/evaluatorv1"
+	"gexec-sandbox/internal/logging"
+	"gexec-sandbox/internal/metrics"
+	"gexec-sandbox/internal/middleware"
+	"gexec-sandbox/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDMetadata carries the call's correlation ID in both directions,
+// like the HTTP API's X-Request-ID header.
+var requestIDMetadata = strings.ToLower(middleware.RequestIDHeader)
+
+type method struct {
+	scope string
+	// startsWork marks calls refused while the server drains.
+	startsWork bool
+	// route is the HTTP route whose rate limit the method shares.
+	route string
+	// executions counts the executions a call carries; nil means one.
+	executions func(req any) int
+}
+
+// methods is keyed by full method name. A method missing here is refused, so
+// a new RPC cannot be exposed without choosing its scope.
+var methods = map[string]method{
+	pb.Evaluator_Execute_FullMethodName:         {scope: auth.ScopeExecute, startsWork: true, route: "/execute"},
+	pb.Evaluator_ExecuteStream_FullMethodName:   {scope: auth.ScopeExecute, startsWork: true, route: "/execute"},
+	pb.Evaluator_ExecuteBatch_FullMethodName:    {scope: auth.ScopeExecute, startsWork: true, route: "/execute", executions: batchSize},
+	pb.Evaluator_StartBenchmark_FullMethodName:  {scope: auth.ScopeBenchmark, startsWork: true, route: "/benchmark/run"},
+	pb.Evaluator_GetBenchmark_FullMethodName:    {scope: auth.ScopeBenchmark, route: "/benchmark/runs/{id}"},
+	pb.Evaluator_CancelBenchmark_FullMethodName: {scope: auth.ScopeBenchmark, route: "/benchmark/runs/{id}"},
+}
+
+// batchSize charges a batch one request and one execution slot per item. An
+// oversized batch is charged the most it could run; ExecuteBatch rejects it.
+func batchSize(req any) int {
+	batch, ok := req.(*pb.ExecuteBatchRequest)
+	if !ok {
+		return 1
+	}
+	return min(max(len(batch.GetRequests()), 1), MaxBatchSize)
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx, finish := s.begin(ctx, info.FullMethod)
+	defer func() { finish(err) }()
+
+	ctx, release, err := s.admit(ctx, info.FullMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return handler(ctx, req)
+}
+
+func (s *Server) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, finish := s.begin(stream.Context(), info.FullMethod)
+	defer func() { finish(err) }()
+
+	ctx, release, err := s.admit(ctx, info.FullMethod, nil)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return handler(srv, contextStream{ServerStream: stream, ctx: ctx})
+}
+
+// begin tags the call with a request ID and opens its server span. finish
+// closes the span and records the call's metrics and access log line.
+func (s *Server) begin(ctx context.Context, fullMethod string) (context.Context, func(error)) {
+	started := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := first(md, requestIDMetadata)
+	if !middleware.ValidRequestID(id) {
+		id = logging.NewID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+	ctx = logging.WithRequestID(ctx, id)
+
+	headers := http.Header{}
+	for key, values := range md {
+		for _, value := range values {
+			headers.Add(key, value)
+		}
+	}
+	service, name, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	ctx, span := tracing.StartServer(ctx, headers, fullMethod,
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", name),
+	)
+
+	return ctx, func(err error) {
+		elapsed := time.Since(started)
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		if serverFault(code) {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		span.End()
+
+		metrics.ObserveGRPCRequest(fullMethod, code.String(), elapsed)
+		slog.InfoContext(ctx, "grpc request", "method", fullMethod, "code", code.String(), "duration_ms", elapsed.Milliseconds())
+	}
+}
+
+// admit applies the same drain, API key and rate limit checks as the HTTP
+// middleware.
+func (s *Server) admit(ctx context.Context, fullMethod string, req any) (context.Context, func(), error) {
+	m, ok := methods[fullMethod]
+	if !ok {
+		return ctx, nil, status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+	}
+	if m.startsWork && s.Drain != nil && s.Drain.Draining() {
+		return ctx, nil, status.Error(codes.Unavailable, "server is draining and not accepting new work")
+	}
+
+	n := 1
+	if m.executions != nil {
+		n = m.executions(req)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := presentedKey(md)
+	// As over HTTP, the limit applies before authentication.
+	if limiter := s.RateLimits[m.route]; limiter != nil {
+		var addr string
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			addr = p.Addr.String()
+		}
+		if retryAfter, ok := limiter.AllowN(s.Keys.Identify(key), addr, md.Get("x-forwarded-for"), n); !ok {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+			return ctx, nil, status.Error(codes.ResourceExhausted, "too many requests")
+		}
+	}
+	ctx, release, err := s.Keys.AuthorizeN(ctx, key, m.scope, n)
+	if err != nil {
+		return ctx, nil, authStatus(err)
+	}
+	return ctx, release, nil
+}
+
+func authStatus(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, auth.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// presentedKey reads the key from "authorization: Bearer" or x-api-key.
+func presentedKey(md metadata.MD) string {
+	if token, ok := strings.CutPrefix(first(md, "authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(first(md, "x-api-key"))
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// serverFault reports codes that indicate a server problem rather than a bad
+// or cancelled call.
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// contextStream hands the handler the context built by the interceptor.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context {
+	return s.ctx
+}
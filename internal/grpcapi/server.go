@@ -0,0 +1,267 @@
+// Package grpcapi serves the Evaluator gRPC service defined in
+// proto/evaluator/v1. It shares the executor, benchmark runs, API keys and
+// metrics with the HTTP API.
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=module=gexec-sandbox/internal/grpcapi --go-grpc_out=. --go-grpc_opt=module=gexec-sandbox/internal/grpcapi evaluator/v1/evaluator.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/config"
+	pb "gexec-sandbox/internal/grpcapi/evaluatorv1"
+	"gexec-sandbox/internal/metrics"
+	"gexec-sandbox/internal/middleware"
+	"gexec-sandbox/internal/sandbox"
+	"gexec-sandbox/internal/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxBatchSize caps the requests in one ExecuteBatch call.
+const MaxBatchSize = 100
+
+// Server implements the Evaluator service.
+type Server struct {
+	pb.UnimplementedEvaluatorServer
+
+	Config   config.Config
+	Executor benchmark.Executor
+	Runs     *benchmark.RunManager
+	// Keys authenticates calls; nil leaves the service open.
+	Keys *auth.Keyring
+	// Drain refuses new work while set; nil accepts work.
+	Drain *middleware.Drain
+	// RateLimits are the HTTP API's limiters, keyed by route, so a client
+	// shares one budget across both transports.
+	RateLimits map[string]*middleware.RateLimiter
+}
+
+// New returns a gRPC server with srv registered behind the authentication,
+// rate limit, drain and instrumentation interceptors.
+func New(srv *Server, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(srv.unaryInterceptor),
+		grpc.ChainStreamInterceptor(srv.streamInterceptor),
+	)
+	s := grpc.NewServer(opts...)
+	pb.RegisterEvaluatorServer(s, srv)
+	return s
+}
+
+func (s *Server) Execute(ctx context.Context, in *pb.ExecuteRequest) (*pb.ExecuteResponse, error) {
+	metrics.IncrementRequest()
+	req, err := s.request(in)
+	if err != nil {
+		metrics.IncrementError()
+		return nil, err
+	}
+
+	resp, err := s.Executor.Execute(ctx, req, s.Config)
+	if err != nil {
+		metrics.IncrementError()
+		return nil, executionError(ctx, req, err)
+	}
+	return executeResponse(resp), nil
+}
+
+func (s *Server) ExecuteStream(in *pb.ExecuteRequest, stream grpc.ServerStreamingServer[pb.ExecuteEvent]) error {
+	metrics.IncrementRequest()
+	req, err := s.request(in)
+	if err != nil {
+		metrics.IncrementError()
+		return err
+	}
+	streamer, ok := s.Executor.(benchmark.StreamingExecutor)
+	if !ok {
+		metrics.IncrementError()
+		return status.Error(codes.Unimplemented, "the executor does not stream output")
+	}
+
+	// A failed send means the client has gone; its context is cancelled,
+	// which stops the execution, so send errors are not reported here.
+	var mu sync.Mutex
+	sink := func(name string, data []byte) {
+		chunk := &pb.OutputChunk{Stream: name, Data: append([]byte(nil), data...)}
+		mu.Lock()
+		defer mu.Unlock()
+		stream.Send(&pb.ExecuteEvent{Event: &pb.ExecuteEvent_Output{Output: chunk}})
+	}
+
+	ctx := stream.Context()
+	resp, err := streamer.ExecuteStream(ctx, req, s.Config, sink)
+	if err != nil {
+		metrics.IncrementError()
+		return executionError(ctx, req, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return stream.Send(&pb.ExecuteEvent{Event: &pb.ExecuteEvent_Result{Result: executeResponse(resp)}})
+}
+
+// ExecuteBatch runs every request concurrently; the sandbox's admission
+// limit decides how many run at once. The interceptor charges the caller's key
+// for every request in the batch. A failed request is reported in its result
+// rather than failing the call.
+func (s *Server) ExecuteBatch(ctx context.Context, in *pb.ExecuteBatchRequest) (*pb.ExecuteBatchResponse, error) {
+	if len(in.GetRequests()) > MaxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "a batch holds at most %d requests", MaxBatchSize)
+	}
+
+	results := make([]*pb.ExecuteBatchResult, len(in.GetRequests()))
+	var wg sync.WaitGroup
+	for i, item := range in.GetRequests() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := s.Execute(ctx, item)
+			if err != nil {
+				results[i] = batchError(err)
+				return
+			}
+			results[i] = &pb.ExecuteBatchResult{Response: resp}
+		}()
+	}
+	wg.Wait()
+	return &pb.ExecuteBatchResponse{Results: results}, nil
+}
+
+func (s *Server) StartBenchmark(ctx context.Context, in *pb.StartBenchmarkRequest) (*pb.BenchmarkRun, error) {
+	if s.Runs == nil {
+		return nil, status.Error(codes.Unimplemented, "benchmarks are not configured")
+	}
+	selection := benchmarkSelection(in)
+	if err := validation.BenchmarkSelection(selection); err != nil {
+		var invalid *validation.Error
+		errors.As(err, &invalid)
+		return nil, validationError(invalid)
+	}
+	return runResponse(s.Runs.Start(ctx, auth.KeyID(ctx), benchmark.RunOptions{Selection: selection}))
+}
+
+func (s *Server) GetBenchmark(ctx context.Context, in *pb.GetBenchmarkRequest) (*pb.BenchmarkRun, error) {
+	if s.Runs == nil {
+		return nil, status.Error(codes.Unimplemented, "benchmarks are not configured")
+	}
+	if err := s.ownsRun(ctx, in.GetId()); err != nil {
+		return nil, err
+	}
+	return runResponse(s.Runs.Get(in.GetId()))
+}
+
+func (s *Server) CancelBenchmark(ctx context.Context, in *pb.CancelBenchmarkRequest) (*pb.BenchmarkRun, error) {
+	if s.Runs == nil {
+		return nil, status.Error(codes.Unimplemented, "benchmarks are not configured")
+	}
+	if err := s.ownsRun(ctx, in.GetId()); err != nil {
+		return nil, err
+	}
+	return runResponse(s.Runs.Cancel(ctx, in.GetId()))
+}
+
+// ownsRun hides a run started by another key, as the HTTP API does, unless
+// the caller's key is an admin key.
+func (s *Server) ownsRun(ctx context.Context, id string) error {
+	if owner, err := s.Runs.Owner(id); err == nil && !auth.Owns(ctx, owner) {
+		return status.Error(codes.NotFound, fmt.Errorf("%w: %s", benchmark.ErrRunNotFound, id).Error())
+	}
+	return nil
+}
+
+func runResponse(run benchmark.RunStatus, err error) (*pb.BenchmarkRun, error) {
+	switch {
+	case errors.Is(err, benchmark.ErrRunNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, benchmark.ErrTooManyRuns):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil, status.FromContextError(err).Err()
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	out, err := benchmarkRun(run)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
+
+// request converts and validates in exactly as the HTTP handler does,
+// filling in the default timeout.
+func (s *Server) request(in *pb.ExecuteRequest) (api.ExecutionRequest, error) {
+	req := executionRequest(in)
+	if err := validation.ExecutionRequest(req, s.Config); err != nil {
+		var invalid *validation.Error
+		errors.As(err, &invalid)
+		return req, validationError(invalid)
+	}
+	if req.TimeoutMS == 0 {
+		req.TimeoutMS = s.Config.DefaultTimeoutMS
+	}
+	return req, nil
+}
+
+// validationError reports each invalid field as a BadRequest violation.
+func validationError(invalid *validation.Error) error {
+	st := status.New(codes.InvalidArgument, invalid.Error())
+	details := &errdetails.BadRequest{}
+	for _, field := range invalid.Fields {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field.Field,
+			Description: field.Message,
+		})
+	}
+	if withDetails, err := st.WithDetails(details); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// executionError maps an executor failure to the status code matching the
+// HTTP API's response.
+func executionError(ctx context.Context, req api.ExecutionRequest, err error) error {
+	slog.ErrorContext(ctx, "execute failed", "language", req.Language, "error", err)
+	switch {
+	case errors.Is(err, sandbox.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, sandbox.ErrKilled):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "execution exceeded timeout_ms")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// batchErrorCodes names each status in the HTTP API's error vocabulary.
+var batchErrorCodes = map[codes.Code]string{
+	codes.InvalidArgument:  api.ErrorCodeInvalidRequest,
+	codes.Aborted:          api.ErrorCodeExecutionKilled,
+	codes.DeadlineExceeded: api.ErrorCodeExecutionTimeout,
+}
+
+func batchError(err error) *pb.ExecuteBatchResult {
+	st := status.Convert(err)
+	code, ok := batchErrorCodes[st.Code()]
+	if !ok {
+		code = api.ErrorCodeExecutionFailed
+	}
+	for _, detail := range st.Details() {
+		if _, ok := detail.(*errdetails.BadRequest); ok {
+			code = api.ErrorCodeValidation
+		}
+	}
+	return &pb.ExecuteBatchResult{ErrorCode: code, ErrorMessage: st.Message()}
+}
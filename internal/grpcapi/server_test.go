@@ -0,0 +1,402 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/config"
+	pb "gexec-sandbox/internal/grpcapi/evaluatorv1"
+	"gexec-sandbox/internal/middleware"
+	"gexec-sandbox/internal/sandbox"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func testConfig() config.Config {
+	return config.Config{Languages: map[string]string{"python": "python:3.9-slim"}, DefaultTimeoutMS: 1500}
+}
+
+type fakeExecutor struct {
+	mu   sync.Mutex
+	reqs []api.ExecutionRequest
+}
+
+// Execute echoes the source as stdout, failing for sources named after an
+// error.
+func (f *fakeExecutor) Execute(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	f.mu.Lock()
+	f.reqs = append(f.reqs, req)
+	f.mu.Unlock()
+	if req.SourceCode == "killed" {
+		return api.ExecutionResponse{}, sandbox.ErrKilled
+	}
+	return api.ExecutionResponse{Stdout: req.SourceCode, ExitCode: 3, DurationMS: 12}, nil
+}
+
+type runFunc func(ctx context.Context, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error)
+
+func (f runFunc) Run(ctx context.Context, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+	return f(ctx, opts)
+}
+
+func dial(t *testing.T, srv *Server) pb.EvaluatorClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	s := New(srv)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewEvaluatorClient(conn)
+}
+
+func TestExecuteAppliesDefaultTimeoutAndEchoesRequestID(t *testing.T) {
+	executor := &fakeExecutor{}
+	client := dial(t, &Server{Config: testConfig(), Executor: executor})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "orchestrator-1")
+	var header metadata.MD
+	resp, err := client.Execute(ctx, &pb.ExecuteRequest{Language: "python", SourceCode: "print(1)"}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp.GetStdout() != "print(1)" || resp.GetExitCode() != 3 || resp.GetDurationMs() != 12 {
+		t.Fatalf("response = %v, want converted execution response", resp)
+	}
+	if executor.reqs[0].TimeoutMS != 1500 {
+		t.Fatalf("TimeoutMS = %d, want config default", executor.reqs[0].TimeoutMS)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "orchestrator-1" {
+		t.Fatalf("x-request-id header = %v, want client's ID", got)
+	}
+}
+
+func TestExecuteReportsInvalidFieldsAsBadRequest(t *testing.T) {
+	executor := &fakeExecutor{}
+	client := dial(t, &Server{Config: testConfig(), Executor: executor})
+
+	_, err := client.Execute(context.Background(), &pb.ExecuteRequest{Language: "cobol", TimeoutMs: -1})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %v, want InvalidArgument", st.Code())
+	}
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+	}
+	if strings.Join(fields, ",") != "language,source_code,timeout_ms" {
+		t.Fatalf("violations = %v, want language, source_code and timeout_ms", fields)
+	}
+	if len(executor.reqs) != 0 {
+		t.Fatal("executor ran an invalid request")
+	}
+}
+
+func TestCallsAreAuthenticatedAndScoped(t *testing.T) {
+	keys, err := auth.NewKeyring([]auth.Key{
+		{ID: "runner", Hash: auth.HashKey("run-secret"), Scopes: []string{auth.ScopeExecute}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	client := dial(t, &Server{Config: testConfig(), Executor: &fakeExecutor{}, Keys: keys, Runs: benchmark.NewRunManager(nil)})
+	req := &pb.ExecuteRequest{Language: "python", SourceCode: "print(1)"}
+
+	if _, err := client.Execute(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("no key: code = %v, want Unauthenticated", status.Code(err))
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer run-secret")
+	if _, err := client.Execute(ctx, req); err != nil {
+		t.Fatalf("Execute() with key error = %v", err)
+	}
+	if _, err := client.GetBenchmark(ctx, &pb.GetBenchmarkRequest{Id: "x"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("missing scope: code = %v, want PermissionDenied", status.Code(err))
+	}
+}
+
+func TestDrainRefusesOnlyNewWork(t *testing.T) {
+	drain := &middleware.Drain{}
+	drain.Start()
+	client := dial(t, &Server{Config: testConfig(), Executor: &fakeExecutor{}, Drain: drain, Runs: benchmark.NewRunManager(nil)})
+
+	_, err := client.Execute(context.Background(), &pb.ExecuteRequest{Language: "python", SourceCode: "print(1)"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Execute() code = %v, want Unavailable", status.Code(err))
+	}
+	_, err = client.GetBenchmark(context.Background(), &pb.GetBenchmarkRequest{Id: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("GetBenchmark() code = %v, want NotFound", status.Code(err))
+	}
+}
+
+func TestExecuteStreamSendsOutputBeforeResult(t *testing.T) {
+	executor := benchmark.CodeExecutionAdapter{
+		StreamRunner: func(ctx context.Context, req api.ExecutionRequest, cfg config.Config, sink sandbox.OutputFunc) (api.ExecutionResponse, error) {
+			sink(sandbox.StreamStdout, []byte("a"))
+			sink(sandbox.StreamStderr, []byte("b"))
+			return api.ExecutionResponse{Stdout: "a", Stderr: "b"}, nil
+		},
+	}
+	client := dial(t, &Server{Config: testConfig(), Executor: executor})
+
+	events, err := client.ExecuteStream(context.Background(), &pb.ExecuteRequest{Language: "python", SourceCode: "print(1)"})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var got []string
+	for {
+		event, err := events.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		switch e := event.GetEvent().(type) {
+		case *pb.ExecuteEvent_Output:
+			got = append(got, e.Output.GetStream()+":"+string(e.Output.GetData()))
+		case *pb.ExecuteEvent_Result:
+			got = append(got, "result:"+e.Result.GetStdout()+e.Result.GetStderr())
+		}
+	}
+	if strings.Join(got, " ") != "stdout:a stderr:b result:ab" {
+		t.Fatalf("events = %v, want output chunks then result", got)
+	}
+}
+
+func TestExecuteBatchKeepsOrderAndReportsFailuresPerRequest(t *testing.T) {
+	client := dial(t, &Server{Config: testConfig(), Executor: &fakeExecutor{}})
+
+	resp, err := client.ExecuteBatch(context.Background(), &pb.ExecuteBatchRequest{Requests: []*pb.ExecuteRequest{
+		{Language: "python", SourceCode: "first"},
+		{Language: "python"},
+		{Language: "python", SourceCode: "killed"},
+		{Language: "python", SourceCode: "last"},
+	}})
+	if err != nil {
+		t.Fatalf("ExecuteBatch() error = %v", err)
+	}
+
+	results := resp.GetResults()
+	if len(results) != 4 {
+		t.Fatalf("results = %d, want 4", len(results))
+	}
+	if results[0].GetResponse().GetStdout() != "first" || results[3].GetResponse().GetStdout() != "last" {
+		t.Fatalf("results = %v, want responses in request order", results)
+	}
+	if results[1].GetErrorCode() != api.ErrorCodeValidation {
+		t.Fatalf("invalid request error code = %q, want %q", results[1].GetErrorCode(), api.ErrorCodeValidation)
+	}
+	if results[2].GetErrorCode() != api.ErrorCodeExecutionKilled {
+		t.Fatalf("killed request error code = %q, want %q", results[2].GetErrorCode(), api.ErrorCodeExecutionKilled)
+	}
+}
+
+func TestExecuteBatchIsChargedPerRequest(t *testing.T) {
+	keys, err := auth.NewKeyring([]auth.Key{
+		{ID: "runner", Hash: auth.HashKey("run-secret"), Scopes: []string{auth.ScopeExecute}, Quotas: auth.Quotas{ConcurrentExecutions: 2}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	client := dial(t, &Server{Config: testConfig(), Executor: &fakeExecutor{}, Keys: keys})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer run-secret")
+	item := &pb.ExecuteRequest{Language: "python", SourceCode: "print(1)"}
+
+	_, err = client.ExecuteBatch(ctx, &pb.ExecuteBatchRequest{Requests: []*pb.ExecuteRequest{item, item, item}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("batch over the concurrency quota: code = %v, want ResourceExhausted", status.Code(err))
+	}
+	if _, err := client.ExecuteBatch(ctx, &pb.ExecuteBatchRequest{Requests: []*pb.ExecuteRequest{item, item}}); err != nil {
+		t.Fatalf("ExecuteBatch() error = %v", err)
+	}
+	if usage, _ := keys.Usage("runner"); usage.Requests != 5 || usage.ConcurrentExecutions != 0 {
+		t.Fatalf("usage = %+v, want every item counted and every slot released", usage)
+	}
+}
+
+func TestCallsShareTheHTTPRateLimit(t *testing.T) {
+	limiter := middleware.NewRateLimiter(middleware.Options{Rate: rate.Every(time.Minute), Burst: 1})
+	client := dial(t, &Server{Config: testConfig(), Executor: &fakeExecutor{}, RateLimits: map[string]*middleware.RateLimiter{"/execute": limiter}})
+	req := &pb.ExecuteRequest{Language: "python", SourceCode: "print(1)"}
+
+	if _, err := client.Execute(context.Background(), req); err != nil {
+		t.Fatalf("first Execute() error = %v", err)
+	}
+	var header metadata.MD
+	_, err := client.Execute(context.Background(), req, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second Execute() code = %v, want ResourceExhausted", status.Code(err))
+	}
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] != "60" {
+		t.Fatalf("retry-after = %v, want 60", got)
+	}
+}
+
+func TestExecuteBatchTakesARateLimitTokenPerRequest(t *testing.T) {
+	limiter := middleware.NewRateLimiter(middleware.Options{Rate: rate.Every(time.Minute), Burst: 3})
+	client := dial(t, &Server{Config: testConfig(), Executor: &fakeExecutor{}, RateLimits: map[string]*middleware.RateLimiter{"/execute": limiter}})
+	item := &pb.ExecuteRequest{Language: "python", SourceCode: "print(1)"}
+
+	if _, err := client.ExecuteBatch(context.Background(), &pb.ExecuteBatchRequest{Requests: []*pb.ExecuteRequest{item, item}}); err != nil {
+		t.Fatalf("first ExecuteBatch() error = %v", err)
+	}
+	_, err := client.ExecuteBatch(context.Background(), &pb.ExecuteBatchRequest{Requests: []*pb.ExecuteRequest{item, item}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second ExecuteBatch() code = %v, want ResourceExhausted with one token left", status.Code(err))
+	}
+	if _, err := client.Execute(context.Background(), item); err != nil {
+		t.Fatalf("Execute() with the last token error = %v", err)
+	}
+}
+
+func TestRateLimitAppliesBeforeAuthentication(t *testing.T) {
+	keys, err := auth.NewKeyring([]auth.Key{
+		{ID: "runner", Hash: auth.HashKey("run-secret"), Scopes: []string{auth.ScopeExecute}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	limiter := middleware.NewRateLimiter(middleware.Options{Rate: rate.Every(time.Minute), Burst: 1})
+	client := dial(t, &Server{Config: testConfig(), Executor: &fakeExecutor{}, Keys: keys, RateLimits: map[string]*middleware.RateLimiter{"/execute": limiter}})
+	req := &pb.ExecuteRequest{Language: "python", SourceCode: "print(1)"}
+	as := func(secret string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+secret)
+	}
+
+	if _, err := client.Execute(as("guess-1"), req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("first unknown key code = %v, want Unauthenticated", status.Code(err))
+	}
+	if _, err := client.Execute(as("guess-2"), req); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second unknown key code = %v, want ResourceExhausted from the address's bucket", status.Code(err))
+	}
+	if _, err := client.Execute(as("run-secret"), req); err != nil {
+		t.Fatalf("Execute() with a known key error = %v, want its own bucket", err)
+	}
+}
+
+func TestBenchmarkRunCanBeStartedPolledAndCancelled(t *testing.T) {
+	release := make(chan struct{})
+	runs := benchmark.NewRunManager(runFunc(func(ctx context.Context, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+		select {
+		case <-release:
+			return benchmark.BenchmarkReport{TotalTasks: 2}, nil
+		case <-ctx.Done():
+			return benchmark.BenchmarkReport{}, ctx.Err()
+		}
+	}))
+	client := dial(t, &Server{Config: testConfig(), Runs: runs})
+	ctx := context.Background()
+
+	cancelled, err := client.StartBenchmark(ctx, &pb.StartBenchmarkRequest{})
+	if err != nil {
+		t.Fatalf("StartBenchmark() error = %v", err)
+	}
+	if cancelled.GetState() != benchmark.RunStateRunning || cancelled.GetStartedAt() == nil {
+		t.Fatalf("started run = %v, want running with start time", cancelled)
+	}
+	final, err := client.CancelBenchmark(ctx, &pb.CancelBenchmarkRequest{Id: cancelled.GetId()})
+	if err != nil {
+		t.Fatalf("CancelBenchmark() error = %v", err)
+	}
+	if final.GetState() != benchmark.RunStateCancelled || final.GetFinishedAt() == nil {
+		t.Fatalf("cancelled run = %v, want cancelled with finish time", final)
+	}
+
+	started, err := client.StartBenchmark(ctx, &pb.StartBenchmarkRequest{})
+	if err != nil {
+		t.Fatalf("StartBenchmark() error = %v", err)
+	}
+	close(release)
+	if _, err := runs.Wait(ctx, started.GetId()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	finished, err := client.GetBenchmark(ctx, &pb.GetBenchmarkRequest{Id: started.GetId()})
+	if err != nil {
+		t.Fatalf("GetBenchmark() error = %v", err)
+	}
+	var report benchmark.BenchmarkReport
+	if err := json.Unmarshal(finished.GetReportJson(), &report); err != nil || report.TotalTasks != 2 {
+		t.Fatalf("report_json = %s (%v), want the run's report", finished.GetReportJson(), err)
+	}
+}
+
+func TestBenchmarkRunsAreLimitedAndSeenOnlyByTheirOwner(t *testing.T) {
+	keys, err := auth.NewKeyring([]auth.Key{
+		{ID: "alice", Hash: auth.HashKey("alice-secret"), Scopes: []string{auth.ScopeBenchmark}},
+		{ID: "bob", Hash: auth.HashKey("bob-secret"), Scopes: []string{auth.ScopeBenchmark}},
+		{ID: "ops", Hash: auth.HashKey("ops-secret"), Scopes: []string{auth.ScopeAdmin}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	runs := benchmark.NewRunManager(runFunc(func(ctx context.Context, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+		<-ctx.Done()
+		return benchmark.BenchmarkReport{}, ctx.Err()
+	}))
+	runs.MaxActive = 1
+	client := dial(t, &Server{Config: testConfig(), Keys: keys, Runs: runs})
+	as := func(secret string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+secret)
+	}
+
+	started, err := client.StartBenchmark(as("alice-secret"), &pb.StartBenchmarkRequest{})
+	if err != nil {
+		t.Fatalf("StartBenchmark() error = %v", err)
+	}
+	if _, err := client.StartBenchmark(as("bob-secret"), &pb.StartBenchmarkRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("StartBenchmark() over the limit code = %v, want ResourceExhausted", status.Code(err))
+	}
+	if _, err := client.GetBenchmark(as("bob-secret"), &pb.GetBenchmarkRequest{Id: started.GetId()}); status.Code(err) != codes.NotFound {
+		t.Fatalf("GetBenchmark() by another key code = %v, want NotFound", status.Code(err))
+	}
+	if _, err := client.CancelBenchmark(as("bob-secret"), &pb.CancelBenchmarkRequest{Id: started.GetId()}); status.Code(err) != codes.NotFound {
+		t.Fatalf("CancelBenchmark() by another key code = %v, want NotFound", status.Code(err))
+	}
+	if _, err := client.GetBenchmark(as("ops-secret"), &pb.GetBenchmarkRequest{Id: started.GetId()}); err != nil {
+		t.Fatalf("GetBenchmark() by an admin key error = %v", err)
+	}
+	if run, err := client.CancelBenchmark(as("alice-secret"), &pb.CancelBenchmarkRequest{Id: started.GetId()}); err != nil || run.GetState() != benchmark.RunStateCancelled {
+		t.Fatalf("CancelBenchmark() by the owner = %v, %v; want cancelled", run, err)
+	}
+}
+
+func TestStartBenchmarkValidatesSelection(t *testing.T) {
+	client := dial(t, &Server{Config: testConfig(), Runs: benchmark.NewRunManager(nil)})
+
+	_, err := client.StartBenchmark(context.Background(), &pb.StartBenchmarkRequest{
+		Scaffolds: &pb.Selector{Exclude: []string{"critic-["}},
+	})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "scaffolds.exclude[0]") {
+		t.Fatalf("StartBenchmark() error = %v, want InvalidArgument naming scaffolds.exclude[0]", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package health runs dependency checks for the readiness endpoint and caches
+// their results so frequent probes do not hammer Docker or model providers.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gexec-sandbox/internal/api"
+)
+
+const defaultCheckTimeout = 5 * time.Second
+
+// Check is one dependency probe. A failing critical check makes the service
+// unavailable; a failing optional check only degrades it. Results are reused
+// for TTL.
+type Check struct {
+	Name     string
+	Critical bool
+	TTL      time.Duration
+	Run      func(context.Context) error
+}
+
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	results map[string]api.DependencyStatus
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{
+		checks:  checks,
+		timeout: defaultCheckTimeout,
+		now:     time.Now,
+		results: map[string]api.DependencyStatus{},
+	}
+}
+
+// Check runs every check whose cached result has expired, concurrently, and
+// reports all results sorted by name. A nil Checker is always ready.
+func (c *Checker) Check(ctx context.Context) api.ReadinessResponse {
+	if c == nil {
+		return api.ReadinessResponse{Status: api.ReadinessReady, Checks: []api.DependencyStatus{}}
+	}
+
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		if c.fresh(check) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.run(ctx, check)
+			if ctx.Err() == nil {
+				// A probe that hung up mid-check says nothing about the dependency.
+				c.store(result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	report := api.ReadinessResponse{Status: api.ReadinessReady, Checks: make([]api.DependencyStatus, 0, len(c.checks))}
+	for _, check := range c.checks {
+		result, ok := c.results[check.Name]
+		if !ok {
+			result = api.DependencyStatus{Name: check.Name, Status: api.DependencyFailed, Critical: check.Critical, Error: "not checked yet"}
+		}
+		report.Checks = append(report.Checks, result)
+		if result.Status == api.DependencyOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = api.ReadinessUnavailable
+		} else if report.Status == api.ReadinessReady {
+			report.Status = api.ReadinessDegraded
+		}
+	}
+	sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+	return report
+}
+
+func (c *Checker) fresh(check Check) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[check.Name]
+	return ok && c.now().Sub(result.CheckedAt) < check.TTL
+}
+
+func (c *Checker) store(result api.DependencyStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[result.Name] = result
+}
+
+func (c *Checker) run(ctx context.Context, check Check) api.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	started := c.now()
+	err := check.Run(ctx)
+	result := api.DependencyStatus{
+		Name:       check.Name,
+		Status:     api.DependencyOK,
+		Critical:   check.Critical,
+		CheckedAt:  c.now(),
+		DurationMS: c.now().Sub(started).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = api.DependencyFailed
+		result.Error = err.Error()
+	}
+	return result
+}
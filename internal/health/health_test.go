@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gexec-sandbox/internal/api"
+)
+
+func TestCheckerAggregatesCriticalAndOptionalFailures(t *testing.T) {
+	dockerErr := error(nil)
+	modelErr := errors.New("connection refused")
+	checker := NewChecker(
+		Check{Name: "docker", Critical: true, Run: func(context.Context) error { return dockerErr }},
+		Check{Name: "model:alpha", Run: func(context.Context) error { return modelErr }},
+	)
+
+	report := checker.Check(context.Background())
+	if report.Status != api.ReadinessDegraded {
+		t.Fatalf("Status = %q, want degraded when only a model fails", report.Status)
+	}
+	if report.Checks[0].Name != "docker" || report.Checks[1].Name != "model:alpha" {
+		t.Fatalf("Checks = %+v, want sorted by name", report.Checks)
+	}
+	if report.Checks[1].Status != api.DependencyFailed || report.Checks[1].Error != "connection refused" {
+		t.Fatalf("model check = %+v, want failure with error", report.Checks[1])
+	}
+
+	dockerErr = errors.New("daemon down")
+	report = checker.Check(context.Background())
+	if report.Status != api.ReadinessUnavailable {
+		t.Fatalf("Status = %q, want unavailable when docker fails", report.Status)
+	}
+
+	dockerErr, modelErr = nil, nil
+	if report := checker.Check(context.Background()); report.Status != api.ReadinessReady {
+		t.Fatalf("Status = %q, want ready once checks pass", report.Status)
+	}
+}
+
+func TestCheckerCachesResultsForTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	checker := NewChecker(Check{Name: "model:alpha", TTL: 30 * time.Second, Run: func(context.Context) error {
+		calls++
+		return nil
+	}})
+	checker.now = func() time.Time { return now }
+
+	checker.Check(context.Background())
+	now = now.Add(10 * time.Second)
+	checker.Check(context.Background())
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 within the TTL", calls)
+	}
+
+	now = now.Add(30 * time.Second)
+	checker.Check(context.Background())
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2 after the TTL", calls)
+	}
+}
+
+func TestCheckerBoundsSlowChecks(t *testing.T) {
+	checker := NewChecker(Check{Name: "model:slow", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	checker.timeout = 10 * time.Millisecond
+
+	report := checker.Check(context.Background())
+	if report.Checks[0].Status != api.DependencyFailed {
+		t.Fatalf("slow check = %+v, want failed after timeout", report.Checks[0])
+	}
+}
@@ -0,0 +1,86 @@
+package httpapi
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/middleware"
+	"gexec-sandbox/internal/sandbox"
+)
+
+// AdminExecutionsHandler lists running executions with their containers'
+// resource usage.
+type AdminExecutionsHandler struct {
+	Drain *middleware.Drain
+}
+
+func (h AdminExecutionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.ExecutionList{
+		Draining:   h.Drain.Draining(),
+		Executions: sandbox.ListExecutions(r.Context()),
+	})
+}
+
+// AdminKillHandler kills the queued or running execution named by the {id}
+// path segment.
+func AdminKillHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeMethodNotAllowed(w, http.MethodDelete)
+		return
+	}
+	killed, err := sandbox.KillExecution(r.PathValue("id"))
+	if errors.Is(err, sandbox.ErrExecutionNotFound) {
+		WriteError(w, http.StatusNotFound, api.ErrorCodeNotFound, err.Error())
+		return
+	}
+	slog.WarnContext(r.Context(), "execution killed", "execution_id", killed.ID, "language", killed.Language, "requester", killed.Requester)
+	writeJSON(w, http.StatusOK, killed)
+}
+
+// AdminQueueHandler lists the executions waiting for an execution slot.
+type AdminQueueHandler struct {
+	Config config.Config
+}
+
+func (h AdminQueueHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	running, _ := sandbox.ExecutionCounts()
+	writeJSON(w, http.StatusOK, api.QueueStatus{
+		Limit:  h.Config.MaxConcurrentExecutions,
+		Active: running,
+		Queued: sandbox.QueuedExecutions(),
+	})
+}
+
+// AdminDrainHandler reports the drain state on GET, starts draining on POST
+// and resumes accepting work on DELETE.
+type AdminDrainHandler struct {
+	Drain *middleware.Drain
+}
+
+func (h AdminDrainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		h.Drain.Start()
+		slog.WarnContext(r.Context(), "server draining")
+	case http.MethodDelete:
+		h.Drain.Stop()
+		slog.InfoContext(r.Context(), "server accepting work")
+	default:
+		writeMethodNotAllowed(w, "GET, POST, DELETE")
+		return
+	}
+	running, queued := sandbox.ExecutionCounts()
+	writeJSON(w, http.StatusOK, api.DrainStatus{Draining: h.Drain.Draining(), Active: running, Queued: queued})
+}
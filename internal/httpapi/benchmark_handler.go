@@ -1,28 +1,228 @@
 package httpapi
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
 	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/validation"
 )
 
+// BenchmarkRunHandler starts a benchmark run in the background and answers
+// 202 with its status; the Location header points at the run. An optional
+// benchmark.Selection body narrows the run.
 type BenchmarkRunHandler struct {
-	Service benchmark.BenchmarkServiceAPI
+	Runs *benchmark.RunManager
 }
 
 func (h BenchmarkRunHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodPost)
 		return
 	}
 
-	report, err := h.Service.Run(r.Context())
+	var selection benchmark.Selection
+	if !decodeOptionalJSON(w, r, &selection) {
+		return
+	}
+	if err := validation.BenchmarkSelection(selection); err != nil {
+		var invalid *validation.Error
+		errors.As(err, &invalid)
+		writeValidationError(w, invalid)
+		return
+	}
+
+	status, err := h.Runs.Start(r.Context(), auth.KeyID(r.Context()), benchmark.RunOptions{Selection: selection})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeRunError(w, err)
+		return
+	}
+	w.Header().Set("Location", APIVersionPrefix+"/benchmark/runs/"+status.ID)
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// BenchmarkRunStatusHandler reports the run named by the {id} path segment on
+// GET and cancels it on DELETE, answering with its final status. Only the key
+// that started a run, or an admin key, sees it.
+type BenchmarkRunStatusHandler struct {
+	Runs *benchmark.RunManager
+}
+
+func (h BenchmarkRunStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet || r.Method == http.MethodDelete {
+		if !ownsRun(w, r, h.Runs) {
+			return
+		}
+	}
+	var status benchmark.RunStatus
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		status, err = h.Runs.Get(r.PathValue("id"))
+	case http.MethodDelete:
+		status, err = h.Runs.Cancel(r.Context(), r.PathValue("id"))
+	default:
+		writeMethodNotAllowed(w, "GET, DELETE")
+		return
+	}
+
+	switch {
+	case errors.Is(err, benchmark.ErrRunNotFound):
+		WriteError(w, http.StatusNotFound, api.ErrorCodeNotFound, err.Error())
+	case err != nil:
+		// The client went away while waiting for the cancelled run to stop.
+		return
+	default:
+		writeJSON(w, http.StatusOK, status)
+	}
+}
+
+// BenchmarkRunRescoreHandler starts grading the logged runs of the run named
+// by the {id} path segment again and answers 202 with the status of the new
+// run that will hold the report, like BenchmarkRunHandler. An optional
+// benchmark.RescoreRequest body names the grader.
+type BenchmarkRunRescoreHandler struct {
+	Runs *benchmark.RunManager
+}
+
+func (h BenchmarkRunRescoreHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	var req benchmark.RescoreRequest
+	if !decodeOptionalJSON(w, r, &req) || !ownsRun(w, r, h.Runs) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(report)
+	status, err := h.Runs.StartRescore(r.Context(), r.PathValue("id"), req.Grader, auth.KeyID(r.Context()))
+	if err != nil {
+		writeRunError(w, err)
+		return
+	}
+	w.Header().Set("Location", APIVersionPrefix+"/benchmark/runs/"+status.ID)
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// ownsRun answers 404 when the run named by the {id} path segment was started
+// by another key, as if it did not exist, unless the request's key is an
+// admin key. It reports whether the handler should go on.
+func ownsRun(w http.ResponseWriter, r *http.Request, runs *benchmark.RunManager) bool {
+	id := r.PathValue("id")
+	if owner, err := runs.Owner(id); err == nil && !auth.Owns(r.Context(), owner) {
+		WriteError(w, http.StatusNotFound, api.ErrorCodeNotFound, fmt.Errorf("%w: %s", benchmark.ErrRunNotFound, id).Error())
+		return false
+	}
+	return true
+}
+
+// writeRunError answers for a run that could not be started. Failures that
+// are not the client's, such as a run directory that cannot be written, are
+// server errors.
+func writeRunError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, benchmark.ErrRunNotFound):
+		WriteError(w, http.StatusNotFound, api.ErrorCodeNotFound, err.Error())
+	case errors.Is(err, benchmark.ErrRunNotSucceeded):
+		WriteError(w, http.StatusConflict, api.ErrorCodeRunNotSucceeded, err.Error())
+	case errors.Is(err, benchmark.ErrNotRescorable):
+		WriteError(w, http.StatusConflict, api.ErrorCodeNotRescorable, err.Error())
+	case errors.Is(err, benchmark.ErrUnknownGrader):
+		WriteError(w, http.StatusBadRequest, api.ErrorCodeInvalidRequest, err.Error())
+	case errors.Is(err, benchmark.ErrTooManyRuns):
+		WriteError(w, http.StatusTooManyRequests, api.ErrorCodeTooManyRuns, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		WriteError(w, http.StatusServiceUnavailable, api.ErrorCodeUnavailable, err.Error())
+	default:
+		WriteError(w, http.StatusInternalServerError, api.ErrorCodeInternal, err.Error())
+	}
+}
+
+// sseKeepAlive is how often an idle event stream sends a comment so proxies
+// do not close the connection.
+const sseKeepAlive = 15 * time.Second
+
+// sseWriteTimeout bounds each write to an event stream. It replaces the
+// server's write timeout, which would otherwise end the stream of any run
+// that outlasts it.
+const sseWriteTimeout = 2 * sseKeepAlive
+
+// BenchmarkRunEventsHandler streams a run's progress as server-sent events:
+// a "progress" event for each finished run, whose ID is the completed count,
+// then a "done" event with the final status. A Last-Event-ID header resumes
+// after that many runs.
+type BenchmarkRunEventsHandler struct {
+	Runs *benchmark.RunManager
+}
+
+func (h BenchmarkRunEventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	if !ownsRun(w, r, h.Runs) {
+		return
+	}
+	id := r.PathValue("id")
+	sent, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	sent = max(sent, 0)
+	status, runs, changed, err := h.Runs.Watch(id, sent)
+	if err != nil {
+		WriteError(w, http.StatusNotFound, api.ErrorCodeNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller := http.NewResponseController(w)
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		// Recorders and other writers without deadlines are fine unbounded.
+		_ = controller.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+		for _, run := range runs {
+			sent++
+			writeEvent(w, "progress", strconv.Itoa(sent), benchmark.RunProgress{Completed: sent, Total: status.Total, Run: run})
+		}
+		if status.State != benchmark.RunStateRunning {
+			writeEvent(w, "done", "", status)
+			controller.Flush()
+			return
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			runs = nil
+			continue
+		case <-r.Context().Done():
+			return
+		}
+		if status, runs, changed, err = h.Runs.Watch(id, sent); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, id string, data any) {
+	raw, _ := json.Marshal(data)
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, raw)
 }
@@ -1,37 +1,300 @@
 package httpapi
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"gexec-sandbox/internal/auth"
 	"gexec-sandbox/internal/benchmark"
 )
 
-func TestBenchmarkRunHandlerReturnsJSON(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/benchmark/run", strings.NewReader(`{}`))
-	rr := httptest.NewRecorder()
+// stepBenchmarkService reports and logs each of runs once a value arrives on
+// step, then returns a report.
+type stepBenchmarkService struct {
+	runs []benchmark.Run
+	step chan struct{}
+}
+
+func (s stepBenchmarkService) Run(ctx context.Context, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+	observer := opts.RunObserver()
+	observer.BenchmarkStarted(len(s.runs))
+	for _, run := range s.runs {
+		select {
+		case <-s.step:
+		case <-ctx.Done():
+			return benchmark.BenchmarkReport{}, ctx.Err()
+		}
+		if opts.Log != nil {
+			opts.Log.Append(run)
+		}
+		observer.RunFinished(run)
+	}
+	return benchmark.BenchmarkReport{TotalTasks: 1, Runs: s.runs}, nil
+}
 
-	handler := BenchmarkRunHandler{
-		Service: fakeBenchmarkService{report: benchmark.BenchmarkReport{TotalTasks: 1}},
+// Rescore reports the runs as graded by grader, which must be "default" or
+// empty.
+func (s stepBenchmarkService) Rescore(ctx context.Context, runs []benchmark.Run, grader string, opts benchmark.RunOptions) (benchmark.BenchmarkReport, error) {
+	if grader != "" && grader != benchmark.GraderNameDefault {
+		return benchmark.BenchmarkReport{}, benchmark.ErrUnknownGrader
+	}
+	return benchmark.BenchmarkReport{TotalTasks: 1, Grader: benchmark.DefaultGraderVersion, Runs: runs}, nil
+}
+
+func newStepService(taskIDs ...string) stepBenchmarkService {
+	service := stepBenchmarkService{step: make(chan struct{})}
+	for _, id := range taskIDs {
+		service.runs = append(service.runs, benchmark.Run{TaskID: id})
+	}
+	return service
+}
+
+func serveBenchmark(t *testing.T, handler http.Handler, method string, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, nil)
+	if _, id, ok := strings.Cut(target, "/runs/"); ok {
+		req.SetPathValue("id", strings.TrimSuffix(strings.TrimSuffix(id, "/events"), "/rescore"))
 	}
 	handler.ServeHTTP(rr, req)
+	return rr
+}
 
-	if rr.Code != http.StatusOK {
-		t.Fatalf("status = %d, want 200", rr.Code)
+func TestBenchmarkRunHandlerStartsRunInBackground(t *testing.T) {
+	service := newStepService("a")
+	runs := benchmark.NewRunManager(service)
+
+	rr := serveBenchmark(t, BenchmarkRunHandler{Runs: runs}, http.MethodPost, "/v1/benchmark/run")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", rr.Code)
+	}
+	var started benchmark.RunStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &started); err != nil || started.ID == "" || started.State != benchmark.RunStateRunning {
+		t.Fatalf("body = %s (%v), want running status with ID", rr.Body.String(), err)
+	}
+	if got := rr.Header().Get("Location"); got != "/v1/benchmark/runs/"+started.ID {
+		t.Fatalf("Location = %q, want run URL", got)
 	}
 
-	if !strings.Contains(rr.Body.String(), "\"total_tasks\":1") {
-		t.Fatalf("body = %s, want benchmark report JSON", rr.Body.String())
+	service.step <- struct{}{}
+	if _, err := runs.Wait(context.Background(), started.ID); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	rr = serveBenchmark(t, BenchmarkRunStatusHandler{Runs: runs}, http.MethodGet, "/v1/benchmark/runs/"+started.ID)
+	var status benchmark.RunStatus
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	if rr.Code != http.StatusOK || status.State != benchmark.RunStateSucceeded || status.Completed != 1 || status.Total != 1 || status.Report == nil {
+		t.Fatalf("GET = %d %s, want succeeded run with report", rr.Code, rr.Body.String())
 	}
 }
 
-type fakeBenchmarkService struct {
-	report benchmark.BenchmarkReport
+func TestBenchmarkRunStatusHandlerCancelsAndReportsMissingRuns(t *testing.T) {
+	runs := benchmark.NewRunManager(newStepService("a", "b"))
+	started, _ := runs.Start(context.Background(), "", benchmark.RunOptions{})
+
+	rr := serveBenchmark(t, BenchmarkRunStatusHandler{Runs: runs}, http.MethodDelete, "/v1/benchmark/runs/"+started.ID)
+	var status benchmark.RunStatus
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	if rr.Code != http.StatusOK || status.State != benchmark.RunStateCancelled {
+		t.Fatalf("DELETE = %d %s, want cancelled status", rr.Code, rr.Body.String())
+	}
+
+	rr = serveBenchmark(t, BenchmarkRunStatusHandler{Runs: runs}, http.MethodGet, "/v1/benchmark/runs/missing")
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), `"code":"not_found"`) {
+		t.Fatalf("GET missing = %d %s, want not_found envelope", rr.Code, rr.Body.String())
+	}
+}
+
+func TestBenchmarkRunEventsHandlerStreamsProgressThenDone(t *testing.T) {
+	service := newStepService("a", "b", "c")
+	runs := benchmark.NewRunManager(service)
+	started, _ := runs.Start(context.Background(), "", benchmark.RunOptions{})
+	service.step <- struct{}{}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.SetPathValue("id", started.ID)
+		BenchmarkRunEventsHandler{Runs: runs}.ServeHTTP(w, r)
+	}))
+	// The stream outlives the server's write timeout.
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET events error = %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	// The first run finished before the client connected and was already
+	// seen, so the stream resumes at the second.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		service.step <- struct{}{}
+		service.step <- struct{}{}
+	}()
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, name)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok && events[len(events)-1] == "progress" {
+			var progress benchmark.RunProgress
+			json.Unmarshal([]byte(data), &progress)
+			events[len(events)-1] += ":" + progress.Run.TaskID
+		}
+	}
+	if strings.Join(events, " ") != "progress:b progress:c done" {
+		t.Fatalf("events = %v, want progress for b and c then done", events)
+	}
 }
 
-func (f fakeBenchmarkService) Run(ctx context.Context) (benchmark.BenchmarkReport, error) {
-	return f.report, nil
+func TestBenchmarkRunHandlerValidatesSelection(t *testing.T) {
+	runs := benchmark.NewRunManager(newStepService("a"))
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/benchmark/run", strings.NewReader(`{"tasks":{"include":["csv-["]},"limit":-1}`))
+	BenchmarkRunHandler{Runs: runs}.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), `"field":"tasks.include[0]"`) || !strings.Contains(rr.Body.String(), `"field":"limit"`) {
+		t.Fatalf("POST = %d %s, want 422 naming tasks.include[0] and limit", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/v1/benchmark/run", strings.NewReader(`{"tasks":`))
+	BenchmarkRunHandler{Runs: runs}.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("POST truncated body = %d, want 400", rr.Code)
+	}
+}
+
+func TestBenchmarkRunRescoreHandlerRegradesLoggedRunsInTheBackground(t *testing.T) {
+	service := newStepService("a")
+	runs := benchmark.NewRunManager(service)
+	handler := BenchmarkRunRescoreHandler{Runs: runs}
+	rr := serveBenchmark(t, handler, http.MethodPost, "/v1/benchmark/runs/unlogged/rescore")
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), `"code":"run_not_rescorable"`) {
+		t.Fatalf("rescore without a runs directory = %d %s, want 409 run_not_rescorable", rr.Code, rr.Body.String())
+	}
+
+	runs.RunsDir = t.TempDir()
+	started, err := runs.Start(context.Background(), "", benchmark.RunOptions{})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	rr = serveBenchmark(t, handler, http.MethodPost, "/v1/benchmark/runs/"+started.ID+"/rescore")
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), `"code":"run_not_succeeded"`) {
+		t.Fatalf("rescore running = %d %s, want 409 run_not_succeeded", rr.Code, rr.Body.String())
+	}
+
+	service.step <- struct{}{}
+	if _, err := runs.Wait(context.Background(), started.ID); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	rr = serveBenchmark(t, handler, http.MethodPost, "/v1/benchmark/runs/"+started.ID+"/rescore")
+	var rescoring benchmark.RunStatus
+	json.Unmarshal(rr.Body.Bytes(), &rescoring)
+	if rr.Code != http.StatusAccepted || rr.Header().Get("Location") != "/v1/benchmark/runs/"+rescoring.ID {
+		t.Fatalf("rescore = %d %s, want 202 pointing at the new run", rr.Code, rr.Body.String())
+	}
+	status, err := runs.Wait(context.Background(), rescoring.ID)
+	if err != nil || status.Report == nil || status.Report.RescoredFrom != started.ID || status.Report.Grader != benchmark.DefaultGraderVersion || len(status.Report.Runs) != 1 {
+		t.Fatalf("rescore run = %+v, %v; want the logged runs rescored", status, err)
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/benchmark/runs/"+started.ID+"/rescore", strings.NewReader(`{"grader":"strict"}`))
+	req.SetPathValue("id", started.ID)
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"code":"invalid_request"`) {
+		t.Fatalf("rescore with unknown grader = %d %s, want 400 invalid_request", rr.Code, rr.Body.String())
+	}
+
+	rr = serveBenchmark(t, handler, http.MethodPost, "/v1/benchmark/runs/missing/rescore")
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("rescore missing = %d, want 404", rr.Code)
+	}
+}
+
+func TestBenchmarkRunHandlerReportsRunDirectoryFailuresAsServerErrors(t *testing.T) {
+	runs := benchmark.NewRunManager(newStepService("a"))
+	// A file where the runs directory belongs cannot hold run directories.
+	runs.RunsDir = filepath.Join(t.TempDir(), "runs")
+	os.WriteFile(runs.RunsDir, nil, 0o600)
+
+	rr := serveBenchmark(t, BenchmarkRunHandler{Runs: runs}, http.MethodPost, "/v1/benchmark/run")
+	if rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), `"code":"internal_error"`) {
+		t.Fatalf("POST = %d %s, want 500 internal_error", rr.Code, rr.Body.String())
+	}
+}
+
+func TestBenchmarkRunsAreLimitedAndSeenOnlyByTheirOwner(t *testing.T) {
+	keys, err := auth.NewKeyring([]auth.Key{
+		{ID: "alice", Hash: auth.HashKey("alice-secret"), Scopes: []string{auth.ScopeBenchmark}},
+		{ID: "bob", Hash: auth.HashKey("bob-secret"), Scopes: []string{auth.ScopeBenchmark}},
+		{ID: "ops", Hash: auth.HashKey("ops-secret"), Scopes: []string{auth.ScopeAdmin}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	runs := benchmark.NewRunManager(newStepService("a"))
+	runs.MaxActive = 1
+	serveAs := func(secret string, handler http.Handler, method string, target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		if _, id, ok := strings.Cut(target, "/runs/"); ok {
+			req.SetPathValue("id", strings.TrimSuffix(strings.TrimSuffix(id, "/events"), "/rescore"))
+		}
+		req.Header.Set("Authorization", "Bearer "+secret)
+		keys.Require(auth.ScopeBenchmark)(handler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serveAs("alice-secret", BenchmarkRunHandler{Runs: runs}, http.MethodPost, "/v1/benchmark/run")
+	var started benchmark.RunStatus
+	json.Unmarshal(rr.Body.Bytes(), &started)
+	if rr.Code != http.StatusAccepted || started.Owner != "alice" {
+		t.Fatalf("POST = %d %s, want a run owned by alice", rr.Code, rr.Body.String())
+	}
+	rr = serveAs("bob-secret", BenchmarkRunHandler{Runs: runs}, http.MethodPost, "/v1/benchmark/run")
+	if rr.Code != http.StatusTooManyRequests || !strings.Contains(rr.Body.String(), `"code":"too_many_runs"`) {
+		t.Fatalf("POST over the limit = %d %s, want 429 too_many_runs", rr.Code, rr.Body.String())
+	}
+
+	target := "/v1/benchmark/runs/" + started.ID
+	for _, tc := range []struct {
+		handler http.Handler
+		method  string
+		target  string
+	}{
+		{BenchmarkRunStatusHandler{Runs: runs}, http.MethodGet, target},
+		{BenchmarkRunStatusHandler{Runs: runs}, http.MethodDelete, target},
+		{BenchmarkRunEventsHandler{Runs: runs}, http.MethodGet, target + "/events"},
+		{BenchmarkRunRescoreHandler{Runs: runs}, http.MethodPost, target + "/rescore"},
+	} {
+		if rr := serveAs("bob-secret", tc.handler, tc.method, tc.target); rr.Code != http.StatusNotFound {
+			t.Fatalf("%s %s by another key = %d %s, want 404", tc.method, tc.target, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := serveAs("ops-secret", BenchmarkRunStatusHandler{Runs: runs}, http.MethodGet, target); rr.Code != http.StatusOK {
+		t.Fatalf("GET by an admin key = %d, want 200", rr.Code)
+	}
+	if rr := serveAs("alice-secret", BenchmarkRunStatusHandler{Runs: runs}, http.MethodDelete, target); rr.Code != http.StatusOK {
+		t.Fatalf("DELETE by the owner = %d %s, want 200", rr.Code, rr.Body.String())
+	}
 }
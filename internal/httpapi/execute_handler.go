@@ -0,0 +1,67 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/metrics"
+	"gexec-sandbox/internal/sandbox"
+	"gexec-sandbox/internal/validation"
+)
+
+type ExecuteHandler struct {
+	Config   config.Config
+	Executor benchmark.Executor
+}
+
+func (h ExecuteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	metrics.IncrementRequest()
+
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		metrics.IncrementError()
+		return
+	}
+
+	var req api.ExecutionRequest
+	if !decodeJSON(w, r, &req) {
+		metrics.IncrementError()
+		return
+	}
+
+	if err := validation.ExecutionRequest(req, h.Config); err != nil {
+		var invalid *validation.Error
+		errors.As(err, &invalid)
+		writeValidationError(w, invalid)
+		metrics.IncrementError()
+		return
+	}
+
+	if req.TimeoutMS == 0 {
+		req.TimeoutMS = h.Config.DefaultTimeoutMS
+	}
+
+	response, err := h.Executor.Execute(r.Context(), req, h.Config)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "execute failed", "language", req.Language, "error", err)
+		switch {
+		case errors.Is(err, sandbox.ErrInvalidRequest):
+			WriteError(w, http.StatusBadRequest, api.ErrorCodeInvalidRequest, err.Error())
+		case errors.Is(err, sandbox.ErrKilled):
+			WriteError(w, http.StatusConflict, api.ErrorCodeExecutionKilled, err.Error())
+		case errors.Is(err, context.DeadlineExceeded):
+			WriteError(w, http.StatusGatewayTimeout, api.ErrorCodeExecutionTimeout, "execution exceeded timeout_ms")
+		default:
+			WriteError(w, http.StatusInternalServerError, api.ErrorCodeExecutionFailed, err.Error())
+		}
+		metrics.IncrementError()
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,132 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/sandbox"
+)
+
+func TestExecuteHandlerAppliesDefaultTimeoutAndReturnsResponse(t *testing.T) {
+	executor := &fakeExecutor{resp: api.ExecutionResponse{Stdout: "hi\n"}}
+	cfg := testConfig()
+	cfg.DefaultTimeoutMS = 1500
+	handler := ExecuteHandler{Config: cfg, Executor: executor}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/execute", strings.NewReader(`{"language":"python","source_code":"print('hi')"}`)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rr.Code)
+	}
+	if executor.seenReq.TimeoutMS != 1500 {
+		t.Fatalf("TimeoutMS = %d, want config default", executor.seenReq.TimeoutMS)
+	}
+	var resp api.ExecutionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp.Stdout != "hi\n" {
+		t.Fatalf("body = %s (%v), want execution response", rr.Body.String(), err)
+	}
+}
+
+func TestExecuteHandlerReturnsErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "method", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed, wantCode: api.ErrorCodeMethodNotAllowed},
+		{name: "json", method: http.MethodPost, body: `{`, wantStatus: http.StatusBadRequest, wantCode: api.ErrorCodeInvalidJSON},
+		{name: "empty source", method: http.MethodPost, body: `{"language":"python"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: api.ErrorCodeValidation},
+		{name: "invalid request", method: http.MethodPost, body: `{"language":"python","source_code":"print(1)"}`, err: fmt.Errorf("wrapped: %w", sandbox.ErrInvalidRequest), wantStatus: http.StatusBadRequest, wantCode: api.ErrorCodeInvalidRequest},
+		{name: "killed", method: http.MethodPost, body: `{"language":"python","source_code":"print(1)"}`, err: sandbox.ErrKilled, wantStatus: http.StatusConflict, wantCode: api.ErrorCodeExecutionKilled},
+		{name: "timeout", method: http.MethodPost, body: `{"language":"python","source_code":"while True: pass"}`, err: context.DeadlineExceeded, wantStatus: http.StatusGatewayTimeout, wantCode: api.ErrorCodeExecutionTimeout},
+		{name: "docker", method: http.MethodPost, body: `{"language":"python","source_code":"print(1)"}`, err: errors.New("failed to create docker client"), wantStatus: http.StatusInternalServerError, wantCode: api.ErrorCodeExecutionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := ExecuteHandler{Config: testConfig(), Executor: &fakeExecutor{err: tt.err}}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tt.method, "/v1/execute", strings.NewReader(tt.body)))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			var envelope api.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+				t.Fatalf("body = %s, want JSON error envelope: %v", rr.Body.String(), err)
+			}
+			if envelope.Error.Code != tt.wantCode || envelope.Error.Message == "" {
+				t.Fatalf("error = %+v, want code %q with message", envelope.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestExecuteHandlerReportsEveryInvalidField(t *testing.T) {
+	executor := &fakeExecutor{}
+	cfg := testConfig()
+	cfg.MaxStdinBytes = 4
+	handler := ExecuteHandler{Config: cfg, Executor: executor}
+
+	body := `{"language":"ruby","source_code":"","stdin":"too long","timeout_ms":-5,"files":{"../x":"y"}}`
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/execute", strings.NewReader(body)))
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rr.Code)
+	}
+	var envelope api.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	var fields []string
+	for _, field := range envelope.Error.Fields {
+		fields = append(fields, field.Field)
+	}
+	if got, want := strings.Join(fields, ","), "language,source_code,stdin,timeout_ms,files.../x"; got != want {
+		t.Fatalf("fields = %s, want %s", got, want)
+	}
+	if executor.calls != 0 {
+		t.Fatalf("executor ran %d times for an invalid request", executor.calls)
+	}
+}
+
+func TestExecuteHandlerRejectsOversizedBody(t *testing.T) {
+	handler := http.MaxBytesHandler(ExecuteHandler{Config: testConfig(), Executor: &fakeExecutor{}}, 16)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/execute", strings.NewReader(`{"language":"python","source_code":"print(1)"}`)))
+
+	if rr.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rr.Body.String(), api.ErrorCodeTooLarge) {
+		t.Fatalf("response = %d %s, want 413 request_too_large", rr.Code, rr.Body.String())
+	}
+}
+
+func testConfig() config.Config {
+	return config.Config{Languages: map[string]string{"python": "python:3.9-slim"}}
+}
+
+type fakeExecutor struct {
+	resp    api.ExecutionResponse
+	err     error
+	seenReq api.ExecutionRequest
+	calls   int
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	f.seenReq = req
+	f.calls++
+	return f.resp, f.err
+}
@@ -0,0 +1,376 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/auth"
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/metrics"
+)
+
+// APIVersionPrefix is the path prefix of the current HTTP API.
+const APIVersionPrefix = "/v1"
+
+// Operation describes one endpoint in the OpenAPI document. Request and
+// response bodies are Go values whose types are reflected into schemas, so
+// the document cannot drift from the types the handlers encode.
+type Operation struct {
+	Path     string
+	Method   string
+	ID       string
+	Summary  string
+	Request  any
+	Response any
+	// OptionalRequest marks a request body the client may omit.
+	OptionalRequest bool
+	// Status is the success status; zero means 200.
+	Status int
+	// ContentType overrides the JSON response body for endpoints that return
+	// plain text.
+	ContentType string
+	// ResponseStatuses are non-200 statuses that still carry Response
+	// rather than the error envelope.
+	ResponseStatuses []int
+	// Scope is the API key scope the endpoint requires; empty means public.
+	Scope string
+	// StartsWork marks endpoints that are refused while the server drains.
+	StartsWork bool
+	Errors     []int
+}
+
+// Operations lists every /v1 endpoint, relative to APIVersionPrefix.
+var Operations = []Operation{
+	{
+		Path: "/execute", Method: http.MethodPost, ID: "executeCode",
+		Summary: "Run source code in a sandbox container",
+		Request: api.ExecutionRequest{}, Response: api.ExecutionResponse{},
+		Scope: auth.ScopeExecute, StartsWork: true,
+		Errors: []int{
+			http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity,
+			http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+		},
+	},
+	{
+		Path: "/benchmark/run", Method: http.MethodPost, ID: "runBenchmark",
+		Summary:         "Start running the manifest's models, tasks and scaffolds, optionally narrowed by a selection",
+		Request:         benchmark.Selection{},
+		OptionalRequest: true,
+		Response:        benchmark.RunStatus{},
+		Status:          http.StatusAccepted,
+		Scope:           auth.ScopeBenchmark,
+		StartsWork:      true,
+		Errors:          []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	},
+	{
+		Path: "/benchmark/runs/{id}", Method: http.MethodGet, ID: "getBenchmarkRun",
+		Summary:  "Report a benchmark run's progress, partial results and report",
+		Response: benchmark.RunStatus{},
+		Scope:    auth.ScopeBenchmark,
+		Errors:   []int{http.StatusNotFound},
+	},
+	{
+		Path: "/benchmark/runs/{id}", Method: http.MethodDelete, ID: "cancelBenchmarkRun",
+		Summary:  "Cancel a benchmark run and report its final status",
+		Response: benchmark.RunStatus{},
+		Scope:    auth.ScopeBenchmark,
+		Errors:   []int{http.StatusNotFound},
+	},
+	{
+		Path: "/benchmark/runs/{id}/rescore", Method: http.MethodPost, ID: "rescoreBenchmarkRun",
+		Summary:         "Start grading a run's logged runs again, optionally with a named grader, as a new run whose report holds the result",
+		Request:         benchmark.RescoreRequest{},
+		OptionalRequest: true,
+		Response:        benchmark.RunStatus{},
+		Status:          http.StatusAccepted,
+		Scope:           auth.ScopeBenchmark,
+		StartsWork:      true,
+		Errors:          []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	},
+	{
+		Path: "/benchmark/runs/{id}/events", Method: http.MethodGet, ID: "streamBenchmarkRun",
+		Summary:     "Stream a progress event per finished run, then a done event",
+		ContentType: "text/event-stream",
+		Scope:       auth.ScopeBenchmark,
+		Errors:      []int{http.StatusNotFound},
+	},
+	{
+		Path: "/ping", Method: http.MethodGet, ID: "ping",
+		Summary:  "Report that the process is serving",
+		Response: api.StatusResponse{},
+	},
+	{
+		Path: "/healthz", Method: http.MethodGet, ID: "getLiveness",
+		Summary:  "Report that the process is alive",
+		Response: api.StatusResponse{},
+	},
+	{
+		Path: "/readyz", Method: http.MethodGet, ID: "getReadiness",
+		Summary:          "Report Docker, image and model provider status",
+		Response:         api.ReadinessResponse{},
+		ResponseStatuses: []int{http.StatusServiceUnavailable},
+	},
+	{
+		Path: "/metrics", Method: http.MethodGet, ID: "getMetrics",
+		Summary:  "Read request counters",
+		Response: metrics.Metrics{},
+		Scope:    auth.ScopeAdmin,
+	},
+	{
+		Path: "/metrics/prometheus", Method: http.MethodGet, ID: "getPrometheusMetrics",
+		Summary:     "Read metrics in the Prometheus text exposition format",
+		ContentType: metrics.PrometheusContentType,
+		Scope:       auth.ScopeAdmin,
+	},
+	{
+		Path: "/admin/executions", Method: http.MethodGet, ID: "listExecutions",
+		Summary:  "List running executions with their containers' resource usage",
+		Response: api.ExecutionList{},
+		Scope:    auth.ScopeAdmin,
+	},
+	{
+		Path: "/admin/executions/{id}", Method: http.MethodDelete, ID: "killExecution",
+		Summary:  "Kill a queued or running execution",
+		Response: api.ActiveExecution{},
+		Scope:    auth.ScopeAdmin,
+		Errors:   []int{http.StatusNotFound},
+	},
+	{
+		Path: "/admin/queue", Method: http.MethodGet, ID: "getQueue",
+		Summary:  "List executions waiting for an execution slot",
+		Response: api.QueueStatus{},
+		Scope:    auth.ScopeAdmin,
+	},
+	{
+		Path: "/admin/drain", Method: http.MethodGet, ID: "getDrain",
+		Summary:  "Report whether the server is draining",
+		Response: api.DrainStatus{},
+		Scope:    auth.ScopeAdmin,
+	},
+	{
+		Path: "/admin/drain", Method: http.MethodPost, ID: "startDrain",
+		Summary:  "Stop accepting new executions and benchmark runs",
+		Response: api.DrainStatus{},
+		Scope:    auth.ScopeAdmin,
+	},
+	{
+		Path: "/admin/drain", Method: http.MethodDelete, ID: "stopDrain",
+		Summary:  "Resume accepting new work",
+		Response: api.DrainStatus{},
+		Scope:    auth.ScopeAdmin,
+	},
+	{
+		Path: "/openapi.json", Method: http.MethodGet, ID: "getOpenAPI",
+		Summary:  "Read this OpenAPI document",
+		Response: map[string]any{},
+	},
+}
+
+var (
+	openAPIOnce     sync.Once
+	openAPIDocument []byte
+)
+
+// OpenAPIDocument returns the OpenAPI 3.1 document for the /v1 API.
+func OpenAPIDocument() []byte {
+	openAPIOnce.Do(func() {
+		raw, err := json.MarshalIndent(buildOpenAPIDocument(Operations), "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		openAPIDocument = raw
+	})
+	return openAPIDocument
+}
+
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(OpenAPIDocument())
+}
+
+func buildOpenAPIDocument(operations []Operation) map[string]any {
+	schemas := schemaRegistry{schemas: map[string]any{}}
+	errorRef := schemas.schemaFor(reflect.TypeOf(api.ErrorResponse{}))
+
+	paths := map[string]any{}
+	for _, op := range operations {
+		success := op.Status
+		if success == 0 {
+			success = http.StatusOK
+		}
+		ok := map[string]any{"description": http.StatusText(success)}
+		if op.ContentType != "" {
+			ok["content"] = map[string]any{op.ContentType: map[string]any{"schema": map[string]any{"type": "string"}}}
+		} else {
+			ok["content"] = jsonContent(schemas.schemaFor(reflect.TypeOf(op.Response)))
+		}
+		responses := map[string]any{strconv.Itoa(success): ok}
+		for _, status := range op.ResponseStatuses {
+			responses[strconv.Itoa(status)] = map[string]any{
+				"description": http.StatusText(status),
+				"content":     ok["content"],
+			}
+		}
+		statuses := op.Errors
+		if op.Scope != "" {
+			// Authenticated endpoints can also reject the key or its quota.
+			statuses = append([]int{http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests}, statuses...)
+		}
+		for _, status := range statuses {
+			responses[strconv.Itoa(status)] = map[string]any{
+				"description": http.StatusText(status),
+				"content":     jsonContent(errorRef),
+			}
+		}
+
+		operation := map[string]any{
+			"operationId": op.ID,
+			"summary":     op.Summary,
+			"responses":   responses,
+		}
+		if op.Scope != "" {
+			operation["security"] = []any{map[string]any{"bearerAuth": []string{}}, map[string]any{"apiKeyHeader": []string{}}}
+			operation["x-required-scope"] = op.Scope
+		}
+		if params := pathParameters(op.Path); len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": !op.OptionalRequest,
+				"content":  jsonContent(schemas.schemaFor(reflect.TypeOf(op.Request))),
+			}
+		}
+
+		path := APIVersionPrefix + op.Path
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "LocalEval API",
+			"version": strings.TrimPrefix(APIVersionPrefix, "/"),
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth":   map[string]any{"type": "http", "scheme": "bearer"},
+				"apiKeyHeader": map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+}
+
+// pathParameters describes the {name} segments of a path as required strings.
+func pathParameters(path string) []any {
+	var params []any
+	for _, segment := range strings.Split(path, "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		params = append(params, map[string]any{
+			"name":     strings.TrimSuffix(name, "}"),
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	return params
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// schemaRegistry reflects Go types into JSON schemas. Named structs become
+// shared components referenced by $ref.
+type schemaRegistry struct {
+	schemas map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (s schemaRegistry) schemaFor(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schemaFor(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := s.schemas[t.Name()]; !ok {
+			// Register before recursing so self-referencing types terminate.
+			s.schemas[t.Name()] = nil
+			s.schemas[t.Name()] = s.structSchema(t)
+		}
+		return ref
+	default:
+		return map[string]any{}
+	}
+}
+
+func (s schemaRegistry) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") && field.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/benchmark"
+)
+
+func TestOpenAPIDocumentDescribesEveryOperation(t *testing.T) {
+	doc := decodeOpenAPI(t)
+
+	paths := doc["paths"].(map[string]any)
+	for _, op := range Operations {
+		item, ok := paths[APIVersionPrefix+op.Path].(map[string]any)
+		if !ok {
+			t.Fatalf("paths missing %s", APIVersionPrefix+op.Path)
+		}
+		if _, ok := item[strings.ToLower(op.Method)]; !ok {
+			t.Fatalf("path %s missing %s operation", op.Path, op.Method)
+		}
+	}
+}
+
+func TestOpenAPIDocumentReferencesResolve(t *testing.T) {
+	doc := decodeOpenAPI(t)
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	var walk func(any)
+	walk = func(node any) {
+		switch value := node.(type) {
+		case map[string]any:
+			if ref, ok := value["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if _, ok := schemas[name]; !ok {
+					t.Fatalf("unresolved $ref %q", ref)
+				}
+			}
+			for _, child := range value {
+				walk(child)
+			}
+		case []any:
+			for _, child := range value {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+func TestOpenAPISchemasMatchEncodedTypes(t *testing.T) {
+	doc := decodeOpenAPI(t)
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	values := map[string]any{
+		"ExecutionRequest": api.ExecutionRequest{
+			Language: "python", SourceCode: "print(1)", Stdin: "x", TimeoutMS: 1,
+			Files: map[string]string{"a": "b"}, Args: []string{"a"}, Mode: api.ExecutionModeTest,
+			CaptureFileChanges: true, Audit: true,
+		},
+		"ExecutionResponse": api.ExecutionResponse{
+			Stdout: "1", Interactor: &api.ExecutionResponse{}, Transcript: []api.TranscriptEntry{{}},
+			TestReport: "r", TestReportFormat: "f", FileChanges: []api.FileChange{{}}, Audit: &api.AuditSummary{},
+		},
+		"ErrorResponse":   api.ErrorResponse{},
+		"BenchmarkReport": benchmark.BenchmarkReport{},
+	}
+	for name, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("json.Marshal(%s) error = %v", name, err)
+		}
+		var encoded map[string]any
+		json.Unmarshal(raw, &encoded)
+
+		schema, ok := schemas[name].(map[string]any)
+		if !ok {
+			t.Fatalf("components.schemas missing %s", name)
+		}
+		properties := schema["properties"].(map[string]any)
+		for key := range encoded {
+			if _, ok := properties[key]; !ok {
+				t.Fatalf("%s schema missing encoded field %q", name, key)
+			}
+		}
+		if len(properties) != reflect.TypeOf(value).NumField() {
+			t.Fatalf("%s schema has %d properties, type has %d fields", name, len(properties), reflect.TypeOf(value).NumField())
+		}
+	}
+
+	required := schemas["ExecutionRequest"].(map[string]any)["required"]
+	if !reflect.DeepEqual(required, []any{"language", "source_code"}) {
+		t.Fatalf("ExecutionRequest required = %v, want language and source_code", required)
+	}
+}
+
+func decodeOpenAPI(t *testing.T) map[string]any {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	OpenAPIHandler(rr, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rr.Code)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Fatalf("openapi = %v, want 3.1.0", doc["openapi"])
+	}
+	return doc
+}
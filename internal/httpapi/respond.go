@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/validation"
+)
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// WriteError writes the JSON error envelope.
+func WriteError(w http.ResponseWriter, status int, code string, message string) {
+	api.WriteError(w, status, code, message)
+}
+
+// decodeJSON decodes the request body into v. On failure it writes a 413 for
+// a body over the server's limit or a 400 otherwise, and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	return decoded(w, json.NewDecoder(r.Body).Decode(v))
+}
+
+// decodeOptionalJSON is decodeJSON for bodies that may be omitted, leaving v
+// unchanged when the body is empty.
+func decodeOptionalJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	return decoded(w, err)
+}
+
+func decoded(w http.ResponseWriter, err error) bool {
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		WriteError(w, http.StatusRequestEntityTooLarge, api.ErrorCodeTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+		return false
+	}
+	WriteError(w, http.StatusBadRequest, api.ErrorCodeInvalidJSON, "request body is not valid JSON")
+	return false
+}
+
+// writeValidationError answers 422 with every invalid field.
+func writeValidationError(w http.ResponseWriter, err *validation.Error) {
+	writeJSON(w, http.StatusUnprocessableEntity, api.ErrorResponse{Error: api.ErrorDetail{
+		Code:    api.ErrorCodeValidation,
+		Message: err.Error(),
+		Fields:  err.Fields,
+	}})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	WriteError(w, http.StatusMethodNotAllowed, api.ErrorCodeMethodNotAllowed, "method not allowed")
+}
+
+// NotFoundHandler answers unknown API paths with the JSON error envelope.
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, http.StatusNotFound, api.ErrorCodeNotFound, "no route for "+r.URL.Path)
+}
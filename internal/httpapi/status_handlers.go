@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/health"
+	"gexec-sandbox/internal/metrics"
+)
+
+func PingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.StatusResponse{Status: "ok"})
+}
+
+// HealthzHandler reports liveness: it answers whenever the process can serve
+// HTTP, regardless of its dependencies.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.StatusResponse{Status: "ok"})
+}
+
+// ReadinessHandler reports each dependency's status, answering 503 when a
+// critical dependency is down.
+type ReadinessHandler struct {
+	Checker *health.Checker
+}
+
+func (h ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	report := h.Checker.Check(r.Context())
+	status := http.StatusOK
+	if report.Status == api.ReadinessUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, metrics.GetMetrics())
+}
+
+func PrometheusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	w.Header().Set("Content-Type", metrics.PrometheusContentType)
+	metrics.WritePrometheus(w)
+}
@@ -3,7 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
@@ -89,9 +89,9 @@ func WaitForOllamaWithConfig(ctx context.Context, cfg config.Config) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			log.Println("Checking Ollama availability...")
+			slog.DebugContext(ctx, "checking ollama availability")
 			if err := adapter.HealthCheck(ctx); err == nil {
-				log.Println("Ollama is available")
+				slog.InfoContext(ctx, "ollama is available")
 				return nil
 			}
 		}
@@ -124,7 +124,7 @@ func PullModel() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	log.Printf("Pulling model %s...", cfg.OLLAMAModel)
+	slog.Info("pulling model", "model", cfg.OLLAMAModel)
 
 	req := &api.PullRequest{
 		Model:  cfg.OLLAMAModel,
@@ -132,7 +132,7 @@ func PullModel() error {
 	}
 
 	err = client.Pull(ctx, req, func(resp api.ProgressResponse) error {
-		log.Printf("Pulling: %s", resp.Status)
+		slog.Debug("pulling model", "model", cfg.OLLAMAModel, "status", resp.Status)
 		return nil
 	})
 
@@ -140,7 +140,7 @@ func PullModel() error {
 		return fmt.Errorf("failed to pull model: %w", err)
 	}
 
-	log.Printf("Model %s pulled successfully", cfg.OLLAMAModel)
+	slog.Info("model pulled", "model", cfg.OLLAMAModel)
 	return nil
 }
 
@@ -0,0 +1,152 @@
+// Package logging configures the process-wide slog logger and carries
+// correlation IDs through contexts so every log line written with a request
+// or benchmark context names the work it belongs to.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	LevelEnv  = "EVALUATOR_LOG_LEVEL"
+	FormatEnv = "EVALUATOR_LOG_FORMAT"
+
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Attribute keys shared by every package that logs correlated work.
+const (
+	RequestIDKey = "request_id"
+	RunIDKey     = "run_id"
+	ModelIDKey   = "model_id"
+	TaskIDKey    = "task_id"
+	KeyIDKey     = "key_id"
+	TraceIDKey   = "trace_id"
+)
+
+type Config struct {
+	Level  slog.Level
+	Format string
+}
+
+// ConfigFromEnv reads the log level (debug, info, warn, error) and format
+// (text, json) from the environment, defaulting to info and text.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Level: slog.LevelInfo, Format: FormatText}
+	if raw := strings.TrimSpace(os.Getenv(LevelEnv)); raw != "" {
+		if err := cfg.Level.UnmarshalText([]byte(raw)); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", LevelEnv, err)
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv(FormatEnv)); raw != "" {
+		cfg.Format = strings.ToLower(raw)
+	}
+	if cfg.Format != FormatText && cfg.Format != FormatJSON {
+		return Config{}, fmt.Errorf("%s must be %q or %q, got %q", FormatEnv, FormatText, FormatJSON, cfg.Format)
+	}
+	return cfg, nil
+}
+
+// New builds a logger writing to w that adds the correlation attributes
+// stored in each record's context.
+func New(w io.Writer, cfg Config) *slog.Logger {
+	options := &slog.HandlerOptions{Level: cfg.Level}
+	var handler slog.Handler
+	if cfg.Format == FormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Setup installs a logger on stderr as the slog and log package default.
+func Setup(cfg Config) {
+	slog.SetDefault(New(os.Stderr, cfg))
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		record.AddAttrs(fields.attrs...)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String(TraceIDKey, span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type fieldsKey struct{}
+
+type fields struct {
+	attrs     []slog.Attr
+	requestID string
+}
+
+// With returns a context whose log lines also carry attrs. Later values for
+// the same key replace earlier ones.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	next := &fields{}
+	if parent, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		next.requestID = parent.requestID
+		for _, attr := range parent.attrs {
+			if !hasKey(attrs, attr.Key) {
+				next.attrs = append(next.attrs, attr)
+			}
+		}
+	}
+	next.attrs = append(next.attrs, attrs...)
+	return context.WithValue(ctx, fieldsKey{}, next)
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRequestID tags ctx with the ID of the request it serves.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = With(ctx, slog.String(RequestIDKey, id))
+	ctx.Value(fieldsKey{}).(*fields).requestID = id
+	return ctx
+}
+
+// RequestID returns the request ID stored by WithRequestID, or "".
+func RequestID(ctx context.Context) string {
+	if fields, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		return fields.requestID
+	}
+	return ""
+}
+
+// NewID returns a random 16-byte hex identifier.
+func NewID() string {
+	var raw [16]byte
+	rand.Read(raw[:])
+	return hex.EncodeToString(raw[:])
+}
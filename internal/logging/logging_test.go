@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestLoggerAddsContextAttributes(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, Config{Level: slog.LevelInfo, Format: FormatJSON})
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = With(ctx, slog.String(RunIDKey, "run-1"), slog.String(TaskIDKey, "first"))
+	ctx = With(ctx, slog.String(TaskIDKey, "second"))
+	logger.InfoContext(ctx, "task run finished", "passed", true)
+	logger.DebugContext(ctx, "dropped below level")
+
+	var line map[string]any
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("output %q is not one JSON line: %v", out.String(), err)
+	}
+	want := map[string]any{"msg": "task run finished", RequestIDKey: "req-1", RunIDKey: "run-1", TaskIDKey: "second", "passed": true}
+	for key, value := range want {
+		if line[key] != value {
+			t.Fatalf("%s = %v, want %v in %s", key, line[key], value, out.String())
+		}
+	}
+	if got := RequestID(ctx); got != "req-1" {
+		t.Fatalf("RequestID() = %q, want req-1", got)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(LevelEnv, "debug")
+	t.Setenv(FormatEnv, "JSON")
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.Level != slog.LevelDebug || cfg.Format != FormatJSON {
+		t.Fatalf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+
+	t.Setenv(FormatEnv, "xml")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Fatal("ConfigFromEnv() accepted format xml")
+	}
+
+	t.Setenv(FormatEnv, "")
+	t.Setenv(LevelEnv, "loud")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Fatal("ConfigFromEnv() accepted level loud")
+	}
+}
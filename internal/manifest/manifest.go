@@ -5,8 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net/netip"
 	"os"
+	"slices"
 	"sort"
+	"strings"
+	"time"
 
 	"gexec-sandbox/internal/benchmark"
 	"gexec-sandbox/internal/config"
@@ -14,10 +19,39 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-var ErrInvalidManifest = errors.New("invalid benchmark manifest")
+var (
+	ErrInvalidManifest  = errors.New("invalid benchmark manifest")
+	ErrManifestNotFound = errors.New("benchmark manifest not found")
+)
+
+// PathEnv names the manifest to load when no path is given, and DefaultPath
+// is looked for in the working directory when PathEnv is unset too.
+const (
+	PathEnv     = "EVALUATOR_MANIFEST"
+	DefaultPath = "benchmark.yaml"
+)
+
+// Resolve returns path, or the manifest that PathEnv or the working directory
+// provides when path is empty.
+func Resolve(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	if path := strings.TrimSpace(os.Getenv(PathEnv)); path != "" {
+		return path, nil
+	}
+	if _, err := os.Stat(DefaultPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w: no path given, %s is unset and the working directory has no %s", ErrManifestNotFound, PathEnv, DefaultPath)
+		}
+		return "", err
+	}
+	return DefaultPath, nil
+}
 
 type Loaded struct {
 	Runtime           config.Config
+	Server            config.Server
 	Models            []modeladapter.Config
 	DefaultModelRoles map[string]string
 	Tasks             benchmark.TaskCatalog
@@ -27,6 +61,7 @@ type Loaded struct {
 type file struct {
 	SchemaVersion     int                 `yaml:"schema_version"`
 	RuntimeDefaults   runtimeDefaults     `yaml:"runtime_defaults"`
+	Server            server              `yaml:"server"`
 	Providers         map[string]provider `yaml:"providers"`
 	Models            map[string]model    `yaml:"models"`
 	DefaultModelRoles map[string]string   `yaml:"default_model_roles"`
@@ -35,7 +70,46 @@ type file struct {
 }
 
 type runtimeDefaults struct {
-	TimeoutMS int `yaml:"timeout_ms"`
+	TimeoutMS               int               `yaml:"timeout_ms"`
+	MaxConcurrentExecutions int               `yaml:"max_concurrent_executions"`
+	Concurrency             int               `yaml:"concurrency"`
+	Epochs                  int               `yaml:"epochs"`
+	SamplesPerTask          int               `yaml:"samples_per_task"`
+	PassAtK                 []int             `yaml:"pass_at_k"`
+	SignificanceAlpha       float64           `yaml:"significance_alpha"`
+	BootstrapResamples      int               `yaml:"bootstrap_resamples"`
+	MinTimeoutMS            int               `yaml:"min_timeout_ms"`
+	MaxTimeoutMS            int               `yaml:"max_timeout_ms"`
+	MaxSourceBytes          int               `yaml:"max_source_bytes"`
+	MaxStdinBytes           int               `yaml:"max_stdin_bytes"`
+	TestImages              map[string]string `yaml:"test_images"`
+	Audit                   auditDefaults     `yaml:"audit"`
+}
+
+type server struct {
+	TrustedProxies []string             `yaml:"trusted_proxies"`
+	RateLimits     map[string]rateLimit `yaml:"rate_limits"`
+	MaxBodyBytes   int64                `yaml:"max_body_bytes"`
+	GRPCAddr       string               `yaml:"grpc_addr"`
+	Addr           string               `yaml:"addr"`
+	TLSCertFile    string               `yaml:"tls_cert_file"`
+	TLSKeyFile     string               `yaml:"tls_key_file"`
+	ReadTimeoutMS  int                  `yaml:"read_timeout_ms"`
+	WriteTimeoutMS int                  `yaml:"write_timeout_ms"`
+	IdleTimeoutMS  int                  `yaml:"idle_timeout_ms"`
+	ShutdownMS     int                  `yaml:"shutdown_grace_ms"`
+	RunsDir        string               `yaml:"runs_dir"`
+	MaxActiveRuns  int                  `yaml:"max_active_runs"`
+}
+
+type rateLimit struct {
+	RequestsPerMinute float64 `yaml:"requests_per_minute"`
+	Burst             int     `yaml:"burst"`
+}
+
+type auditDefaults struct {
+	Enabled bool              `yaml:"enabled"`
+	Images  map[string]string `yaml:"images"`
 }
 
 type provider struct {
@@ -57,6 +131,7 @@ type model struct {
 	RequestMapping  modeladapter.RequestMapping  `yaml:"request_mapping"`
 	ResponseMapping modeladapter.ResponseMapping `yaml:"response_mapping"`
 	Capabilities    modeladapter.Capabilities    `yaml:"capabilities"`
+	Concurrency     int                          `yaml:"concurrency"`
 }
 
 type task struct {
@@ -65,8 +140,14 @@ type task struct {
 	Description         string                         `yaml:"description"`
 	Family              string                         `yaml:"family"`
 	Language            string                         `yaml:"language"`
+	Tags                []string                       `yaml:"tags"`
 	ArtifactExpectation *benchmark.ArtifactExpectation `yaml:"artifact_expectation"`
 	TestCases           []benchmark.TestCase           `yaml:"test_cases"`
+	Checker             *benchmark.Checker             `yaml:"checker"`
+	Interactor          *benchmark.Interactor          `yaml:"interactor"`
+	GradingMode         string                         `yaml:"grading_mode"`
+	Tests               *benchmark.TestSuite           `yaml:"tests"`
+	FileChanges         *benchmark.FileChangePolicy    `yaml:"file_changes"`
 }
 
 type scaffold struct {
@@ -105,6 +186,13 @@ func Load(path string) (Loaded, error) {
 	if err != nil {
 		return Loaded{}, err
 	}
+	serverConfig, err := manifest.serverConfig()
+	if err != nil {
+		return Loaded{}, err
+	}
+	if err := validateWriteTimeout(runtime, serverConfig); err != nil {
+		return Loaded{}, err
+	}
 	defaultRoles, err := manifest.defaultModelRoles(models)
 	if err != nil {
 		return Loaded{}, err
@@ -114,6 +202,9 @@ func Load(path string) (Loaded, error) {
 	if err != nil {
 		return Loaded{}, err
 	}
+	if err := validateAuditImages(runtime, tasks); err != nil {
+		return Loaded{}, err
+	}
 
 	scaffolds, err := manifest.scaffoldCatalog()
 	if err != nil {
@@ -125,6 +216,7 @@ func Load(path string) (Loaded, error) {
 
 	return Loaded{
 		Runtime:           runtime,
+		Server:            serverConfig,
 		Models:            models,
 		DefaultModelRoles: defaultRoles,
 		Tasks:             tasks,
@@ -139,6 +231,102 @@ func (m file) validateSchema() error {
 	return nil
 }
 
+func (m file) serverConfig() (config.Server, error) {
+	cfg := config.DefaultServer()
+	for _, proxy := range m.Server.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				return config.Server{}, fmt.Errorf("%w: server.trusted_proxies entry %q is not an IP or CIDR", ErrInvalidManifest, proxy)
+			}
+		}
+	}
+	cfg.TrustedProxies = append([]string(nil), m.Server.TrustedProxies...)
+
+	for _, path := range sortedKeys(m.Server.RateLimits) {
+		limit := m.Server.RateLimits[path]
+		if !strings.HasPrefix(path, "/") {
+			return config.Server{}, fmt.Errorf("%w: server.rate_limits key %q must be an endpoint path", ErrInvalidManifest, path)
+		}
+		if limit.RequestsPerMinute < 0 {
+			return config.Server{}, fmt.Errorf("%w: server.rate_limits.%s.requests_per_minute cannot be negative", ErrInvalidManifest, path)
+		}
+		if limit.RequestsPerMinute > 0 && limit.Burst < 1 {
+			return config.Server{}, fmt.Errorf("%w: server.rate_limits.%s.burst must be at least 1", ErrInvalidManifest, path)
+		}
+		cfg.RateLimits[path] = config.RateLimit{RequestsPerMinute: limit.RequestsPerMinute, Burst: limit.Burst}
+	}
+
+	if m.Server.MaxBodyBytes < 0 {
+		return config.Server{}, fmt.Errorf("%w: server.max_body_bytes cannot be negative", ErrInvalidManifest)
+	}
+	if m.Server.MaxBodyBytes > 0 {
+		cfg.MaxBodyBytes = m.Server.MaxBodyBytes
+	}
+	cfg.GRPCAddr = strings.TrimSpace(m.Server.GRPCAddr)
+	if addr := strings.TrimSpace(m.Server.Addr); addr != "" {
+		cfg.Addr = addr
+	}
+
+	cfg.RunsDir = strings.TrimSpace(m.Server.RunsDir)
+	if m.Server.MaxActiveRuns < 0 {
+		return config.Server{}, fmt.Errorf("%w: server.max_active_runs cannot be negative", ErrInvalidManifest)
+	}
+	if m.Server.MaxActiveRuns > 0 {
+		cfg.MaxActiveRuns = m.Server.MaxActiveRuns
+	}
+	cfg.TLSCertFile, cfg.TLSKeyFile = m.Server.TLSCertFile, m.Server.TLSKeyFile
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return config.Server{}, fmt.Errorf("%w: server.tls_cert_file and server.tls_key_file must be set together", ErrInvalidManifest)
+	}
+
+	durations := []struct {
+		name  string
+		value int
+		field *time.Duration
+	}{
+		{"read_timeout_ms", m.Server.ReadTimeoutMS, &cfg.ReadTimeout},
+		{"write_timeout_ms", m.Server.WriteTimeoutMS, &cfg.WriteTimeout},
+		{"idle_timeout_ms", m.Server.IdleTimeoutMS, &cfg.IdleTimeout},
+		{"shutdown_grace_ms", m.Server.ShutdownMS, &cfg.ShutdownGrace},
+	}
+	for _, duration := range durations {
+		if duration.value < 0 {
+			return config.Server{}, fmt.Errorf("%w: server.%s cannot be negative", ErrInvalidManifest, duration.name)
+		}
+		if duration.value > 0 {
+			*duration.field = time.Duration(duration.value) * time.Millisecond
+		}
+	}
+	return cfg, nil
+}
+
+// validateWriteTimeout rejects a write timeout that would cut off an
+// execution the runtime limits allow.
+func validateWriteTimeout(runtime config.Config, server config.Server) error {
+	if server.WriteTimeout > 0 && server.WriteTimeout <= time.Duration(runtime.MaxTimeoutMS)*time.Millisecond {
+		return fmt.Errorf("%w: server.write_timeout_ms must exceed runtime_defaults.max_timeout_ms %d", ErrInvalidManifest, runtime.MaxTimeoutMS)
+	}
+	return nil
+}
+
+// validateAuditImages rejects audit mode for a task language without an
+// audit image, since the default images do not ship strace. Tasks graded by
+// their tests are never audited.
+func validateAuditImages(runtime config.Config, tasks benchmark.TaskCatalog) error {
+	if !runtime.Audit {
+		return nil
+	}
+	for _, task := range tasks.Tasks {
+		if task.GradingMode == benchmark.GradingModeTestsPass {
+			continue
+		}
+		if _, ok := runtime.AuditImages[task.Language]; !ok {
+			return fmt.Errorf("%w: runtime_defaults.audit.enabled requires runtime_defaults.audit.images.%s for task %q", ErrInvalidManifest, task.Language, task.ID)
+		}
+	}
+	return nil
+}
+
 func (m file) runtimeConfig() (config.Config, error) {
 	ollamaModel, ollamaHost, err := m.ollamaSelection()
 	if err != nil {
@@ -152,13 +340,100 @@ func (m file) runtimeConfig() (config.Config, error) {
 	if timeoutMS < 0 {
 		return config.Config{}, fmt.Errorf("%w: runtime_defaults.timeout_ms cannot be negative", ErrInvalidManifest)
 	}
+	if m.RuntimeDefaults.MaxConcurrentExecutions < 0 {
+		return config.Config{}, fmt.Errorf("%w: runtime_defaults.max_concurrent_executions cannot be negative", ErrInvalidManifest)
+	}
+	if m.RuntimeDefaults.Concurrency < 0 {
+		return config.Config{}, fmt.Errorf("%w: runtime_defaults.concurrency cannot be negative", ErrInvalidManifest)
+	}
+	if m.RuntimeDefaults.Epochs < 0 {
+		return config.Config{}, fmt.Errorf("%w: runtime_defaults.epochs cannot be negative", ErrInvalidManifest)
+	}
+	// samples_per_task is another name for epochs.
+	if m.RuntimeDefaults.SamplesPerTask < 0 {
+		return config.Config{}, fmt.Errorf("%w: runtime_defaults.samples_per_task cannot be negative", ErrInvalidManifest)
+	}
+	if m.RuntimeDefaults.Epochs > 0 && m.RuntimeDefaults.SamplesPerTask > 0 && m.RuntimeDefaults.Epochs != m.RuntimeDefaults.SamplesPerTask {
+		return config.Config{}, fmt.Errorf("%w: runtime_defaults.samples_per_task %d disagrees with epochs %d", ErrInvalidManifest, m.RuntimeDefaults.SamplesPerTask, m.RuntimeDefaults.Epochs)
+	}
+	samples := max(m.RuntimeDefaults.Epochs, m.RuntimeDefaults.SamplesPerTask)
+	if alpha := m.RuntimeDefaults.SignificanceAlpha; alpha < 0 || alpha >= 1 {
+		return config.Config{}, fmt.Errorf("%w: runtime_defaults.significance_alpha must be between 0 and 1", ErrInvalidManifest)
+	}
+	if m.RuntimeDefaults.BootstrapResamples < 0 {
+		return config.Config{}, fmt.Errorf("%w: runtime_defaults.bootstrap_resamples cannot be negative", ErrInvalidManifest)
+	}
+	epochs := max(samples, 1)
+	passAtK := slices.Sorted(slices.Values(m.RuntimeDefaults.PassAtK))
+	for i, k := range passAtK {
+		if k < 1 || k > epochs {
+			return config.Config{}, fmt.Errorf("%w: runtime_defaults.pass_at_k value %d must be between 1 and epochs %d", ErrInvalidManifest, k, epochs)
+		}
+		if i > 0 && passAtK[i-1] == k {
+			return config.Config{}, fmt.Errorf("%w: runtime_defaults.pass_at_k repeats %d", ErrInvalidManifest, k)
+		}
+	}
+	limits := []struct {
+		name     string
+		value    *int
+		fallback int
+	}{
+		{"min_timeout_ms", &m.RuntimeDefaults.MinTimeoutMS, config.DefaultMinTimeoutMS},
+		{"max_timeout_ms", &m.RuntimeDefaults.MaxTimeoutMS, config.DefaultMaxTimeoutMS},
+		{"max_source_bytes", &m.RuntimeDefaults.MaxSourceBytes, config.DefaultMaxSourceBytes},
+		{"max_stdin_bytes", &m.RuntimeDefaults.MaxStdinBytes, config.DefaultMaxStdinBytes},
+	}
+	for _, limit := range limits {
+		if *limit.value < 0 {
+			return config.Config{}, fmt.Errorf("%w: runtime_defaults.%s cannot be negative", ErrInvalidManifest, limit.name)
+		}
+		if *limit.value == 0 {
+			*limit.value = limit.fallback
+		}
+	}
+	minTimeoutMS, maxTimeoutMS := m.RuntimeDefaults.MinTimeoutMS, m.RuntimeDefaults.MaxTimeoutMS
+	if timeoutMS < minTimeoutMS || timeoutMS > maxTimeoutMS {
+		return config.Config{}, fmt.Errorf("%w: runtime_defaults.timeout_ms %d must be between min_timeout_ms %d and max_timeout_ms %d", ErrInvalidManifest, timeoutMS, minTimeoutMS, maxTimeoutMS)
+	}
+
+	languages := defaultLanguages()
+	for language, image := range m.RuntimeDefaults.TestImages {
+		if _, ok := languages[language]; !ok {
+			return config.Config{}, fmt.Errorf("%w: runtime_defaults.test_images language %q is not supported", ErrInvalidManifest, language)
+		}
+		if image == "" {
+			return config.Config{}, fmt.Errorf("%w: runtime_defaults.test_images.%s requires an image", ErrInvalidManifest, language)
+		}
+	}
+	for language, image := range m.RuntimeDefaults.Audit.Images {
+		if _, ok := languages[language]; !ok {
+			return config.Config{}, fmt.Errorf("%w: runtime_defaults.audit.images language %q is not supported", ErrInvalidManifest, language)
+		}
+		if image == "" {
+			return config.Config{}, fmt.Errorf("%w: runtime_defaults.audit.images.%s requires an image", ErrInvalidManifest, language)
+		}
+	}
 
 	return config.Config{
 		DefaultTimeoutMS: timeoutMS,
 		MaxMemoryMB:      256,
 		OLLAMAHost:       ollamaHost,
 		OLLAMAModel:      ollamaModel,
-		Languages:        defaultLanguages(),
+		Languages:        languages,
+		TestImages:       copyStringMap(m.RuntimeDefaults.TestImages),
+		Audit:            m.RuntimeDefaults.Audit.Enabled,
+		AuditImages:      copyStringMap(m.RuntimeDefaults.Audit.Images),
+
+		MaxConcurrentExecutions: m.RuntimeDefaults.MaxConcurrentExecutions,
+		BenchmarkConcurrency:    m.RuntimeDefaults.Concurrency,
+		Epochs:                  samples,
+		PassAtK:                 passAtK,
+		SignificanceAlpha:       m.RuntimeDefaults.SignificanceAlpha,
+		BootstrapResamples:      m.RuntimeDefaults.BootstrapResamples,
+		MinTimeoutMS:            minTimeoutMS,
+		MaxTimeoutMS:            maxTimeoutMS,
+		MaxSourceBytes:          m.RuntimeDefaults.MaxSourceBytes,
+		MaxStdinBytes:           m.RuntimeDefaults.MaxStdinBytes,
 	}, nil
 }
 
@@ -187,6 +462,9 @@ func (m file) modelConfigs() ([]modeladapter.Config, error) {
 		if providerConfig.ModelLookup != "" && providerConfig.ModelLookup != "direct" {
 			return nil, fmt.Errorf("%w: provider %q model_lookup %q is not supported by the current runtime", ErrInvalidManifest, candidate.Provider, providerConfig.ModelLookup)
 		}
+		if candidate.Concurrency < 0 {
+			return nil, fmt.Errorf("%w: model %q concurrency cannot be negative", ErrInvalidManifest, name)
+		}
 
 		auth, apiKeyEnv, err := resolveAuth(name, providerConfig.APIKeyEnv, candidate.Auth)
 		if err != nil {
@@ -207,6 +485,7 @@ func (m file) modelConfigs() ([]modeladapter.Config, error) {
 			RequestMapping:  candidate.RequestMapping,
 			ResponseMapping: candidate.ResponseMapping,
 			Capabilities:    candidate.Capabilities,
+			Concurrency:     candidate.Concurrency,
 		}
 		if err := cfg.Validate(); err != nil {
 			return nil, err
@@ -327,14 +606,36 @@ func (m file) taskCatalog() (benchmark.TaskCatalog, error) {
 		if task.ID != name {
 			return benchmark.TaskCatalog{}, fmt.Errorf("%w: task key %q must match id %q", ErrInvalidManifest, name, task.ID)
 		}
+		if task.Checker != nil {
+			if _, ok := defaultLanguages()[task.Checker.Language]; !ok {
+				return benchmark.TaskCatalog{}, fmt.Errorf("%w: task %q checker language %q is not supported", ErrInvalidManifest, name, task.Checker.Language)
+			}
+		}
+		if task.GradingMode == benchmark.GradingModeTestsPass && task.Tests == nil {
+			return benchmark.TaskCatalog{}, fmt.Errorf("%w: task %q grading_mode tests_pass requires tests.files", ErrInvalidManifest, name)
+		}
+		if task.Interactor != nil {
+			if _, ok := defaultLanguages()[task.Interactor.Language]; !ok {
+				return benchmark.TaskCatalog{}, fmt.Errorf("%w: task %q interactor language %q is not supported", ErrInvalidManifest, name, task.Interactor.Language)
+			}
+			if task.Checker != nil {
+				return benchmark.TaskCatalog{}, fmt.Errorf("%w: task %q cannot declare both checker and interactor", ErrInvalidManifest, name)
+			}
+		}
 		catalog.Tasks = append(catalog.Tasks, benchmark.Task{
 			ID:                  task.ID,
 			Title:               task.Title,
 			Description:         task.Description,
 			TaskFamily:          task.Family,
 			Language:            task.Language,
+			Tags:                task.Tags,
 			ArtifactExpectation: task.ArtifactExpectation,
 			TestCases:           task.TestCases,
+			Checker:             task.Checker,
+			Interactor:          task.Interactor,
+			GradingMode:         task.GradingMode,
+			Tests:               task.Tests,
+			FileChanges:         task.FileChanges,
 		})
 	}
 	if err := benchmark.ValidateTaskCatalog(catalog); err != nil {
@@ -446,6 +747,17 @@ func sortedKeys[V any](items map[string]V) []string {
 	return keys
 }
 
+func copyStringMap(source map[string]string) map[string]string {
+	if len(source) == 0 {
+		return nil
+	}
+	cloned := make(map[string]string, len(source))
+	for key, value := range source {
+		cloned[key] = value
+	}
+	return cloned
+}
+
 func copyAnyMap(source map[string]any) map[string]any {
 	if len(source) == 0 {
 		return nil
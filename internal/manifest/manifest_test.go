@@ -1,11 +1,15 @@
 package manifest
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"gexec-sandbox/internal/config"
 )
 
 func TestLoadSupportedManifestReturnsRuntimeAndCatalogs(t *testing.T) {
@@ -29,6 +33,7 @@ tasks:
     description: Produce a markdown digest.
     family: engineering_workflows
     language: python
+    tags: [markdown, reporting]
     artifact_expectation:
       type: markdown_report
       format: markdown
@@ -67,6 +72,9 @@ scaffolds:
 	if got := loaded.Tasks.Tasks[0].TaskFamily; got != "engineering_workflows" {
 		t.Fatalf("TaskFamily = %q, want engineering_workflows", got)
 	}
+	if got := loaded.Tasks.Tasks[0].Tags; len(got) != 2 || got[1] != "reporting" {
+		t.Fatalf("Tags = %v, want markdown and reporting", got)
+	}
 	if len(loaded.Scaffolds.Scaffolds) != 2 {
 		t.Fatalf("scaffolds = %d, want 2", len(loaded.Scaffolds.Scaffolds))
 	}
@@ -263,6 +271,7 @@ models:
     provider: ollama_local
     model_name: alpha:3b
     enabled: true
+    concurrency: 2
 tasks:
   task:
     id: task
@@ -285,8 +294,8 @@ scaffolds:
 	if len(loaded.Models) != 2 {
 		t.Fatalf("Models length = %d, want 2", len(loaded.Models))
 	}
-	if loaded.Models[0].ID != "alpha_local" {
-		t.Fatalf("Models[0].ID = %q, want alpha_local", loaded.Models[0].ID)
+	if loaded.Models[0].ID != "alpha_local" || loaded.Models[0].Concurrency != 2 {
+		t.Fatalf("Models[0] = %q with concurrency %d, want alpha_local limited to 2", loaded.Models[0].ID, loaded.Models[0].Concurrency)
 	}
 	if loaded.Models[1].ID != "zed_local" {
 		t.Fatalf("Models[1].ID = %q, want zed_local", loaded.Models[1].ID)
@@ -719,6 +728,50 @@ scaffolds:
 	}
 }
 
+func TestLoadRejectsAuditWithoutImageForTaskLanguage(t *testing.T) {
+	manifest := `
+schema_version: 1
+runtime_defaults:
+  audit:
+    enabled: true
+    images:
+      %s: python-strace:3.9
+providers:
+  ollama_local:
+    kind: ollama
+models:
+  qwen_local:
+    provider: ollama_local
+    model_name: qwen3:4b
+    enabled: true
+tasks:
+  task:
+    id: task
+    title: Task
+    description: Desc
+    family: support_workflows
+    language: python
+    test_cases:
+      - input: ""
+        expected_output: ok
+scaffolds:
+  baseline:
+    baseline: true
+    description: Baseline
+`
+
+	if _, err := Load(writeManifest(t, fmt.Sprintf(manifest, "go"))); !errors.Is(err, ErrInvalidManifest) {
+		t.Fatalf("Load() error = %v, want ErrInvalidManifest for a task language without an audit image", err)
+	}
+	loaded, err := Load(writeManifest(t, fmt.Sprintf(manifest, "python")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !loaded.Runtime.Audit || loaded.Runtime.AuditImages["python"] != "python-strace:3.9" {
+		t.Fatalf("Runtime audit = %v %v, want enabled with the python image", loaded.Runtime.Audit, loaded.Runtime.AuditImages)
+	}
+}
+
 func TestLoadRejectsTaskKeyIDMismatch(t *testing.T) {
 	path := writeManifest(t, `
 schema_version: 1
@@ -951,3 +1004,328 @@ func writeManifest(t *testing.T, contents string) string {
 	}
 	return path
 }
+
+func TestLoadParsesTaskChecker(t *testing.T) {
+	contents := strings.Replace(manifestFixture(`
+  ollama_local:
+    kind: ollama
+`, `
+  qwen_local:
+    provider: ollama_local
+    model_name: qwen3:4b
+    enabled: true
+`, ""), `    test_cases:`, `    checker:
+      language: python
+      source: |
+        import sys
+        sys.exit(0)
+      timeout_ms: 2000
+    test_cases:`, 1)
+
+	loaded, err := Load(writeManifest(t, contents))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	checker := loaded.Tasks.Tasks[0].Checker
+	if checker == nil {
+		t.Fatal("task checker = nil, want parsed checker")
+	}
+	if checker.Language != "python" || checker.TimeoutMS != 2000 || !strings.Contains(checker.Source, "sys.exit(0)") {
+		t.Fatalf("checker = %#v, want python checker with timeout", checker)
+	}
+}
+
+func TestLoadRejectsCheckerWithUnsupportedLanguage(t *testing.T) {
+	contents := strings.Replace(manifestFixture(`
+  ollama_local:
+    kind: ollama
+`, `
+  qwen_local:
+    provider: ollama_local
+    model_name: qwen3:4b
+    enabled: true
+`, ""), `    test_cases:`, `    checker:
+      language: ruby
+      source: exit 0
+    test_cases:`, 1)
+
+	_, err := Load(writeManifest(t, contents))
+	if err == nil || !strings.Contains(err.Error(), "checker language") {
+		t.Fatalf("Load() error = %v, want checker language error", err)
+	}
+}
+
+func TestLoadParsesTaskInteractorAndRejectsCheckerConflict(t *testing.T) {
+	base := manifestFixture(`
+  ollama_local:
+    kind: ollama
+`, `
+  qwen_local:
+    provider: ollama_local
+    model_name: qwen3:4b
+    enabled: true
+`, "")
+	interactor := `    interactor:
+      language: go
+      source: package main
+      timeout_ms: 3000
+    test_cases:`
+
+	loaded, err := Load(writeManifest(t, strings.Replace(base, `    test_cases:`, interactor, 1)))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := loaded.Tasks.Tasks[0].Interactor; got == nil || got.Language != "go" || got.TimeoutMS != 3000 {
+		t.Fatalf("task interactor = %#v, want go interactor with timeout", got)
+	}
+
+	conflicting := strings.Replace(base, `    test_cases:`, `    checker:
+      language: python
+      source: pass
+`+interactor, 1)
+	_, err = Load(writeManifest(t, conflicting))
+	if err == nil || !strings.Contains(err.Error(), "both checker and interactor") {
+		t.Fatalf("Load() error = %v, want checker/interactor conflict", err)
+	}
+}
+
+func TestLoadParsesTestsPassTaskAndTestImages(t *testing.T) {
+	base := manifestFixture(`
+  ollama_local:
+    kind: ollama
+`, `
+  qwen_local:
+    provider: ollama_local
+    model_name: qwen3:4b
+    enabled: true
+`, "")
+	contents := strings.Replace(base, `    test_cases:`, `    grading_mode: tests_pass
+    tests:
+      timeout_ms: 20000
+      files:
+        test_main.py: "from main import solve"
+    test_cases:`, 1)
+	contents = strings.Replace(contents, "schema_version: 1\n", `schema_version: 1
+runtime_defaults:
+  test_images:
+    python: python-pytest:3.11
+`, 1)
+
+	loaded, err := Load(writeManifest(t, contents))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	task := loaded.Tasks.Tasks[0]
+	if task.GradingMode != "tests_pass" || task.Tests == nil || task.Tests.TimeoutMS != 20000 || task.Tests.Files["test_main.py"] == "" {
+		t.Fatalf("task = %#v, want tests_pass task with hidden tests", task)
+	}
+	if got := loaded.Runtime.TestImages["python"]; got != "python-pytest:3.11" {
+		t.Fatalf("TestImages[python] = %q, want python-pytest:3.11", got)
+	}
+
+	unsupported := strings.Replace(contents, "    python: python-pytest:3.11", "    ruby: ruby:3", 1)
+	_, err = Load(writeManifest(t, unsupported))
+	if err == nil || !strings.Contains(err.Error(), "test_images language \"ruby\"") {
+		t.Fatalf("Load() error = %v, want unsupported test image language", err)
+	}
+}
+
+func TestLoadParsesServerRateLimitsOverDefaults(t *testing.T) {
+	base := manifestFixture(`
+  ollama_local:
+    kind: ollama
+`, `
+  qwen_local:
+    provider: ollama_local
+    model_name: qwen3:4b
+    enabled: true
+`, "")
+	contents := strings.Replace(base, "schema_version: 1\n", `schema_version: 1
+server:
+  trusted_proxies: [10.0.0.0/8, 192.0.2.1]
+  rate_limits:
+    /benchmark/run:
+      requests_per_minute: 2
+      burst: 1
+`, 1)
+
+	loaded, err := Load(writeManifest(t, contents))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := loaded.Server.RateLimits["/execute"]; got.RequestsPerMinute != 10 || got.Burst != 10 {
+		t.Fatalf("/execute limit = %+v, want default", got)
+	}
+	if got := loaded.Server.RateLimits["/benchmark/run"]; got.RequestsPerMinute != 2 || got.Burst != 1 {
+		t.Fatalf("/benchmark/run limit = %+v, want manifest override", got)
+	}
+	if len(loaded.Server.TrustedProxies) != 2 {
+		t.Fatalf("TrustedProxies = %v, want two entries", loaded.Server.TrustedProxies)
+	}
+
+	for _, invalid := range []string{
+		"server:\n  trusted_proxies: [proxy.internal]\n",
+		"server:\n  rate_limits:\n    /execute: {requests_per_minute: 5, burst: 0}\n",
+		"server:\n  rate_limits:\n    execute: {requests_per_minute: 5, burst: 1}\n",
+	} {
+		_, err := Load(writeManifest(t, strings.Replace(base, "schema_version: 1\n", "schema_version: 1\n"+invalid, 1)))
+		if !errors.Is(err, ErrInvalidManifest) {
+			t.Fatalf("Load(%q) error = %v, want ErrInvalidManifest", invalid, err)
+		}
+	}
+}
+
+func TestLoadAppliesRequestLimits(t *testing.T) {
+	base := manifestFixture(`
+  ollama_local:
+    kind: ollama
+`, `
+  qwen_local:
+    provider: ollama_local
+    model_name: qwen3:4b
+    enabled: true
+`, "")
+
+	loaded, err := Load(writeManifest(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Runtime.MaxTimeoutMS != config.DefaultMaxTimeoutMS || loaded.Runtime.MaxSourceBytes != config.DefaultMaxSourceBytes || loaded.Server.MaxBodyBytes != config.DefaultMaxBodyBytes {
+		t.Fatalf("limits = %+v / %d, want defaults", loaded.Runtime, loaded.Server.MaxBodyBytes)
+	}
+
+	overridden := strings.Replace(base, "schema_version: 1\n", `schema_version: 1
+runtime_defaults:
+  timeout_ms: 2000
+  max_timeout_ms: 10000
+  max_stdin_bytes: 64
+  concurrency: 4
+  epochs: 5
+  pass_at_k: [5, 1]
+  significance_alpha: 0.01
+  bootstrap_resamples: 500
+server:
+  max_body_bytes: 1024
+  grpc_addr: " :9090 "
+`, 1)
+	loaded, err = Load(writeManifest(t, overridden))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Runtime.MaxTimeoutMS != 10000 || loaded.Runtime.MaxStdinBytes != 64 || loaded.Server.MaxBodyBytes != 1024 {
+		t.Fatalf("limits = %+v / %d, want manifest overrides", loaded.Runtime, loaded.Server.MaxBodyBytes)
+	}
+	if loaded.Server.GRPCAddr != ":9090" {
+		t.Fatalf("GRPCAddr = %q, want trimmed manifest value", loaded.Server.GRPCAddr)
+	}
+	if loaded.Runtime.BenchmarkConcurrency != 4 {
+		t.Fatalf("BenchmarkConcurrency = %d, want 4", loaded.Runtime.BenchmarkConcurrency)
+	}
+	if loaded.Runtime.Epochs != 5 || fmt.Sprint(loaded.Runtime.PassAtK) != "[1 5]" {
+		t.Fatalf("Epochs = %d, PassAtK = %v; want 5 epochs and sorted k values", loaded.Runtime.Epochs, loaded.Runtime.PassAtK)
+	}
+	if loaded.Runtime.Alpha() != 0.01 || loaded.Runtime.Resamples() != 500 {
+		t.Fatalf("alpha = %v, resamples = %d; want manifest overrides", loaded.Runtime.Alpha(), loaded.Runtime.Resamples())
+	}
+
+	aliased := strings.Replace(base, "schema_version: 1\n", "schema_version: 1\nruntime_defaults:\n  samples_per_task: 3\n  pass_at_k: [3]\n", 1)
+	if loaded, err := Load(writeManifest(t, aliased)); err != nil || loaded.Runtime.Epochs != 3 {
+		t.Fatalf("Load() with samples_per_task = %d epochs, %v; want 3", loaded.Runtime.Epochs, err)
+	}
+
+	for _, invalid := range []string{
+		"runtime_defaults:\n  max_source_bytes: -1\n",
+		"runtime_defaults:\n  concurrency: -1\n",
+		"runtime_defaults:\n  pass_at_k: [2]\n",
+		"runtime_defaults:\n  significance_alpha: 1\n",
+		"runtime_defaults:\n  epochs: 3\n  pass_at_k: [1, 1]\n",
+		"runtime_defaults:\n  epochs: 3\n  samples_per_task: 4\n",
+		"runtime_defaults:\n  samples_per_task: -1\n",
+		"runtime_defaults:\n  timeout_ms: 60000\n  max_timeout_ms: 1000\n",
+		"server:\n  max_body_bytes: -1\n",
+	} {
+		_, err := Load(writeManifest(t, strings.Replace(base, "schema_version: 1\n", "schema_version: 1\n"+invalid, 1)))
+		if !errors.Is(err, ErrInvalidManifest) {
+			t.Fatalf("Load(%q) error = %v, want ErrInvalidManifest", invalid, err)
+		}
+	}
+}
+
+func TestLoadParsesListenerSettings(t *testing.T) {
+	base := manifestFixture(`
+  ollama_local:
+    kind: ollama
+`, `
+  qwen_local:
+    provider: ollama_local
+    model_name: qwen3:4b
+    enabled: true
+`, "")
+
+	loaded, err := Load(writeManifest(t, base))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Server.Addr != config.DefaultAddr || loaded.Server.ShutdownGrace != config.DefaultShutdownGrace || loaded.Server.WriteTimeout != 0 || loaded.Server.TLS() || loaded.Server.MaxActiveRuns != config.DefaultMaxActiveRuns {
+		t.Fatalf("server = %+v, want listener defaults", loaded.Server)
+	}
+
+	overridden := strings.Replace(base, "schema_version: 1\n", `schema_version: 1
+server:
+  addr: 127.0.0.1:8443
+  tls_cert_file: cert.pem
+  tls_key_file: key.pem
+  read_timeout_ms: 5000
+  write_timeout_ms: 400000
+  idle_timeout_ms: 60000
+  shutdown_grace_ms: 10000
+  runs_dir: runs
+  max_active_runs: 2
+`, 1)
+	loaded, err = Load(writeManifest(t, overridden))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got := loaded.Server
+	if got.Addr != "127.0.0.1:8443" || !got.TLS() || got.TLSKeyFile != "key.pem" || got.RunsDir != "runs" || got.MaxActiveRuns != 2 {
+		t.Fatalf("server = %+v, want manifest address and TLS files", got)
+	}
+	if got.ReadTimeout != 5*time.Second || got.WriteTimeout != 400*time.Second || got.IdleTimeout != time.Minute || got.ShutdownGrace != 10*time.Second {
+		t.Fatalf("server timeouts = %+v, want manifest overrides", got)
+	}
+
+	for _, invalid := range []string{
+		"server:\n  tls_cert_file: cert.pem\n",
+		"server:\n  idle_timeout_ms: -1\n",
+		"server:\n  max_active_runs: -1\n",
+		// The default max_timeout_ms is five minutes.
+		"server:\n  write_timeout_ms: 60000\n",
+	} {
+		_, err := Load(writeManifest(t, strings.Replace(base, "schema_version: 1\n", "schema_version: 1\n"+invalid, 1)))
+		if !errors.Is(err, ErrInvalidManifest) {
+			t.Fatalf("Load(%q) error = %v, want ErrInvalidManifest", invalid, err)
+		}
+	}
+}
+
+func TestResolvePrefersFlagThenEnvThenWorkingDirectory(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(PathEnv, "")
+
+	if _, err := Resolve(""); !errors.Is(err, ErrManifestNotFound) {
+		t.Fatalf("Resolve() in empty directory error = %v, want ErrManifestNotFound", err)
+	}
+	if err := os.WriteFile(DefaultPath, []byte("schema_version: 1\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if path, err := Resolve(""); err != nil || path != DefaultPath {
+		t.Fatalf("Resolve() = %q, %v; want the working directory manifest", path, err)
+	}
+	t.Setenv(PathEnv, "/etc/evaluator/benchmark.yaml")
+	if path, _ := Resolve(""); path != "/etc/evaluator/benchmark.yaml" {
+		t.Fatalf("Resolve() = %q, want %s", path, PathEnv)
+	}
+	if path, _ := Resolve("custom.yaml"); path != "custom.yaml" {
+		t.Fatalf("Resolve(custom.yaml) = %q, want the given path", path)
+	}
+}
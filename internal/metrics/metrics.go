@@ -1,16 +1,22 @@
 package metrics
 
 import (
+	"maps"
+	"sync"
 	"sync/atomic"
 )
 
 type Metrics struct {
-	TotalRequests uint64 `json:"total_requests"`
-	TotalErrors   uint64 `json:"total_errors"`
+	TotalRequests uint64            `json:"total_requests"`
+	TotalErrors   uint64            `json:"total_errors"`
+	RequestsByKey map[string]uint64 `json:"requests_by_key,omitempty"`
 }
 
 var (
 	globalMetrics = &Metrics{}
+
+	keyRequestsMu sync.Mutex
+	keyRequests   = map[string]uint64{}
 )
 
 func IncrementRequest() {
@@ -21,9 +27,24 @@ func IncrementError() {
 	atomic.AddUint64(&globalMetrics.TotalErrors, 1)
 }
 
+// IncrementKeyRequest counts an authenticated request against its API key ID.
+func IncrementKeyRequest(keyID string) {
+	keyRequestsMu.Lock()
+	defer keyRequestsMu.Unlock()
+	keyRequests[keyID]++
+}
+
 func GetMetrics() Metrics {
+	keyRequestsMu.Lock()
+	byKey := maps.Clone(keyRequests)
+	keyRequestsMu.Unlock()
+	if len(byKey) == 0 {
+		byKey = nil
+	}
+
 	return Metrics{
 		TotalRequests: atomic.LoadUint64(&globalMetrics.TotalRequests),
 		TotalErrors:   atomic.LoadUint64(&globalMetrics.TotalErrors),
+		RequestsByKey: byKey,
 	}
 }
@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusContentType is the media type of WritePrometheus output.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+var (
+	latencyBuckets   = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	executionBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+	modelBuckets     = []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120}
+)
+
+var (
+	httpRequests = newFamily("evaluator_http_requests_total", "HTTP requests by endpoint and response status.",
+		kindCounter, nil, "endpoint", "status")
+	httpDuration = newFamily("evaluator_http_request_duration_seconds", "HTTP request latency by endpoint and response status.",
+		kindHistogram, latencyBuckets, "endpoint", "status")
+	grpcRequests = newFamily("evaluator_grpc_requests_total", "gRPC calls by method and status code.",
+		kindCounter, nil, "method", "code")
+	grpcDuration = newFamily("evaluator_grpc_request_duration_seconds", "gRPC call latency by method and status code.",
+		kindHistogram, latencyBuckets, "method", "code")
+	executions = newFamily("evaluator_sandbox_executions_total", "Sandbox executions by language and outcome.",
+		kindCounter, nil, "language", "status")
+	executionDuration = newFamily("evaluator_sandbox_execution_duration_seconds", "Sandbox execution time by language and outcome.",
+		kindHistogram, executionBuckets, "language", "status")
+	activeContainers = newFamily("evaluator_sandbox_active_containers", "Sandbox containers currently alive.",
+		kindGauge, nil)
+	queueDepth = newFamily("evaluator_sandbox_queue_depth", "Executions waiting for a sandbox slot.",
+		kindGauge, nil)
+	modelDuration = newFamily("evaluator_model_generation_duration_seconds", "Model generation latency by model and outcome.",
+		kindHistogram, modelBuckets, "model", "status")
+	modelTokens = newFamily("evaluator_model_tokens_total", "Tokens reported by model providers, by direction.",
+		kindCounter, nil, "model", "direction")
+	benchmarkPassRate = newFamily("evaluator_benchmark_pass_rate", "Fraction of passed runs in the latest benchmark, by model and scaffold.",
+		kindGauge, nil, "model", "scaffold")
+
+	families = []*family{
+		httpRequests, httpDuration,
+		grpcRequests, grpcDuration,
+		executions, executionDuration, activeContainers, queueDepth,
+		modelDuration, modelTokens,
+		benchmarkPassRate,
+	}
+)
+
+// ObserveHTTPRequest records one served request.
+func ObserveHTTPRequest(endpoint string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.add(1, endpoint, code)
+	httpDuration.observe(d.Seconds(), endpoint, code)
+}
+
+// ObserveGRPCRequest records one served gRPC call. For streaming calls d
+// covers the whole stream.
+func ObserveGRPCRequest(method string, code string, d time.Duration) {
+	grpcRequests.add(1, method, code)
+	grpcDuration.observe(d.Seconds(), method, code)
+}
+
+// ObserveExecution records one finished sandbox execution.
+func ObserveExecution(language string, status string, d time.Duration) {
+	executions.add(1, language, status)
+	executionDuration.observe(d.Seconds(), language, status)
+}
+
+func SetActiveContainers(n int) {
+	activeContainers.set(float64(n))
+}
+
+func SetQueueDepth(n int) {
+	queueDepth.set(float64(n))
+}
+
+// ObserveModelGeneration records one Generate call and the tokens it used.
+func ObserveModelGeneration(model string, status string, d time.Duration, inputTokens int, outputTokens int) {
+	modelDuration.observe(d.Seconds(), model, status)
+	if inputTokens > 0 {
+		modelTokens.add(float64(inputTokens), model, "input")
+	}
+	if outputTokens > 0 {
+		modelTokens.add(float64(outputTokens), model, "output")
+	}
+}
+
+func SetBenchmarkPassRate(model string, scaffold string, rate float64) {
+	benchmarkPassRate.set(rate, model, scaffold)
+}
+
+// ResetBenchmarkPassRates drops the pass rates of an earlier benchmark so
+// pairs it ran but the latest did not stop being reported.
+func ResetBenchmarkPassRates() {
+	benchmarkPassRate.reset()
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition
+// format, version 0.0.4.
+func WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	count       uint64
+}
+
+func newFamily(name string, help string, kind string, buckets []float64, labels ...string) *family {
+	return &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: map[string]*series{}}
+}
+
+func (f *family) get(labelValues []string) *series {
+	key := strings.Join(labelValues, "\x00")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) add(delta float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.get(labelValues).value += delta
+}
+
+func (f *family) set(value float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.get(labelValues).value = value
+}
+
+func (f *family) observe(value float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.get(labelValues)
+	for i, upper := range f.buckets {
+		if value <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+func (f *family) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.series = map[string]*series{}
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+	if len(f.labels) == 0 && len(f.series) == 0 && f.kind == kindGauge {
+		fmt.Fprintf(b, "%s 0\n", f.name)
+		return
+	}
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		labels := formatLabels(f.labels, s.labelValues)
+		if f.kind != kindHistogram {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, wrapLabels(labels), formatValue(s.value))
+			continue
+		}
+		for i, upper := range f.buckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, wrapLabels(joinLabels(labels, `le="`+formatValue(upper)+`"`)), s.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, wrapLabels(joinLabels(labels, `le="+Inf"`)), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, wrapLabels(labels), formatValue(s.value))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, wrapLabels(labels), s.count)
+	}
+}
+
+func formatLabels(names []string, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabelValue(values[i]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func joinLabels(labels string, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
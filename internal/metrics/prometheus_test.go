@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetFamilies(t *testing.T) {
+	t.Helper()
+	for _, f := range families {
+		f.reset()
+	}
+	t.Cleanup(func() {
+		for _, f := range families {
+			f.reset()
+		}
+	})
+}
+
+func TestWritePrometheusExposesCountersAndHistograms(t *testing.T) {
+	resetFamilies(t)
+
+	ObserveHTTPRequest("/execute", 200, 30*time.Millisecond)
+	ObserveHTTPRequest("/execute", 200, 2*time.Second)
+	ObserveHTTPRequest("/execute", 429, time.Millisecond)
+
+	var out strings.Builder
+	if err := WritePrometheus(&out); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	text := out.String()
+
+	for _, want := range []string{
+		"# TYPE evaluator_http_requests_total counter\n",
+		`evaluator_http_requests_total{endpoint="/execute",status="200"} 2` + "\n",
+		`evaluator_http_requests_total{endpoint="/execute",status="429"} 1` + "\n",
+		"# TYPE evaluator_http_request_duration_seconds histogram\n",
+		`evaluator_http_request_duration_seconds_bucket{endpoint="/execute",status="200",le="0.025"} 0` + "\n",
+		`evaluator_http_request_duration_seconds_bucket{endpoint="/execute",status="200",le="0.05"} 1` + "\n",
+		`evaluator_http_request_duration_seconds_bucket{endpoint="/execute",status="200",le="2.5"} 2` + "\n",
+		`evaluator_http_request_duration_seconds_bucket{endpoint="/execute",status="200",le="+Inf"} 2` + "\n",
+		`evaluator_http_request_duration_seconds_sum{endpoint="/execute",status="200"} 2.03` + "\n",
+		`evaluator_http_request_duration_seconds_count{endpoint="/execute",status="200"} 2` + "\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("exposition missing %q\n%s", want, text)
+		}
+	}
+}
+
+func TestWritePrometheusReportsGaugesAndModelTokens(t *testing.T) {
+	resetFamilies(t)
+
+	SetActiveContainers(3)
+	SetActiveContainers(2)
+	ObserveModelGeneration("qwen", "ok", time.Second, 120, 30)
+	ObserveModelGeneration("qwen", "ok", time.Second, 80, 0)
+	SetBenchmarkPassRate("qwen", "baseline", 0.5)
+
+	var out strings.Builder
+	WritePrometheus(&out)
+	text := out.String()
+
+	for _, want := range []string{
+		"evaluator_sandbox_active_containers 2\n",
+		"evaluator_sandbox_queue_depth 0\n",
+		`evaluator_model_tokens_total{model="qwen",direction="input"} 200` + "\n",
+		`evaluator_model_tokens_total{model="qwen",direction="output"} 30` + "\n",
+		`evaluator_model_generation_duration_seconds_count{model="qwen",status="ok"} 2` + "\n",
+		`evaluator_benchmark_pass_rate{model="qwen",scaffold="baseline"} 0.5` + "\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("exposition missing %q\n%s", want, text)
+		}
+	}
+}
+
+func TestResetBenchmarkPassRatesDropsEarlierPairs(t *testing.T) {
+	resetFamilies(t)
+
+	SetBenchmarkPassRate("qwen", "baseline", 0.5)
+	ResetBenchmarkPassRates()
+	SetBenchmarkPassRate("llama", "baseline", 1)
+
+	var out strings.Builder
+	WritePrometheus(&out)
+	text := out.String()
+
+	if strings.Contains(text, `model="qwen"`) {
+		t.Fatalf("exposition still reports the earlier pair\n%s", text)
+	}
+	if !strings.Contains(text, `evaluator_benchmark_pass_rate{model="llama",scaffold="baseline"} 1`) {
+		t.Fatalf("exposition missing the latest pair\n%s", text)
+	}
+}
+
+func TestWritePrometheusEscapesLabelValues(t *testing.T) {
+	resetFamilies(t)
+
+	SetBenchmarkPassRate(`a"b\c`, "line\nbreak", 1)
+
+	var out strings.Builder
+	WritePrometheus(&out)
+
+	want := `evaluator_benchmark_pass_rate{model="a\"b\\c",scaffold="line\nbreak"} 1`
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("exposition missing %q\n%s", want, out.String())
+	}
+}
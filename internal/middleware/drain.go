@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"gexec-sandbox/internal/api"
+)
+
+// Drain stops the server accepting new work while in-flight executions and
+// benchmark runs finish. The zero value accepts work.
+type Drain struct {
+	draining atomic.Bool
+}
+
+func (d *Drain) Start() {
+	d.draining.Store(true)
+}
+
+func (d *Drain) Stop() {
+	d.draining.Store(false)
+}
+
+func (d *Drain) Draining() bool {
+	return d.draining.Load()
+}
+
+// Middleware rejects requests with 503 while the server is draining.
+func (d *Drain) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.Draining() {
+			api.WriteError(w, http.StatusServiceUnavailable, api.ErrorCodeDraining, "server is draining and not accepting new work")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDrainRejectsWorkOnlyWhileDraining(t *testing.T) {
+	var drain Drain
+	handler := drain.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", nil))
+		return rr
+	}
+
+	if rr := serve(); rr.Code != http.StatusOK {
+		t.Fatalf("status before drain = %d, want 200", rr.Code)
+	}
+	drain.Start()
+	if rr := serve(); rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), `"code":"draining"`) {
+		t.Fatalf("while draining = %d %s, want 503 draining envelope", rr.Code, rr.Body.String())
+	}
+	drain.Stop()
+	if rr := serve(); rr.Code != http.StatusOK {
+		t.Fatalf("status after resume = %d, want 200", rr.Code)
+	}
+}
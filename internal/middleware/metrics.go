@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"gexec-sandbox/internal/metrics"
+	"gexec-sandbox/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Instrument wraps each request to endpoint in a server span, records its
+// count and latency labelled with the response status, and writes one access
+// log line.
+func Instrument(endpoint string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		ctx, span := tracing.StartServer(r.Context(), r.Header, r.Method+" "+endpoint,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", endpoint),
+			attribute.String("url.path", r.URL.Path),
+		)
+		r = r.WithContext(ctx)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		elapsed := time.Since(started)
+
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+		span.End()
+
+		metrics.ObserveHTTPRequest(endpoint, recorder.status, elapsed)
+		slog.InfoContext(ctx, "http request",
+			"method", r.Method, "path", r.URL.Path, "status", recorder.status, "duration_ms", elapsed.Milliseconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
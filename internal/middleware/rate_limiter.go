@@ -1,57 +1,208 @@
 package middleware
 
 import (
+	"container/list"
+	"fmt"
+	"math"
 	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"gexec-sandbox/internal/api"
 	"golang.org/x/time/rate"
 )
 
-type IPRateLimiter struct {
-	ips map[string]*rate.Limiter
-	mu  sync.Mutex
-	r   rate.Limit
-	b   int
+const (
+	defaultIdleTTL    = 10 * time.Minute
+	defaultMaxEntries = 10000
+)
+
+// Options configures a RateLimiter. Zero IdleTTL and MaxEntries use the
+// defaults.
+type Options struct {
+	Rate  rate.Limit
+	Burst int
+	// TrustedProxies are the peers whose X-Forwarded-For header is believed.
+	TrustedProxies []netip.Prefix
+	// IdleTTL evicts a client's limiter after this long without requests.
+	IdleTTL time.Duration
+	// MaxEntries bounds the number of tracked clients; the least recently
+	// seen client is evicted first.
+	MaxEntries int
+	// Identify names the API key a request presents, or returns "" for
+	// none. The limiter runs before authentication, so it must not trust
+	// an unknown key. Nil limits every request by address.
+	Identify func(*http.Request) string
+}
+
+// RateLimiter keeps a token bucket per client. Clients are identified by API
+// key when the request presents a known one and by IP address otherwise.
+type RateLimiter struct {
+	opts Options
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type limiterEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewRateLimiter(opts Options) *RateLimiter {
+	if opts.IdleTTL <= 0 {
+		opts.IdleTTL = defaultIdleTTL
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaultMaxEntries
+	}
+	return &RateLimiter{
+		opts:    opts,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// NewIPRateLimiter returns a limiter that trusts no proxies.
+func NewIPRateLimiter(r rate.Limit, b int) *RateLimiter {
+	return NewRateLimiter(Options{Rate: r, Burst: b})
 }
 
-func NewIPRateLimiter(r rate.Limit, b int) *IPRateLimiter {
-	return &IPRateLimiter{
-		ips: make(map[string]*rate.Limiter),
-		r:   r,
-		b:   b,
+// ParseTrustedProxies accepts IP addresses and CIDR prefixes.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is not an IP or CIDR", value)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
 	}
+	return prefixes, nil
 }
 
-func (i *IPRateLimiter) getLimiter(ip string) *rate.Limiter {
-	i.mu.Lock()
-	defer i.mu.Unlock()
+func (l *RateLimiter) getLimiter(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	limiter, exists := i.ips[ip]
-	if !exists {
-		limiter = rate.NewLimiter(i.r, i.b)
-		i.ips[ip] = limiter
+	l.evictLocked(now)
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*limiterEntry)
+		entry.lastSeen = now
+		l.lru.MoveToFront(element)
+		return entry.limiter
 	}
 
-	return limiter
+	entry := &limiterEntry{key: key, limiter: rate.NewLimiter(l.opts.Rate, l.opts.Burst), lastSeen: now}
+	l.entries[key] = l.lru.PushFront(entry)
+	if l.lru.Len() > l.opts.MaxEntries {
+		l.removeLocked(l.lru.Back())
+	}
+	return entry.limiter
+}
+
+// evictLocked drops idle clients from the back of the LRU list.
+func (l *RateLimiter) evictLocked(now time.Time) {
+	for back := l.lru.Back(); back != nil; back = l.lru.Back() {
+		if now.Sub(back.Value.(*limiterEntry).lastSeen) < l.opts.IdleTTL {
+			return
+		}
+		l.removeLocked(back)
+	}
+}
+
+func (l *RateLimiter) removeLocked(element *list.Element) {
+	l.lru.Remove(element)
+	delete(l.entries, element.Value.(*limiterEntry).key)
+}
+
+// Len reports the number of tracked clients.
+func (l *RateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
 }
 
-func (i *IPRateLimiter) getIP(r *http.Request) string {
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+func (l *RateLimiter) clientKey(r *http.Request) string {
+	var keyID string
+	if l.opts.Identify != nil {
+		keyID = l.opts.Identify(r)
+	}
+	return l.client(keyID, r.RemoteAddr, r.Header.Values("X-Forwarded-For"))
+}
+
+func (l *RateLimiter) client(keyID, remoteAddr string, forwardedFor []string) string {
+	if keyID != "" {
+		return "key:" + keyID
+	}
+	return "ip:" + l.clientIP(remoteAddr, forwardedFor)
+}
+
+// clientIP returns the peer address, or when the peer is a trusted proxy, the
+// right-most X-Forwarded-For entry that is not itself a trusted proxy.
+func (l *RateLimiter) clientIP(remoteAddr string, forwardedFor []string) string {
+	ip, _, err := net.SplitHostPort(remoteAddr)
 	if err != nil {
-		return r.RemoteAddr
+		ip = remoteAddr
+	}
+	if !l.trusted(ip) {
+		return ip
 	}
 
+	var hops []string
+	for _, header := range forwardedFor {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !l.trusted(hops[i]) {
+			return hops[i]
+		}
+		ip = hops[i]
+	}
 	return ip
 }
 
-func (i *IPRateLimiter) Middleware(next http.Handler) http.Handler {
+func (l *RateLimiter) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range l.opts.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := i.getIP(r)
-		limiter := i.getLimiter(ip)
+		now := l.now()
+		limiter := l.getLimiter(l.clientKey(r), now)
+		allowed := limiter.AllowN(now, 1)
+		l.writeHeaders(w, limiter.TokensAt(now))
 
-		if !limiter.Allow() {
-			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		if !allowed {
+			retryAfter := l.secondsUntil(1, limiter.TokensAt(now))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			api.WriteError(w, http.StatusTooManyRequests, api.ErrorCodeRateLimited, "too many requests")
 			return
 		}
 
@@ -59,6 +210,36 @@ func (i *IPRateLimiter) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// AllowN takes n tokens for a caller identified by API key ID, or by address
+// when keyID is empty, for transports without an http.Request. When the
+// bucket holds fewer it reports how many seconds until it holds n; n above
+// the burst is never allowed.
+func (l *RateLimiter) AllowN(keyID, remoteAddr string, forwardedFor []string, n int) (retryAfter int, ok bool) {
+	now := l.now()
+	limiter := l.getLimiter(l.client(keyID, remoteAddr, forwardedFor), now)
+	if limiter.AllowN(now, n) {
+		return 0, true
+	}
+	return l.secondsUntil(float64(min(n, l.opts.Burst)), limiter.TokensAt(now)), false
+}
+
+// writeHeaders sets the IETF draft RateLimit-* headers: the bucket size, the
+// whole tokens left and the seconds until the bucket is full again.
+func (l *RateLimiter) writeHeaders(w http.ResponseWriter, tokens float64) {
+	window := int(math.Ceil(float64(l.opts.Burst) / float64(l.opts.Rate)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", l.opts.Burst, window))
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(l.opts.Burst))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(max(0, int(math.Floor(tokens)))))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(l.secondsUntil(float64(l.opts.Burst), tokens)))
+}
+
+func (l *RateLimiter) secondsUntil(target float64, tokens float64) int {
+	if tokens >= target || l.opts.Rate <= 0 {
+		return 0
+	}
+	return int(math.Ceil((target - tokens) / float64(l.opts.Rate)))
+}
+
 func RateLimitMiddleware(r rate.Limit, b int) func(http.Handler) http.Handler {
 	limiter := NewIPRateLimiter(r, b)
 	return limiter.Middleware
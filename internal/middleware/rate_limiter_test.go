@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"gexec-sandbox/internal/auth"
 	"golang.org/x/time/rate"
 )
 
@@ -44,3 +45,111 @@ func TestIPRateLimiterIgnoresForwardedFor(t *testing.T) {
 		t.Fatalf("handler called %d times, want 1", calls)
 	}
 }
+
+func TestRateLimiterUsesForwardedForBehindTrustedProxy(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.7"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+	limiter := NewRateLimiter(Options{Rate: rate.Every(time.Minute), Burst: 1, TrustedProxies: proxies})
+
+	tests := []struct {
+		keyID  string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{remote: "10.0.0.5:80", xff: []string{"198.51.100.1"}, want: "ip:198.51.100.1"},
+		{remote: "10.0.0.5:80", xff: []string{"203.0.113.9, 198.51.100.1", "192.0.2.7"}, want: "ip:198.51.100.1"},
+		{remote: "10.0.0.5:80", xff: []string{"10.0.0.9"}, want: "ip:10.0.0.9"},
+		{remote: "10.0.0.5:80", want: "ip:10.0.0.5"},
+		{remote: "203.0.113.10:80", xff: []string{"198.51.100.1"}, want: "ip:203.0.113.10"},
+		{remote: "10.0.0.5", want: "ip:10.0.0.5"},
+		{keyID: "ci", remote: "10.0.0.5:80", xff: []string{"198.51.100.1"}, want: "key:ci"},
+	}
+	for _, tt := range tests {
+		if got := limiter.client(tt.keyID, tt.remote, tt.xff); got != tt.want {
+			t.Fatalf("client(%q, %s, %v) = %q, want %q", tt.keyID, tt.remote, tt.xff, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatal("ParseTrustedProxies() error = nil, want invalid entry rejected")
+	}
+}
+
+func TestRateLimiterKeysByKnownAPIKeyBeforeAuthentication(t *testing.T) {
+	keys, err := auth.NewKeyring([]auth.Key{{ID: "ci", Hash: auth.HashKey("secret"), Scopes: []string{auth.ScopeExecute}}})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	limiter := NewRateLimiter(Options{Rate: rate.Every(time.Minute), Burst: 1, Identify: keys.IdentifyRequest})
+	handler := limiter.Middleware(keys.Require(auth.ScopeExecute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	serve := func(remote, key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/execute", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-API-Key", key)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	codes := []int{serve("198.51.100.1:1", "secret"), serve("198.51.100.2:1", "secret")}
+	if codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("codes = %v, want the key's bucket shared across addresses", codes)
+	}
+
+	// Unknown keys are limited by address before they reach authentication,
+	// so guessing keys cannot dodge the limit.
+	codes = []int{serve("198.51.100.3:1", "guess-1"), serve("198.51.100.3:1", "guess-2")}
+	if codes[0] != http.StatusUnauthorized || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("codes = %v, want the second unknown key from the address limited", codes)
+	}
+}
+
+func TestRateLimiterSetsRateLimitAndRetryAfterHeaders(t *testing.T) {
+	limiter := NewRateLimiter(Options{Rate: rate.Limit(10.0 / 60), Burst: 2})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var last *httptest.ResponseRecorder
+	for range 3 {
+		last = httptest.NewRecorder()
+		handler.ServeHTTP(last, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if last.Code != http.StatusTooManyRequests {
+		t.Fatalf("third status = %d, want 429", last.Code)
+	}
+	want := map[string]string{
+		"RateLimit-Policy":    "2;w=12",
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "12",
+		"Retry-After":         "6",
+	}
+	for header, value := range want {
+		if got := last.Header().Get(header); got != value {
+			t.Fatalf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestRateLimiterEvictsIdleAndLeastRecentClients(t *testing.T) {
+	limiter := NewRateLimiter(Options{Rate: rate.Every(time.Second), Burst: 1, IdleTTL: time.Minute, MaxEntries: 2})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	limiter.getLimiter("a", now)
+	limiter.getLimiter("b", now.Add(10*time.Second))
+	limiter.getLimiter("a", now.Add(20*time.Second))
+	limiter.getLimiter("c", now.Add(30*time.Second))
+	if _, ok := limiter.entries["b"]; ok || limiter.Len() != 2 {
+		t.Fatalf("entries = %v, want least recently seen client b evicted", limiter.entries)
+	}
+
+	limiter.getLimiter("d", now.Add(85*time.Second))
+	if _, ok := limiter.entries["a"]; ok || limiter.Len() != 2 {
+		t.Fatalf("entries = %v, want idle client a expired", limiter.entries)
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gexec-sandbox/internal/logging"
+)
+
+func TestRequestIDReusesValidClientIDAndReplacesOthers(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		header string
+		reused bool
+	}{
+		{header: "client-abc.123", reused: true},
+		{header: "", reused: false},
+		{header: "bad id\nwith newline", reused: false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set(RequestIDHeader, tc.header)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if seen == "" || rr.Header().Get(RequestIDHeader) != seen {
+			t.Fatalf("header %q: context ID %q, response ID %q", tc.header, seen, rr.Header().Get(RequestIDHeader))
+		}
+		if (seen == tc.header) != tc.reused {
+			t.Fatalf("header %q: got ID %q, reused = %v", tc.header, seen, tc.reused)
+		}
+	}
+}
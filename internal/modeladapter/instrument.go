@@ -0,0 +1,45 @@
+package modeladapter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gexec-sandbox/internal/metrics"
+	"gexec-sandbox/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Instrument wraps every Generate call under modelID in a span, records its
+// latency and token usage, and logs it with the caller's context.
+func Instrument(modelID string, adapter Adapter) Adapter {
+	return instrumentedAdapter{modelID: modelID, Adapter: adapter}
+}
+
+type instrumentedAdapter struct {
+	Adapter
+	modelID string
+}
+
+func (a instrumentedAdapter) Generate(ctx context.Context, req ModelRequest) (ModelResponse, error) {
+	ctx, span := tracing.Start(ctx, "model.generate", attribute.String("model.id", a.modelID))
+	started := time.Now()
+	resp, err := a.Adapter.Generate(ctx, req)
+	elapsed := time.Since(started)
+	span.SetAttributes(
+		attribute.Int("model.input_tokens", resp.Usage.InputTokens),
+		attribute.Int("model.output_tokens", resp.Usage.OutputTokens),
+	)
+	tracing.End(span, err)
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+		slog.WarnContext(ctx, "model generation failed", "model", a.modelID, "duration_ms", elapsed.Milliseconds(), "error", err)
+	} else {
+		slog.DebugContext(ctx, "model generation finished", "model", a.modelID, "duration_ms", elapsed.Milliseconds(),
+			"input_tokens", resp.Usage.InputTokens, "output_tokens", resp.Usage.OutputTokens)
+	}
+	metrics.ObserveModelGeneration(a.modelID, status, elapsed, resp.Usage.InputTokens, resp.Usage.OutputTokens)
+	return resp, err
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 
 	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/envconfig"
 )
 
 type ollamaAdapter struct {
@@ -103,8 +104,9 @@ func validateOllamaConfig(cfg Config) error {
 }
 
 func newOllamaClient(baseURL string) (*api.Client, error) {
+	httpClient := &http.Client{Transport: requestIDTransport{base: http.DefaultTransport}}
 	if baseURL == "" {
-		return api.ClientFromEnvironment()
+		return api.NewClient(envconfig.Host(), httpClient), nil
 	}
 
 	parsedURL, err := url.Parse(baseURL)
@@ -127,7 +129,7 @@ func newOllamaClient(baseURL string) (*api.Client, error) {
 		return nil, fmt.Errorf("ollama base URL must not contain query parameters")
 	}
 
-	return api.NewClient(parsedURL, http.DefaultClient), nil
+	return api.NewClient(parsedURL, httpClient), nil
 }
 
 func mapToolCalls(toolCalls []api.ToolCall) []ToolCall {
@@ -12,6 +12,8 @@ import (
 	"os"
 	"path"
 	"strconv"
+
+	"gexec-sandbox/internal/logging"
 )
 
 type openAICompatibleAdapter struct {
@@ -259,6 +261,9 @@ func (a *openAICompatibleAdapter) newJSONRequest(ctx context.Context, method str
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
 
 	apiKey, err := requiredAPIKey(a.apiKeyEnv)
 	if err != nil {
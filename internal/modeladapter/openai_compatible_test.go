@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+
+	"gexec-sandbox/internal/logging"
 )
 
 func TestOpenAICompatibleAdapterBuildsChatCompletionsRequest(t *testing.T) {
@@ -43,6 +45,35 @@ func TestOpenAICompatibleAdapterBuildsChatCompletionsRequest(t *testing.T) {
 	}
 }
 
+func TestOpenAICompatibleAdapterForwardsRequestID(t *testing.T) {
+	adapter, err := NewOpenAICompatibleAdapter(Config{
+		ID:           "local",
+		ProviderKind: "openai_compatible",
+		BaseURL:      "http://openai.test/v1",
+		ModelName:    "local-model",
+	})
+	if err != nil {
+		t.Fatalf("NewOpenAICompatibleAdapter() error = %v", err)
+	}
+
+	typedAdapter := adapter.(*openAICompatibleAdapter)
+	typedAdapter.httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if got := r.Header.Get(RequestIDHeader); got != "req-42" {
+			t.Fatalf("%s = %q, want req-42", RequestIDHeader, got)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"answer"}}]}`)),
+		}, nil
+	})}
+
+	ctx := logging.WithRequestID(context.Background(), "req-42")
+	if _, err := adapter.Generate(ctx, ModelRequest{Messages: []Message{{Role: "user", Content: "hi"}}}); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+}
+
 func TestOpenAICompatibleAdapterUsesBearerAuthAndParams(t *testing.T) {
 	t.Setenv("OPENAI_COMPATIBLE_API_KEY", "secret-key")
 
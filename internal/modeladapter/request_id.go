@@ -0,0 +1,23 @@
+package modeladapter
+
+import (
+	"net/http"
+
+	"gexec-sandbox/internal/logging"
+)
+
+// RequestIDHeader forwards the caller's request ID to model providers so
+// their logs can be matched with ours.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+func (t requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if requestID := logging.RequestID(req.Context()); requestID != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -123,6 +123,9 @@ type Config struct {
 	RequestMapping  RequestMapping
 	ResponseMapping ResponseMapping
 	Capabilities    Capabilities
+	// Concurrency caps how many benchmark runs use the model at once. Zero
+	// leaves the benchmark-wide limit in charge.
+	Concurrency int
 }
 
 func (c Config) Validate() error {
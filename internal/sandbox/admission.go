@@ -0,0 +1,72 @@
+package sandbox
+
+import (
+	"context"
+	"sync"
+
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/metrics"
+	"gexec-sandbox/internal/tracing"
+)
+
+// admit waits for a slot per container under cfg's concurrency limit.
+func admit(ctx context.Context, cfg config.Config, containers int) (func(), error) {
+	ctx, span := tracing.Start(ctx, "sandbox.admission")
+	release, err := executionSlots.acquire(ctx, cfg.MaxConcurrentExecutions, containers)
+	tracing.End(span, err)
+	return release, err
+}
+
+// executionSlots bounds how many sandbox containers run at once. Executions
+// beyond the limit wait in line and are counted as queue depth.
+var executionSlots = &admission{freed: make(chan struct{})}
+
+type admission struct {
+	mu      sync.Mutex
+	active  int
+	waiting int
+	freed   chan struct{}
+}
+
+// acquire waits until n more slots fit under limit. A request for more slots
+// than the limit waits until none are taken so it cannot wait forever. A
+// limit of zero or less admits immediately. The returned release func is safe
+// to call more than once.
+func (a *admission) acquire(ctx context.Context, limit int, n int) (func(), error) {
+	a.mu.Lock()
+	for limit > 0 && a.active > 0 && a.active+n > limit {
+		freed := a.freed
+		a.setWaiting(a.waiting + 1)
+		a.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			a.mu.Lock()
+			a.setWaiting(a.waiting - 1)
+			a.mu.Unlock()
+			return nil, ctx.Err()
+		}
+
+		a.mu.Lock()
+		a.setWaiting(a.waiting - 1)
+	}
+	a.active += n
+	a.mu.Unlock()
+
+	var once sync.Once
+	return func() { once.Do(func() { a.release(n) }) }, nil
+}
+
+func (a *admission) release(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.active -= n
+	close(a.freed)
+	a.freed = make(chan struct{})
+}
+
+func (a *admission) setWaiting(n int) {
+	a.waiting = n
+	metrics.SetQueueDepth(n)
+}
@@ -0,0 +1,115 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdmissionQueuesBeyondLimit(t *testing.T) {
+	slots := &admission{freed: make(chan struct{})}
+
+	release, err := slots.acquire(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	admitted := make(chan struct{})
+	go func() {
+		second, err := slots.acquire(context.Background(), 1, 1)
+		if err != nil {
+			t.Errorf("queued acquire() error = %v", err)
+			return
+		}
+		second()
+		close(admitted)
+	}()
+
+	waitFor(t, func() bool {
+		slots.mu.Lock()
+		defer slots.mu.Unlock()
+		return slots.waiting == 1
+	})
+	select {
+	case <-admitted:
+		t.Fatal("second execution admitted while the only slot was held")
+	default:
+	}
+
+	release()
+	release()
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("second execution was not admitted after release")
+	}
+	if slots.active != 0 || slots.waiting != 0 {
+		t.Fatalf("active = %d waiting = %d, want 0 and 0", slots.active, slots.waiting)
+	}
+}
+
+func TestAdmissionHonoursCancellationWhileQueued(t *testing.T) {
+	slots := &admission{freed: make(chan struct{})}
+	release, _ := slots.acquire(context.Background(), 1, 1)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := slots.acquire(ctx, 1, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() error = %v, want deadline exceeded", err)
+	}
+	if slots.waiting != 0 {
+		t.Fatalf("waiting = %d, want 0 after cancellation", slots.waiting)
+	}
+}
+
+func TestAdmissionWithoutLimitAdmitsImmediately(t *testing.T) {
+	slots := &admission{freed: make(chan struct{})}
+	for range 3 {
+		if _, err := slots.acquire(context.Background(), 0, 1); err != nil {
+			t.Fatalf("acquire() error = %v", err)
+		}
+	}
+	if slots.active != 3 {
+		t.Fatalf("active = %d, want 3", slots.active)
+	}
+}
+
+func TestAdmissionCountsEveryContainer(t *testing.T) {
+	slots := &admission{freed: make(chan struct{})}
+	single, _ := slots.acquire(context.Background(), 2, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := slots.acquire(ctx, 2, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() of two slots with one free error = %v, want deadline exceeded", err)
+	}
+
+	single()
+	pair, err := slots.acquire(context.Background(), 2, 2)
+	if err != nil {
+		t.Fatalf("acquire() of two free slots error = %v", err)
+	}
+	pair()
+
+	wide, err := slots.acquire(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("acquire() of more slots than the limit with none taken error = %v", err)
+	}
+	wide()
+	if slots.active != 0 {
+		t.Fatalf("active = %d, want 0 after every release", slots.active)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,144 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+	"github.com/docker/docker/client"
+)
+
+const (
+	auditLogFile = ".audit-log"
+	auditBinary  = ".audit-bin"
+)
+
+// auditSyscalls is the strace filter: every network call, program execution
+// and file open. Everything else is left untraced to keep the log small.
+const auditSyscalls = "trace=%network,execve,execveat,open,openat"
+
+// sensitivePaths are path prefixes whose opening is reported even when it
+// fails.
+var sensitivePaths = []string{
+	"/etc/shadow",
+	"/etc/sudoers",
+	"/root/.ssh",
+	"/proc/self/environ",
+	"/proc/1/",
+	"/var/run/docker.sock",
+	"/run/docker.sock",
+	"/run/secrets",
+}
+
+var (
+	straceCallPattern = regexp.MustCompile(`^(?:\[pid\s+)?\d*\]?\s*(\w+)\((.*?)(?:\)\s+=\s+(-?\d+|\?)(?:\s+(E[A-Z0-9]+))?.*| <unfinished \.\.\.>)$`)
+	quotedArgPattern  = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+)
+
+// getAuditCommand wraps the program in strace. Go sources are compiled
+// first so the toolchain's own process spawns stay out of the trace.
+func getAuditCommand(language string, filePath string, cfg config.Config) (string, []string) {
+	runCmd := getCommand(language, filePath, cfg)
+	prepare := ""
+	if strings.HasPrefix(strings.ToLower(language), "go") {
+		binary := path.Join(workDir, auditBinary)
+		prepare = fmt.Sprintf("go build -o %s %s", binary, filePath)
+		runCmd = []string{binary}
+	}
+	strace := []string{"strace", "-f", "-qq", "-s", "256", "-e", "signal=none", "-e", auditSyscalls, "-o", path.Join(workDir, auditLogFile), "--"}
+	return prepare, append(strace, runCmd...)
+}
+
+// collectAudit reads the strace log. strace records at least the program's
+// own execve, so a missing or empty log means the audit did not run and is
+// an error rather than a clean summary.
+func collectAudit(ctx context.Context, cli *client.Client, containerID string) (*api.AuditSummary, error) {
+	log, err := readContainerFile(ctx, cli, containerID, path.Join(workDir, auditLogFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if strings.TrimSpace(log) == "" {
+		return nil, errors.New("audit log is missing; the image may not have strace installed")
+	}
+	return parseAuditLog(log), nil
+}
+
+// parseAuditLog summarizes an strace log. The first execve is the audited
+// program itself; later ones are processes it spawned.
+func parseAuditLog(log string) *api.AuditSummary {
+	summary := &api.AuditSummary{}
+	seenExec := false
+	for _, line := range strings.Split(log, "\n") {
+		match := straceCallPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		syscall, args, result, errno := match[1], match[2], match[3], match[4]
+
+		event := api.AuditEvent{Syscall: syscall, Blocked: strings.HasPrefix(result, "-")}
+		switch syscall {
+		case "execve", "execveat":
+			if !seenExec {
+				seenExec = true
+				continue
+			}
+			if errno == "ENOENT" {
+				// PATH lookups try each directory in turn.
+				continue
+			}
+			event.Category = api.AuditProcess
+			event.Detail = firstQuotedArg(args)
+			summary.ProcessSpawns++
+		case "open", "openat":
+			target := firstQuotedArg(args)
+			if !isSensitivePath(target) {
+				continue
+			}
+			event.Category = api.AuditSensitivePath
+			event.Detail = target
+			summary.SensitivePaths++
+		default:
+			// Local sockets are routine (name service lookups, logging); only
+			// internet families count as network attempts.
+			if !strings.Contains(args, "AF_INET") {
+				continue
+			}
+			event.Category = api.AuditNetwork
+			event.Detail = args
+			summary.NetworkAttempts++
+		}
+		if event.Blocked {
+			summary.Blocked++
+			if errno != "" {
+				event.Detail += " (" + errno + ")"
+			}
+		}
+		summary.Events = append(summary.Events, event)
+	}
+	return summary
+}
+
+func firstQuotedArg(args string) string {
+	match := quotedArgPattern.FindStringSubmatch(args)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func isSensitivePath(target string) bool {
+	if target == "" {
+		return false
+	}
+	for _, prefix := range sensitivePaths {
+		if strings.HasPrefix(target, prefix) {
+			return true
+		}
+	}
+	return strings.Contains(target, "/.ssh/") || strings.Contains(target, "/.aws/")
+}
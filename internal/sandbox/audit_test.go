@@ -0,0 +1,60 @@
+package sandbox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+)
+
+const straceLog = `41 execve("/usr/local/bin/python", ["python", "/tmp/main.py"], 0x7ffd /* 8 vars */) = 0
+41 openat(AT_FDCWD, "/usr/local/lib/python3.9/os.py", O_RDONLY|O_CLOEXEC) = 3
+41 socket(AF_UNIX, SOCK_STREAM|SOCK_CLOEXEC|SOCK_NONBLOCK, 0) = 3
+41 connect(3, {sa_family=AF_UNIX, sun_path="/var/run/nscd/socket"}, 110) = -1 ENOENT (No such file or directory)
+41 socket(AF_INET, SOCK_STREAM|SOCK_CLOEXEC, IPPROTO_TCP) = 4
+41 connect(4, {sa_family=AF_INET, sin_port=htons(80), sin_addr=inet_addr("93.184.216.34")}, 16) = -1 ENETUNREACH (Network is unreachable)
+41 openat(AT_FDCWD, "/etc/shadow", O_RDONLY|O_CLOEXEC) = -1 EACCES (Permission denied)
+42 execve("/usr/local/sbin/sh", ["/bin/sh", "-c", "id"], 0x55 /* 8 vars */) = -1 ENOENT (No such file or directory)
+42 execve("/bin/sh", ["/bin/sh", "-c", "id"], 0x55 /* 8 vars */) = 0
+43 connect(5, {sa_family=AF_INET6, sin6_port=htons(443)} <unfinished ...>
+43 <... connect resumed>) = -1 ENETUNREACH (Network is unreachable)
+`
+
+func TestParseAuditLogSummarizesNotableSyscalls(t *testing.T) {
+	summary := parseAuditLog(straceLog)
+
+	if summary.NetworkAttempts != 3 || summary.ProcessSpawns != 1 || summary.SensitivePaths != 1 || summary.Blocked != 2 {
+		t.Fatalf("summary = %+v, want 3 network, 1 spawn, 1 sensitive path, 2 blocked", summary)
+	}
+
+	want := []api.AuditEvent{
+		{Category: api.AuditNetwork, Syscall: "socket", Detail: "AF_INET, SOCK_STREAM|SOCK_CLOEXEC, IPPROTO_TCP"},
+		{Category: api.AuditNetwork, Syscall: "connect", Detail: `4, {sa_family=AF_INET, sin_port=htons(80), sin_addr=inet_addr("93.184.216.34")}, 16 (ENETUNREACH)`, Blocked: true},
+		{Category: api.AuditSensitivePath, Syscall: "openat", Detail: "/etc/shadow (EACCES)", Blocked: true},
+		{Category: api.AuditProcess, Syscall: "execve", Detail: "/bin/sh"},
+		{Category: api.AuditNetwork, Syscall: "connect", Detail: "5, {sa_family=AF_INET6, sin6_port=htons(443)}"},
+	}
+	if !reflect.DeepEqual(summary.Events, want) {
+		t.Fatalf("Events = %#v, want %#v", summary.Events, want)
+	}
+}
+
+func TestBuildShellCommandWrapsAuditedProgramInStrace(t *testing.T) {
+	req := api.ExecutionRequest{Language: "go", SourceCode: "package main", Audit: true}
+
+	cmd, err := buildShellCommand(req, "/tmp/main.go", config.Config{})
+	if err != nil {
+		t.Fatalf("buildShellCommand() error = %v", err)
+	}
+	if !strings.Contains(cmd, "&& go build -o /tmp/.audit-bin /tmp/main.go && strace -f") ||
+		!strings.HasSuffix(cmd, "-o /tmp/.audit-log -- /tmp/.audit-bin") {
+		t.Fatalf("command = %q, want compiled binary run under strace", cmd)
+	}
+
+	req.Mode = api.ExecutionModeTest
+	if _, err := buildShellCommand(req, "/tmp/main.go", config.Config{}); err == nil {
+		t.Fatal("buildShellCommand(audit test mode) error = nil, want unsupported")
+	}
+}
@@ -1,38 +1,62 @@
 package sandbox
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"gexec-sandbox/internal/api"
 	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/logging"
+	"gexec-sandbox/internal/metrics"
+	"gexec-sandbox/internal/tracing"
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const (
+	workDir        = "/tmp"
+	testReportFile = ".test-report"
+)
+
+// RequestIDLabel is the container label holding the ID of the request that
+// started the execution, so containers can be traced back to log lines.
+const RequestIDLabel = "gexec-sandbox.request-id"
+
 var (
 	containers      = make(map[string]*client.Client)
 	containersMutex sync.RWMutex
 )
 
-func registerContainer(containerID string, cli *client.Client) {
+// registerContainer records a created container for cleanup and attaches it
+// to the execution running under ctx.
+func registerContainer(ctx context.Context, containerID string, cli *client.Client) {
 	containersMutex.Lock()
-	defer containersMutex.Unlock()
 	containers[containerID] = cli
+	metrics.SetActiveContainers(len(containers))
+	containersMutex.Unlock()
+	executions.attach(ctx, containerID)
 }
 
 func unregisterContainer(containerID string) {
 	containersMutex.Lock()
-	defer containersMutex.Unlock()
 	delete(containers, containerID)
+	metrics.SetActiveContainers(len(containers))
+	containersMutex.Unlock()
+	executions.detach(containerID)
 }
 
 func CleanupAllContainers() {
@@ -43,7 +67,7 @@ func CleanupAllContainers() {
 	defer cancel()
 
 	for containerID, cli := range containers {
-		log.Printf("Cleaning up container: %s", containerID)
+		slog.Info("cleaning up container", "container_id", containerID)
 		cli.ContainerKill(ctx, containerID, "SIGKILL")
 		cli.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true})
 		cli.Close()
@@ -61,6 +85,19 @@ func getCommand(language string, filePath string, cfg config.Config) []string {
 	return []string{language, filePath}
 }
 
+// getTestCommand returns the shell command that runs the language's test
+// runner in the work directory, writing its report to testReportFile.
+func getTestCommand(language string) ([]string, string, error) {
+	lowerLang := strings.ToLower(language)
+	if strings.HasPrefix(lowerLang, "py") {
+		return []string{"python", "-m", "pytest", "-q", "-p", "no:cacheprovider", "--junitxml=" + testReportFile}, api.TestReportJUnitXML, nil
+	}
+	if strings.HasPrefix(lowerLang, "go") {
+		return []string{"{ [ -f go.mod ] || go mod init submission >/dev/null 2>&1; }", "&&", "go", "test", "-json", ".", ">", testReportFile}, api.TestReportGoJSON, nil
+	}
+	return nil, "", fmt.Errorf("test mode is not supported for language: %s", language)
+}
+
 func getExtension(language string, cfg config.Config) string {
 	lowerLang := strings.ToLower(language)
 	if strings.HasPrefix(lowerLang, "py") {
@@ -72,15 +109,101 @@ func getExtension(language string, cfg config.Config) string {
 	return ".txt"
 }
 
+// buildShellCommand writes the source and any companion files into the work
+// directory, then runs the program with its arguments and optional stdin.
+func buildShellCommand(req api.ExecutionRequest, filePath string, cfg config.Config) (string, error) {
+	steps := []string{fmt.Sprintf("printf %%s %s > %s", shellQuote(req.SourceCode), shellQuote(filePath))}
+
+	names := make([]string, 0, len(req.Files))
+	for name := range req.Files {
+		if err := ValidateFileName(name); err != nil {
+			return "", err
+		}
+		if path.Join(workDir, name) == filePath {
+			return "", fmt.Errorf("file %q would overwrite the submitted source", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		steps = append(steps, fmt.Sprintf("printf %%s %s > %s", shellQuote(req.Files[name]), shellQuote(path.Join(workDir, name))))
+	}
+
+	execCmd := getCommand(req.Language, filePath, cfg)
+	if req.Mode == api.ExecutionModeTest {
+		if req.Audit {
+			return "", errors.New("audit is not supported in test mode")
+		}
+		testCmd, _, err := getTestCommand(req.Language)
+		if err != nil {
+			return "", err
+		}
+		execCmd = testCmd
+	}
+	if req.Audit {
+		prepare, auditCmd := getAuditCommand(req.Language, filePath, cfg)
+		if prepare != "" {
+			steps = append(steps, prepare)
+		}
+		execCmd = auditCmd
+	}
+	for _, arg := range req.Args {
+		execCmd = append(execCmd, shellQuote(arg))
+	}
+	runCmd := strings.Join(execCmd, " ")
+	if req.Stdin != "" {
+		runCmd = fmt.Sprintf("printf %%s %s | %s", shellQuote(req.Stdin), runCmd)
+	}
+	steps = append(steps, runCmd)
+
+	return strings.Join(steps, " && "), nil
+}
+
+// ValidateFileName rejects companion file names that are not plain names
+// inside the work directory.
+func ValidateFileName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("invalid file name %q: files must be plain names inside the work directory", name)
+	}
+	return nil
+}
+
 func shellQuote(value string) string {
 	return "'" + strings.ReplaceAll(value, "'", "'\\''") + "'"
 }
 
-func readAttachedOutput(reader io.Reader) (string, string, error) {
+// Output stream names passed to an OutputFunc.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputFunc receives a program's output as the container writes it. data is
+// only valid for the duration of the call.
+type OutputFunc func(stream string, data []byte)
+
+type outputWriter struct {
+	stream string
+	sink   OutputFunc
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.sink(w.stream, p)
+	return len(p), nil
+}
+
+// readAttachedOutput demultiplexes a container's attach stream, passing each
+// chunk to sink as well when it is not nil.
+func readAttachedOutput(reader io.Reader, sink OutputFunc) (string, string, error) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 
-	_, err := stdcopy.StdCopy(&stdout, &stderr, reader)
+	var stdoutWriter, stderrWriter io.Writer = &stdout, &stderr
+	if sink != nil {
+		stdoutWriter = io.MultiWriter(&stdout, outputWriter{StreamStdout, sink})
+		stderrWriter = io.MultiWriter(&stderr, outputWriter{StreamStderr, sink})
+	}
+	_, err := stdcopy.StdCopy(stdoutWriter, stderrWriter, reader)
 	if err != nil {
 		return "", "", err
 	}
@@ -88,110 +211,350 @@ func readAttachedOutput(reader io.Reader) (string, string, error) {
 	return stdout.String(), stderr.String(), nil
 }
 
+type attachedOutput struct {
+	stdout string
+	stderr string
+	err    error
+}
+
 func RunCodeInSandbox(ctx context.Context, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	return StreamCodeInSandbox(ctx, req, cfg, nil)
+}
+
+// StreamCodeInSandbox is RunCodeInSandbox with the program's output also
+// passed to sink while it runs. The response still carries the full output.
+func StreamCodeInSandbox(ctx context.Context, req api.ExecutionRequest, cfg config.Config, sink OutputFunc) (resp api.ExecutionResponse, err error) {
+	ctx, span := tracing.Start(ctx, "sandbox.execute", attribute.String("language", req.Language), attribute.String("mode", req.Mode))
+	defer func() { tracing.End(span, err) }()
+
+	if _, ok := cfg.Languages[req.Language]; !ok {
+		err := invalidRequestError{fmt.Errorf("unsupported language: %s", req.Language)}
+		return api.ExecutionResponse{Error: err.Error()}, err
+	}
+
+	ctx, exec, done := executions.track(ctx, api.ExecutionKindSingle, req.Language)
+	defer done()
+	release, err := admit(ctx, cfg, 1)
+	if err != nil {
+		return api.ExecutionResponse{}, killed(ctx, err)
+	}
+	defer release()
+	executions.start(exec)
+
+	started := time.Now()
+	resp, err = runCodeInSandbox(ctx, req, cfg, sink)
+	err = killed(ctx, err)
+	observeExecution(ctx, req.Language, resp, err, time.Since(started))
+	return resp, err
+}
+
+func runCodeInSandbox(ctx context.Context, req api.ExecutionRequest, cfg config.Config, sink OutputFunc) (api.ExecutionResponse, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return api.ExecutionResponse{}, fmt.Errorf("failed to create docker client: %w", err)
 	}
 	defer cli.Close()
 
-	imageName, ok := cfg.Languages[req.Language]
-	if !ok {
-		return api.ExecutionResponse{Error: fmt.Sprintf("unsupported language: %s", req.Language)}, fmt.Errorf("unsupported language: %s", req.Language)
-	}
-	extension := getExtension(req.Language, cfg)
-	filePath := "/tmp/main" + extension
-
 	execCtx, cancel := context.WithTimeout(ctx, time.Duration(req.TimeoutMS)*time.Millisecond)
 	defer cancel()
 
-	pull, err := cli.ImagePull(execCtx, imageName, image.PullOptions{})
+	containerID, err := createContainer(execCtx, cli, req, cfg, false)
 	if err != nil {
-		if execCtx.Err() != nil {
-			return api.ExecutionResponse{}, execCtx.Err()
-		}
-		return api.ExecutionResponse{}, fmt.Errorf("failed to pull image: %w", err)
-	}
-	defer pull.Close()
-
-	if _, err := io.Copy(io.Discard, pull); err != nil {
-		if execCtx.Err() != nil {
-			return api.ExecutionResponse{}, execCtx.Err()
+		var inputErr invalidRequestError
+		if errors.As(err, &inputErr) {
+			return api.ExecutionResponse{Error: err.Error()}, err
 		}
-		return api.ExecutionResponse{}, fmt.Errorf("failed to read image pull output: %w", err)
-	}
-
-	execCmd := getCommand(req.Language, filePath, cfg)
-	sourceCmd := fmt.Sprintf("printf %%s %s > %s", shellQuote(req.SourceCode), shellQuote(filePath))
-	runCmd := strings.Join(execCmd, " ")
-	if req.Stdin != "" {
-		runCmd = fmt.Sprintf("printf %%s %s | %s", shellQuote(req.Stdin), runCmd)
-	}
-	fullCmd := fmt.Sprintf("%s && %s", sourceCmd, runCmd)
-
-	resp, err := cli.ContainerCreate(execCtx, &container.Config{
-		Image:           imageName,
-		Cmd:             []string{"sh", "-c", fullCmd},
-		Tty:             false,
-		AttachStdout:    true,
-		AttachStderr:    true,
-		NetworkDisabled: true,
-	}, &container.HostConfig{
-		Resources: container.Resources{
-			Memory:   int64(cfg.MaxMemoryMB) * 1024 * 1024,
-			CPUQuota: 50000,
-		},
-	}, nil, nil, "")
-	if err != nil {
-		return api.ExecutionResponse{}, fmt.Errorf("failed to create container: %w", err)
+		return api.ExecutionResponse{}, err
 	}
+	defer removeContainer(execCtx, cli, containerID)
 
-	containerID := resp.ID
-	registerContainer(containerID, cli)
-
-	defer func() {
-		cli.ContainerKill(context.Background(), containerID, "SIGKILL")
-		cli.ContainerRemove(context.Background(), containerID, container.RemoveOptions{Force: true})
-		unregisterContainer(containerID)
-	}()
-
-	attachResp, err := cli.ContainerAttach(execCtx, resp.ID, container.AttachOptions{
+	startCtx, startSpan := tracing.Start(execCtx, "sandbox.start")
+	attachResp, err := cli.ContainerAttach(startCtx, containerID, container.AttachOptions{
 		Stream: true,
 		Stdout: true,
 		Stderr: true,
 	})
 	if err != nil {
-		return api.ExecutionResponse{}, fmt.Errorf("failed to attach to container: %w", err)
+		err = fmt.Errorf("failed to attach to container: %w", err)
+		tracing.End(startSpan, err)
+		return api.ExecutionResponse{}, err
 	}
 	defer attachResp.Close()
 
-	if err := cli.ContainerStart(execCtx, resp.ID, container.StartOptions{}); err != nil {
-		return api.ExecutionResponse{}, fmt.Errorf("failed to start container: %w", err)
+	// Output is read while the program runs so it can be streamed and so a
+	// chatty program cannot stall on a full attach buffer.
+	output := make(chan attachedOutput, 1)
+	go func() {
+		stdout, stderr, err := readAttachedOutput(attachResp.Reader, sink)
+		output <- attachedOutput{stdout: stdout, stderr: stderr, err: err}
+	}()
+
+	if err := cli.ContainerStart(startCtx, containerID, container.StartOptions{}); err != nil {
+		err = fmt.Errorf("failed to start container: %w", err)
+		tracing.End(startSpan, err)
+		return api.ExecutionResponse{}, err
 	}
+	startSpan.End()
+	started := time.Now()
 
-	statusCh, errCh := cli.ContainerWait(execCtx, resp.ID, container.WaitConditionNotRunning)
+	_, waitSpan := tracing.Start(execCtx, "sandbox.wait")
+	statusCh, errCh := cli.ContainerWait(execCtx, containerID, container.WaitConditionNotRunning)
 	select {
 	case err := <-errCh:
-		return api.ExecutionResponse{}, fmt.Errorf("error waiting for container: %w", err)
+		err = fmt.Errorf("error waiting for container: %w", err)
+		tracing.End(waitSpan, err)
+		return api.ExecutionResponse{}, err
 	case <-execCtx.Done():
+		tracing.End(waitSpan, execCtx.Err())
 		return api.ExecutionResponse{}, execCtx.Err()
 	case <-statusCh:
 	}
+	duration := time.Since(started)
+	waitSpan.End()
 
-	stdout, stderr, err := readAttachedOutput(attachResp.Reader)
+	collectCtx, collectSpan := tracing.Start(execCtx, "sandbox.collect")
+	var attached attachedOutput
+	select {
+	case attached = <-output:
+	case <-execCtx.Done():
+		tracing.End(collectSpan, execCtx.Err())
+		return api.ExecutionResponse{}, execCtx.Err()
+	}
+	if attached.err != nil {
+		err = fmt.Errorf("failed to read container output: %w", attached.err)
+		tracing.End(collectSpan, err)
+		return api.ExecutionResponse{}, err
+	}
+	response, err := collectResponse(collectCtx, cli, containerID, attached.stdout, attached.stderr, req, cfg)
+	tracing.End(collectSpan, err)
 	if err != nil {
-		return api.ExecutionResponse{}, fmt.Errorf("failed to read container output: %w", err)
+		return api.ExecutionResponse{}, err
 	}
+	response.DurationMS = duration.Milliseconds()
+	return response, nil
+}
 
-	inspect, err := cli.ContainerInspect(execCtx, resp.ID)
+// collectResponse combines a finished container's output with its exit code
+// and any requested test report, audit and file changes.
+func collectResponse(ctx context.Context, cli *client.Client, containerID string, stdout string, stderr string, req api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	inspect, err := cli.ContainerInspect(ctx, containerID)
 	if err != nil {
 		return api.ExecutionResponse{}, fmt.Errorf("failed to inspect container: %w", err)
 	}
 
-	return api.ExecutionResponse{
+	response := api.ExecutionResponse{
 		Stdout:   stdout,
 		Stderr:   stderr,
 		ExitCode: inspect.State.ExitCode,
 		Error:    "",
-	}, nil
+	}
+
+	if req.Mode == api.ExecutionModeTest {
+		_, format, _ := getTestCommand(req.Language)
+		report, err := readContainerFile(ctx, cli, containerID, path.Join(workDir, testReportFile))
+		if err != nil {
+			return api.ExecutionResponse{}, fmt.Errorf("failed to read test report: %w", err)
+		}
+		response.TestReport = report
+		response.TestReportFormat = format
+	}
+
+	if req.Audit {
+		if response.Audit, err = collectAudit(ctx, cli, containerID); err != nil {
+			return api.ExecutionResponse{}, err
+		}
+	}
+
+	if req.CaptureFileChanges {
+		changes, err := collectFileChanges(ctx, cli, containerID, req, cfg)
+		if err != nil {
+			return api.ExecutionResponse{}, err
+		}
+		response.FileChanges = changes
+	}
+
+	return response, nil
+}
+
+// readContainerFile copies a single file out of a stopped container. A missing
+// file yields an empty string so callers can treat it as "no report".
+func readContainerFile(ctx context.Context, cli *client.Client, containerID string, filePath string) (string, error) {
+	reader, _, err := cli.CopyFromContainer(ctx, containerID, filePath)
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer reader.Close()
+
+	return readSingleFileTar(reader)
+}
+
+func readSingleFileTar(reader io.Reader) (string, error) {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		var contents bytes.Buffer
+		if _, err := io.Copy(&contents, archive); err != nil {
+			return "", err
+		}
+		return contents.String(), nil
+	}
+}
+
+// Execution outcomes used as the status label on execution metrics.
+const (
+	executionOK          = "ok"
+	executionNonzeroExit = "nonzero_exit"
+	executionTimeout     = "timeout"
+	executionKilled      = "killed"
+	executionError       = "error"
+)
+
+func observeExecution(ctx context.Context, language string, resp api.ExecutionResponse, err error, elapsed time.Duration) {
+	status := executionStatus(resp, err)
+	metrics.ObserveExecution(language, status, elapsed)
+	slog.DebugContext(ctx, "execution finished", "language", language, "status", status, "duration_ms", elapsed.Milliseconds())
+}
+
+func executionStatus(resp api.ExecutionResponse, err error) string {
+	switch {
+	case errors.Is(err, ErrKilled):
+		return executionKilled
+	case errors.Is(err, context.DeadlineExceeded), resp.Error == TimeLimitExceeded:
+		return executionTimeout
+	case err != nil, resp.Error != "":
+		return executionError
+	case resp.ExitCode != 0:
+		return executionNonzeroExit
+	default:
+		return executionOK
+	}
+}
+
+// ErrInvalidRequest matches errors caused by the request itself, such as an
+// unsupported language or unsafe file name, rather than by the Docker daemon.
+var ErrInvalidRequest = errors.New("invalid execution request")
+
+type invalidRequestError struct {
+	err error
+}
+
+func (e invalidRequestError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+func (e invalidRequestError) Error() string {
+	return e.err.Error()
+}
+
+func (e invalidRequestError) Unwrap() error {
+	return e.err
+}
+
+func pullImage(ctx context.Context, cli *client.Client, imageName string) error {
+	pull, err := cli.ImagePull(ctx, imageName, image.PullOptions{})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
+	defer pull.Close()
+
+	if _, err := io.Copy(io.Discard, pull); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to read image pull output: %w", err)
+	}
+	return nil
+}
+
+// createContainer pulls the language image and creates a registered container
+// for req. Interactive containers keep stdin open for the caller to drive
+// instead of piping req.Stdin.
+func createContainer(ctx context.Context, cli *client.Client, req api.ExecutionRequest, cfg config.Config, interactive bool) (string, error) {
+	imageName, ok := cfg.Languages[req.Language]
+	if !ok {
+		return "", invalidRequestError{fmt.Errorf("unsupported language: %s", req.Language)}
+	}
+	if testImage, ok := cfg.TestImages[req.Language]; ok && req.Mode == api.ExecutionModeTest {
+		imageName = testImage
+	}
+	if auditImage, ok := cfg.AuditImages[req.Language]; ok && req.Audit {
+		imageName = auditImage
+	}
+	filePath := path.Join(workDir, "main"+getExtension(req.Language, cfg))
+
+	if interactive {
+		req.Stdin = ""
+	}
+	fullCmd, err := buildShellCommand(req, filePath, cfg)
+	if err != nil {
+		return "", invalidRequestError{err}
+	}
+
+	pullCtx, pullSpan := tracing.Start(ctx, "sandbox.pull_image", attribute.String("image", imageName))
+	err = pullImage(pullCtx, cli, imageName)
+	tracing.End(pullSpan, err)
+	if err != nil {
+		return "", err
+	}
+
+	labels := map[string]string{}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		labels[RequestIDLabel] = requestID
+	}
+
+	createCtx, createSpan := tracing.Start(ctx, "sandbox.create_container", attribute.String("image", imageName))
+	resp, err := cli.ContainerCreate(createCtx, &container.Config{
+		Image:           imageName,
+		Labels:          labels,
+		Cmd:             []string{"sh", "-c", fullCmd},
+		WorkingDir:      workDir,
+		Tty:             false,
+		AttachStdin:     interactive,
+		OpenStdin:       interactive,
+		StdinOnce:       interactive,
+		AttachStdout:    true,
+		AttachStderr:    true,
+		NetworkDisabled: true,
+	}, &container.HostConfig{
+		Resources: container.Resources{
+			Memory:   int64(cfg.MaxMemoryMB) * 1024 * 1024,
+			CPUQuota: 50000,
+		},
+	}, nil, nil, "")
+	if err != nil {
+		err = fmt.Errorf("failed to create container: %w", err)
+		tracing.End(createSpan, err)
+		return "", err
+	}
+	createSpan.SetAttributes(attribute.String("container.id", resp.ID))
+	createSpan.End()
+
+	registerContainer(ctx, resp.ID, cli)
+	slog.DebugContext(ctx, "container created", "container_id", resp.ID, "image", imageName, "language", req.Language)
+	return resp.ID, nil
+}
+
+// removeContainer kills and removes a container. It runs even after ctx is
+// cancelled; ctx only parents the span.
+func removeContainer(ctx context.Context, cli *client.Client, containerID string) {
+	ctx, span := tracing.Start(context.WithoutCancel(ctx), "sandbox.remove", attribute.String("container.id", containerID))
+	defer span.End()
+	cli.ContainerKill(ctx, containerID, "SIGKILL")
+	cli.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true})
+	unregisterContainer(containerID)
 }
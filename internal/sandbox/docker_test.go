@@ -1,9 +1,14 @@
 package sandbox
 
 import (
+	"archive/tar"
 	"bytes"
 	"encoding/binary"
+	"strings"
 	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
 )
 
 func multiplexedFrame(streamType byte, payload string) []byte {
@@ -20,7 +25,7 @@ func TestReadAttachedOutputSplitsStdoutAndStderr(t *testing.T) {
 		multiplexedFrame(1, "world\n")...,
 	))
 
-	stdout, stderr, err := readAttachedOutput(stream)
+	stdout, stderr, err := readAttachedOutput(stream, nil)
 	if err != nil {
 		t.Fatalf("readAttachedOutput returned error: %v", err)
 	}
@@ -33,3 +38,86 @@ func TestReadAttachedOutputSplitsStdoutAndStderr(t *testing.T) {
 		t.Fatalf("stderr mismatch: got %q", stderr)
 	}
 }
+
+func TestReadAttachedOutputStreamsChunksToSink(t *testing.T) {
+	stream := bytes.NewReader(append(multiplexedFrame(1, "out"), multiplexedFrame(2, "err")...))
+
+	var chunks []string
+	stdout, _, err := readAttachedOutput(stream, func(name string, data []byte) {
+		chunks = append(chunks, name+":"+string(data))
+	})
+	if err != nil {
+		t.Fatalf("readAttachedOutput returned error: %v", err)
+	}
+	if stdout != "out" || strings.Join(chunks, ",") != "stdout:out,stderr:err" {
+		t.Fatalf("stdout = %q, chunks = %v", stdout, chunks)
+	}
+}
+
+func TestBuildShellCommandWritesFilesAndPassesArgs(t *testing.T) {
+	req := api.ExecutionRequest{
+		Language:   "python",
+		SourceCode: "print('hi')",
+		Stdin:      "in",
+		Files:      map[string]string{"b.txt": "two", "a.txt": "it's"},
+		Args:       []string{"a.txt", "b.txt"},
+	}
+
+	cmd, err := buildShellCommand(req, "/tmp/main.py", config.Config{})
+	if err != nil {
+		t.Fatalf("buildShellCommand() error = %v", err)
+	}
+
+	want := `printf %s 'print('\''hi'\'')' > '/tmp/main.py' && printf %s 'it'\''s' > '/tmp/a.txt' && printf %s 'two' > '/tmp/b.txt' && printf %s 'in' | python /tmp/main.py 'a.txt' 'b.txt'`
+	if cmd != want {
+		t.Fatalf("command = %q, want %q", cmd, want)
+	}
+}
+
+func TestBuildShellCommandRejectsUnsafeFileNames(t *testing.T) {
+	for _, name := range []string{"", "..", "../etc/passwd", "dir/file", "main.py"} {
+		req := api.ExecutionRequest{Language: "python", Files: map[string]string{name: "x"}}
+		if _, err := buildShellCommand(req, "/tmp/main.py", config.Config{}); err == nil {
+			t.Fatalf("buildShellCommand(file %q) error = nil, want rejection", name)
+		}
+	}
+}
+
+func TestBuildShellCommandUsesTestRunnerInTestMode(t *testing.T) {
+	req := api.ExecutionRequest{
+		Language:   "python",
+		SourceCode: "def add(a, b): return a + b",
+		Mode:       api.ExecutionModeTest,
+		Files:      map[string]string{"test_main.py": "from main import add"},
+	}
+
+	cmd, err := buildShellCommand(req, "/tmp/main.py", config.Config{})
+	if err != nil {
+		t.Fatalf("buildShellCommand() error = %v", err)
+	}
+	if !strings.HasSuffix(cmd, "python -m pytest -q -p no:cacheprovider --junitxml=.test-report") {
+		t.Fatalf("command = %q, want pytest runner writing the junit report", cmd)
+	}
+
+	req.Language = "ruby"
+	if _, err := buildShellCommand(req, "/tmp/main.rb", config.Config{}); err == nil {
+		t.Fatal("buildShellCommand(ruby test mode) error = nil, want unsupported")
+	}
+}
+
+func TestReadSingleFileTarReturnsFirstRegularFile(t *testing.T) {
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	contents := `{"Action":"pass","Test":"TestAdd"}`
+	writer.WriteHeader(&tar.Header{Name: ".test-report", Mode: 0o644, Size: int64(len(contents)), Typeflag: tar.TypeReg})
+	writer.Write([]byte(contents))
+	writer.Close()
+
+	got, err := readSingleFileTar(&archive)
+	if err != nil {
+		t.Fatalf("readSingleFileTar() error = %v", err)
+	}
+	if got != contents {
+		t.Fatalf("contents = %q, want %q", got, contents)
+	}
+}
@@ -0,0 +1,80 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// collectFileChanges lists what the program touched in a stopped container.
+// It must run before the container is removed.
+func collectFileChanges(ctx context.Context, cli *client.Client, containerID string, req api.ExecutionRequest, cfg config.Config) ([]api.FileChange, error) {
+	changes, err := cli.ContainerDiff(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff container: %w", err)
+	}
+	return filterFileChanges(changes, sandboxWrittenPaths(req, cfg)), nil
+}
+
+// sandboxWrittenPaths returns the files the sandbox itself places in the work
+// directory, which would otherwise show up in every diff.
+func sandboxWrittenPaths(req api.ExecutionRequest, cfg config.Config) map[string]bool {
+	written := map[string]bool{
+		path.Join(workDir, "main"+getExtension(req.Language, cfg)): true,
+	}
+	for name := range req.Files {
+		written[path.Join(workDir, name)] = true
+	}
+	if req.Mode == api.ExecutionModeTest {
+		written[path.Join(workDir, testReportFile)] = true
+	}
+	if req.Audit {
+		written[path.Join(workDir, auditLogFile)] = true
+		written[path.Join(workDir, auditBinary)] = true
+	}
+	return written
+}
+
+// filterFileChanges drops the sandbox's own files and the "modified" entries
+// Docker reports for every parent directory of a change, which say nothing
+// beyond the change itself.
+func filterFileChanges(changes []container.FilesystemChange, written map[string]bool) []api.FileChange {
+	parents := map[string]bool{}
+	for _, change := range changes {
+		for dir := path.Dir(change.Path); dir != "/" && dir != "."; dir = path.Dir(dir) {
+			parents[dir] = true
+		}
+	}
+
+	result := make([]api.FileChange, 0, len(changes))
+	for _, change := range changes {
+		if written[change.Path] {
+			continue
+		}
+		if change.Kind == container.ChangeModify && parents[change.Path] {
+			continue
+		}
+		result = append(result, api.FileChange{Path: change.Path, Kind: fileChangeKind(change.Kind)})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+func fileChangeKind(kind container.ChangeType) string {
+	switch kind {
+	case container.ChangeAdd:
+		return api.FileChangeAdded
+	case container.ChangeDelete:
+		return api.FileChangeDeleted
+	default:
+		return api.FileChangeModified
+	}
+}
@@ -0,0 +1,45 @@
+package sandbox
+
+import (
+	"reflect"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestFilterFileChangesDropsSandboxFilesAndParentDirectories(t *testing.T) {
+	req := api.ExecutionRequest{
+		Language: "python",
+		Files:    map[string]string{"input.txt": "1 2"},
+	}
+	changes := []container.FilesystemChange{
+		{Kind: container.ChangeModify, Path: "/tmp"},
+		{Kind: container.ChangeAdd, Path: "/tmp/main.py"},
+		{Kind: container.ChangeAdd, Path: "/tmp/input.txt"},
+		{Kind: container.ChangeAdd, Path: "/tmp/out.csv"},
+		{Kind: container.ChangeModify, Path: "/etc"},
+		{Kind: container.ChangeDelete, Path: "/etc/hosts"},
+		{Kind: container.ChangeModify, Path: "/var/log/app.log"},
+	}
+
+	got := filterFileChanges(changes, sandboxWrittenPaths(req, config.Config{}))
+
+	want := []api.FileChange{
+		{Path: "/etc/hosts", Kind: api.FileChangeDeleted},
+		{Path: "/tmp/out.csv", Kind: api.FileChangeAdded},
+		{Path: "/var/log/app.log", Kind: api.FileChangeModified},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("filterFileChanges() = %#v, want %#v", got, want)
+	}
+}
+
+func TestSandboxWrittenPathsIncludesTestReportInTestMode(t *testing.T) {
+	written := sandboxWrittenPaths(api.ExecutionRequest{Language: "go", Mode: api.ExecutionModeTest}, config.Config{})
+
+	if !written["/tmp/main.go"] || !written["/tmp/.test-report"] {
+		t.Fatalf("written = %v, want source and test report", written)
+	}
+}
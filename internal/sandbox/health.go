@@ -0,0 +1,75 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gexec-sandbox/internal/config"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/client"
+)
+
+// PingDocker reports whether the Docker daemon answers.
+func PingDocker(ctx context.Context) error {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	if _, err := cli.Ping(ctx); err != nil {
+		return fmt.Errorf("ping docker: %w", err)
+	}
+	return nil
+}
+
+// RequiredImages lists every image cfg can run, without duplicates. Audit
+// images are included only when auditing is enabled.
+func RequiredImages(cfg config.Config) []string {
+	seen := map[string]bool{}
+	for _, images := range []map[string]string{cfg.Languages, cfg.TestImages} {
+		for _, image := range images {
+			seen[image] = true
+		}
+	}
+	if cfg.Audit {
+		for _, image := range cfg.AuditImages {
+			seen[image] = true
+		}
+	}
+
+	images := make([]string, 0, len(seen))
+	for image := range seen {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// CheckImages reports an error naming every image in RequiredImages that is
+// not present locally. Missing images would be pulled on first use, which
+// adds that pull to the first execution's latency.
+func CheckImages(ctx context.Context, cfg config.Config) error {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	var missing []string
+	for _, image := range RequiredImages(cfg) {
+		if _, err := cli.ImageInspect(ctx, image); err != nil {
+			if cerrdefs.IsNotFound(err) {
+				missing = append(missing, image)
+				continue
+			}
+			return fmt.Errorf("inspect image %s: %w", image, err)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("images not present: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
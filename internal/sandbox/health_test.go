@@ -0,0 +1,27 @@
+package sandbox
+
+import (
+	"reflect"
+	"testing"
+
+	"gexec-sandbox/internal/config"
+)
+
+func TestRequiredImagesDeduplicatesAndSkipsAuditImagesWhenDisabled(t *testing.T) {
+	cfg := config.Config{
+		Languages:   map[string]string{"python": "python:3.9-slim", "py": "python:3.9-slim", "go": "golang:1.24-alpine"},
+		TestImages:  map[string]string{"python": "python-pytest:3.9"},
+		AuditImages: map[string]string{"python": "python-strace:3.9"},
+	}
+
+	want := []string{"golang:1.24-alpine", "python-pytest:3.9", "python:3.9-slim"}
+	if got := RequiredImages(cfg); !reflect.DeepEqual(got, want) {
+		t.Fatalf("RequiredImages() = %v, want %v", got, want)
+	}
+
+	cfg.Audit = true
+	want = []string{"golang:1.24-alpine", "python-pytest:3.9", "python-strace:3.9", "python:3.9-slim"}
+	if got := RequiredImages(cfg); !reflect.DeepEqual(got, want) {
+		t.Fatalf("RequiredImages() with audit = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,288 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/tracing"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	TranscriptSubmission = "submission"
+	TranscriptInteractor = "interactor"
+)
+
+// TimeLimitExceeded is the per-side error recorded when an interactive
+// program is killed for running past its time limit.
+const TimeLimitExceeded = "time limit exceeded"
+
+// RunInteractiveInSandbox runs a submission and a task interactor in separate
+// containers with each program's stdout wired to the other's stdin. Each side
+// is killed when its own time limit expires. The returned response describes
+// the submission; the interactor's result and the full transcript are attached.
+func RunInteractiveInSandbox(ctx context.Context, submission api.ExecutionRequest, interactor api.ExecutionRequest, cfg config.Config) (resp api.ExecutionResponse, err error) {
+	ctx, span := tracing.Start(ctx, "sandbox.execute_interactive",
+		attribute.String("language", submission.Language),
+		attribute.String("interactor.language", interactor.Language),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	for _, req := range []api.ExecutionRequest{submission, interactor} {
+		if _, ok := cfg.Languages[req.Language]; !ok {
+			err := invalidRequestError{fmt.Errorf("unsupported language: %s", req.Language)}
+			return api.ExecutionResponse{Error: err.Error()}, err
+		}
+	}
+
+	ctx, exec, done := executions.track(ctx, api.ExecutionKindInteractive, submission.Language)
+	defer done()
+	// The submission and the interactor each hold a container.
+	release, err := admit(ctx, cfg, 2)
+	if err != nil {
+		return api.ExecutionResponse{}, killed(ctx, err)
+	}
+	defer release()
+	executions.start(exec)
+
+	started := time.Now()
+	resp, err = runInteractiveInSandbox(ctx, submission, interactor, cfg)
+	err = killed(ctx, err)
+	observeExecution(ctx, submission.Language, resp, err, time.Since(started))
+	return resp, err
+}
+
+func runInteractiveInSandbox(ctx context.Context, submission api.ExecutionRequest, interactor api.ExecutionRequest, cfg config.Config) (api.ExecutionResponse, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return api.ExecutionResponse{}, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	submissionLimit := time.Duration(submission.TimeoutMS) * time.Millisecond
+	interactorLimit := time.Duration(interactor.TimeoutMS) * time.Millisecond
+	sessionCtx, cancel := context.WithTimeout(ctx, max(submissionLimit, interactorLimit))
+	defer cancel()
+
+	submissionID, err := createContainer(sessionCtx, cli, submission, cfg, true)
+	if err != nil {
+		return api.ExecutionResponse{}, fmt.Errorf("create submission container: %w", err)
+	}
+	defer removeContainer(sessionCtx, cli, submissionID)
+
+	interactorID, err := createContainer(sessionCtx, cli, interactor, cfg, true)
+	if err != nil {
+		return api.ExecutionResponse{}, fmt.Errorf("create interactor container: %w", err)
+	}
+	defer removeContainer(sessionCtx, cli, interactorID)
+
+	startCtx, startSpan := tracing.Start(sessionCtx, "sandbox.start")
+	attachOptions := container.AttachOptions{Stream: true, Stdin: true, Stdout: true, Stderr: true}
+	submissionAttach, err := cli.ContainerAttach(startCtx, submissionID, attachOptions)
+	if err != nil {
+		err = fmt.Errorf("failed to attach to submission container: %w", err)
+		tracing.End(startSpan, err)
+		return api.ExecutionResponse{}, err
+	}
+	defer submissionAttach.Close()
+
+	interactorAttach, err := cli.ContainerAttach(startCtx, interactorID, attachOptions)
+	if err != nil {
+		err = fmt.Errorf("failed to attach to interactor container: %w", err)
+		tracing.End(startSpan, err)
+		return api.ExecutionResponse{}, err
+	}
+	defer interactorAttach.Close()
+
+	recorder := &transcriptRecorder{}
+	var submissionStdout, submissionStderr, interactorStdout, interactorStderr bytes.Buffer
+	var pumps sync.WaitGroup
+	pumps.Add(2)
+	go relay(&pumps, submissionAttach, interactorAttach, relayWriter{
+		source: TranscriptSubmission, recorder: recorder, captured: &submissionStdout, peer: interactorAttach.Conn,
+	}, &submissionStderr)
+	go relay(&pumps, interactorAttach, submissionAttach, relayWriter{
+		source: TranscriptInteractor, recorder: recorder, captured: &interactorStdout, peer: submissionAttach.Conn,
+	}, &interactorStderr)
+
+	if err := cli.ContainerStart(startCtx, interactorID, container.StartOptions{}); err != nil {
+		err = fmt.Errorf("failed to start interactor container: %w", err)
+		tracing.End(startSpan, err)
+		return api.ExecutionResponse{}, err
+	}
+	if err := cli.ContainerStart(startCtx, submissionID, container.StartOptions{}); err != nil {
+		err = fmt.Errorf("failed to start submission container: %w", err)
+		tracing.End(startSpan, err)
+		return api.ExecutionResponse{}, err
+	}
+	startSpan.End()
+
+	_, waitSpan := tracing.Start(sessionCtx, "sandbox.wait")
+	started := time.Now()
+	var submissionDuration, interactorDuration time.Duration
+	var submissionTimedOut, interactorTimedOut bool
+	var submissionErr, interactorErr error
+	var waits sync.WaitGroup
+	waits.Add(2)
+	go func() {
+		defer waits.Done()
+		submissionTimedOut, submissionErr = waitWithLimit(sessionCtx, cli, submissionID, submissionLimit)
+		submissionDuration = time.Since(started)
+	}()
+	go func() {
+		defer waits.Done()
+		interactorTimedOut, interactorErr = waitWithLimit(sessionCtx, cli, interactorID, interactorLimit)
+		interactorDuration = time.Since(started)
+	}()
+	waits.Wait()
+
+	if err := errors.Join(submissionErr, interactorErr); err != nil {
+		tracing.End(waitSpan, err)
+		return api.ExecutionResponse{}, err
+	}
+
+	pumpsDone := make(chan struct{})
+	go func() {
+		pumps.Wait()
+		close(pumpsDone)
+	}()
+	select {
+	case <-pumpsDone:
+	case <-sessionCtx.Done():
+		tracing.End(waitSpan, sessionCtx.Err())
+		return api.ExecutionResponse{}, sessionCtx.Err()
+	}
+	waitSpan.End()
+
+	collectCtx, collectSpan := tracing.Start(sessionCtx, "sandbox.collect")
+	defer collectSpan.End()
+	submissionResp, err := exitedResponse(collectCtx, cli, submissionID, submissionTimedOut)
+	if err != nil {
+		return api.ExecutionResponse{}, err
+	}
+	interactorResp, err := exitedResponse(collectCtx, cli, interactorID, interactorTimedOut)
+	if err != nil {
+		return api.ExecutionResponse{}, err
+	}
+
+	if submission.Audit {
+		if submissionResp.Audit, err = collectAudit(collectCtx, cli, submissionID); err != nil {
+			return api.ExecutionResponse{}, err
+		}
+	}
+	if submission.CaptureFileChanges {
+		if submissionResp.FileChanges, err = collectFileChanges(collectCtx, cli, submissionID, submission, cfg); err != nil {
+			return api.ExecutionResponse{}, err
+		}
+	}
+	if interactor.CaptureFileChanges {
+		if interactorResp.FileChanges, err = collectFileChanges(collectCtx, cli, interactorID, interactor, cfg); err != nil {
+			return api.ExecutionResponse{}, err
+		}
+	}
+
+	submissionResp.DurationMS = submissionDuration.Milliseconds()
+	interactorResp.DurationMS = interactorDuration.Milliseconds()
+	submissionResp.Stdout = submissionStdout.String()
+	submissionResp.Stderr = submissionStderr.String()
+	interactorResp.Stdout = interactorStdout.String()
+	interactorResp.Stderr = interactorStderr.String()
+	submissionResp.Interactor = &interactorResp
+	submissionResp.Transcript = recorder.entries()
+	return submissionResp, nil
+}
+
+// relay demultiplexes one container's output, forwarding stdout to the peer
+// and signalling EOF on the peer's stdin once the source stops writing.
+func relay(done *sync.WaitGroup, source types.HijackedResponse, peer types.HijackedResponse, stdout relayWriter, stderr io.Writer) {
+	defer done.Done()
+	stdcopy.StdCopy(stdout, stderr, source.Reader)
+	peer.CloseWrite()
+}
+
+// waitWithLimit waits for a started container to exit, killing it once limit
+// elapses. It reports whether the limit was hit.
+func waitWithLimit(ctx context.Context, cli *client.Client, containerID string, limit time.Duration) (bool, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+
+	statusCh, errCh := cli.ContainerWait(waitCtx, containerID, container.WaitConditionNotRunning)
+	select {
+	case <-statusCh:
+		return false, nil
+	case err := <-errCh:
+		if ctx.Err() == nil && waitCtx.Err() != nil {
+			cli.ContainerKill(context.Background(), containerID, "SIGKILL")
+			return true, nil
+		}
+		return false, fmt.Errorf("error waiting for container: %w", err)
+	case <-waitCtx.Done():
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		cli.ContainerKill(context.Background(), containerID, "SIGKILL")
+		return true, nil
+	}
+}
+
+func exitedResponse(ctx context.Context, cli *client.Client, containerID string, timedOut bool) (api.ExecutionResponse, error) {
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return api.ExecutionResponse{}, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	resp := api.ExecutionResponse{ExitCode: inspect.State.ExitCode}
+	if timedOut {
+		resp.Error = TimeLimitExceeded
+	}
+	return resp, nil
+}
+
+type transcriptRecorder struct {
+	mu      sync.Mutex
+	records []api.TranscriptEntry
+}
+
+func (r *transcriptRecorder) record(source string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last := len(r.records) - 1; last >= 0 && r.records[last].Source == source {
+		r.records[last].Data += string(data)
+		return
+	}
+	r.records = append(r.records, api.TranscriptEntry{Source: source, Data: string(data)})
+}
+
+func (r *transcriptRecorder) entries() []api.TranscriptEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]api.TranscriptEntry(nil), r.records...)
+}
+
+// relayWriter records one side's stdout and forwards it to the peer. Writes to
+// a peer that has already exited are dropped so the source keeps draining.
+type relayWriter struct {
+	source   string
+	recorder *transcriptRecorder
+	captured *bytes.Buffer
+	peer     io.Writer
+}
+
+func (w relayWriter) Write(p []byte) (int, error) {
+	w.recorder.record(w.source, p)
+	w.captured.Write(p)
+	w.peer.Write(p)
+	return len(p), nil
+}
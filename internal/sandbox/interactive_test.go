@@ -0,0 +1,57 @@
+package sandbox
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+)
+
+func TestRelayWriterRecordsTranscriptAndForwardsToPeer(t *testing.T) {
+	recorder := &transcriptRecorder{}
+	var submissionOut, interactorOut, toInteractor, toSubmission bytes.Buffer
+	submission := relayWriter{source: TranscriptSubmission, recorder: recorder, captured: &submissionOut, peer: &toInteractor}
+	interactor := relayWriter{source: TranscriptInteractor, recorder: recorder, captured: &interactorOut, peer: &toSubmission}
+
+	interactor.Write([]byte("5\n"))
+	submission.Write([]byte("? 3\n"))
+	submission.Write([]byte("? 4\n"))
+	interactor.Write([]byte("higher\n"))
+
+	want := []api.TranscriptEntry{
+		{Source: TranscriptInteractor, Data: "5\n"},
+		{Source: TranscriptSubmission, Data: "? 3\n? 4\n"},
+		{Source: TranscriptInteractor, Data: "higher\n"},
+	}
+	if got := recorder.entries(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("transcript = %#v, want %#v", got, want)
+	}
+	if toInteractor.String() != "? 3\n? 4\n" || submissionOut.String() != "? 3\n? 4\n" {
+		t.Fatalf("forwarded = %q captured = %q, want submission output", toInteractor.String(), submissionOut.String())
+	}
+	if toSubmission.String() != "5\nhigher\n" || interactorOut.String() != "5\nhigher\n" {
+		t.Fatalf("forwarded = %q captured = %q, want interactor output", toSubmission.String(), interactorOut.String())
+	}
+}
+
+func TestRelayWriterKeepsDrainingAfterPeerExits(t *testing.T) {
+	recorder := &transcriptRecorder{}
+	var captured bytes.Buffer
+	writer := relayWriter{source: TranscriptSubmission, recorder: recorder, captured: &captured, peer: closedWriter{}}
+
+	n, err := writer.Write([]byte("late output"))
+	if err != nil || n != len("late output") {
+		t.Fatalf("Write() = %d, %v, want full write without error", n, err)
+	}
+	if captured.String() != "late output" {
+		t.Fatalf("captured = %q, want late output", captured.String())
+	}
+}
+
+type closedWriter struct{}
+
+func (closedWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("use of closed network connection")
+}
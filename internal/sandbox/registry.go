@@ -0,0 +1,239 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/logging"
+	"github.com/docker/docker/api/types/container"
+)
+
+var (
+	// ErrExecutionNotFound is returned by KillExecution for an ID that is not
+	// queued or running.
+	ErrExecutionNotFound = errors.New("execution not found")
+	// ErrKilled is returned by an execution that an operator killed.
+	ErrKilled = errors.New("execution killed by operator")
+)
+
+// usageTimeout bounds how long ListExecutions waits for the daemon's stats.
+const usageTimeout = 2 * time.Second
+
+// executions tracks every execution from the moment it queues for admission
+// until its containers are removed.
+var executions = &executionRegistry{byID: make(map[string]*execution)}
+
+type executionRegistry struct {
+	mu   sync.Mutex
+	byID map[string]*execution
+}
+
+type execution struct {
+	info   api.ActiveExecution
+	cancel context.CancelCauseFunc
+}
+
+type executionKey struct{}
+
+type requesterKey struct{}
+
+// WithRequester tags ctx with who asked for the executions it starts, as
+// shown to operators listing active executions.
+func WithRequester(ctx context.Context, requester string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, requester)
+}
+
+// track registers a queued execution. The returned context is cancelled with
+// ErrKilled when an operator kills the execution; done unregisters it.
+func (r *executionRegistry) track(ctx context.Context, kind string, language string) (context.Context, *execution, func()) {
+	requester, _ := ctx.Value(requesterKey{}).(string)
+	ctx, cancel := context.WithCancelCause(ctx)
+	e := &execution{
+		info: api.ActiveExecution{
+			ID:        logging.NewID(),
+			Kind:      kind,
+			State:     api.ExecutionStateQueued,
+			Language:  language,
+			RequestID: logging.RequestID(ctx),
+			Requester: requester,
+			QueuedAt:  time.Now(),
+		},
+		cancel: cancel,
+	}
+
+	r.mu.Lock()
+	r.byID[e.info.ID] = e
+	r.mu.Unlock()
+
+	done := func() {
+		r.mu.Lock()
+		delete(r.byID, e.info.ID)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+	return context.WithValue(ctx, executionKey{}, e), e, done
+}
+
+// start marks an admitted execution as running.
+func (r *executionRegistry) start(e *execution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	started := time.Now()
+	e.info.State = api.ExecutionStateRunning
+	e.info.StartedAt = &started
+}
+
+// attach records a container against the execution that owns ctx.
+func (r *executionRegistry) attach(ctx context.Context, containerID string) {
+	e, ok := ctx.Value(executionKey{}).(*execution)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.info.Containers = append(e.info.Containers, api.ContainerUsage{ID: containerID})
+}
+
+// detach forgets a removed container, wherever it was attached.
+func (r *executionRegistry) detach(containerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.byID {
+		for i, c := range e.info.Containers {
+			if c.ID == containerID {
+				e.info.Containers = append(e.info.Containers[:i:i], e.info.Containers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// snapshot copies the executions in state, or all of them when state is
+// empty, oldest first.
+func (r *executionRegistry) snapshot(state string, now time.Time) []api.ActiveExecution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]api.ActiveExecution, 0, len(r.byID))
+	for _, e := range r.byID {
+		if state != "" && e.info.State != state {
+			continue
+		}
+		info := e.info
+		info.Containers = append([]api.ContainerUsage(nil), e.info.Containers...)
+		info.AgeMS = now.Sub(info.QueuedAt).Milliseconds()
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].QueuedAt.Equal(list[j].QueuedAt) {
+			return list[i].QueuedAt.Before(list[j].QueuedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+func (r *executionRegistry) kill(id string) (api.ActiveExecution, error) {
+	r.mu.Lock()
+	e, ok := r.byID[id]
+	var info api.ActiveExecution
+	if ok {
+		info = e.info
+	}
+	r.mu.Unlock()
+	if !ok {
+		return api.ActiveExecution{}, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+	e.cancel(ErrKilled)
+	return info, nil
+}
+
+// killed reports ErrKilled in place of the error an execution returned after
+// an operator cancelled its context.
+func killed(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), ErrKilled) {
+		return ErrKilled
+	}
+	return err
+}
+
+// ListExecutions returns the running executions with each container's current
+// resource usage.
+func ListExecutions(ctx context.Context) []api.ActiveExecution {
+	list := executions.snapshot(api.ExecutionStateRunning, time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, usageTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := range list {
+		for j := range list[i].Containers {
+			wg.Add(1)
+			go func(usage *api.ContainerUsage) {
+				defer wg.Done()
+				*usage = containerUsage(ctx, usage.ID)
+			}(&list[i].Containers[j])
+		}
+	}
+	wg.Wait()
+	return list
+}
+
+// QueuedExecutions returns the executions waiting for admission, oldest first.
+func QueuedExecutions() []api.ActiveExecution {
+	return executions.snapshot(api.ExecutionStateQueued, time.Now())
+}
+
+// KillExecution cancels a queued or running execution. Its containers are
+// removed by the execution itself as it unwinds.
+func KillExecution(id string) (api.ActiveExecution, error) {
+	return executions.kill(id)
+}
+
+func containerUsage(ctx context.Context, containerID string) api.ContainerUsage {
+	usage := api.ContainerUsage{ID: containerID}
+
+	containersMutex.RLock()
+	cli, ok := containers[containerID]
+	containersMutex.RUnlock()
+	if !ok {
+		usage.Error = "container was removed"
+		return usage
+	}
+
+	reader, err := cli.ContainerStatsOneShot(ctx, containerID)
+	if err != nil {
+		usage.Error = err.Error()
+		return usage
+	}
+	defer reader.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(reader.Body).Decode(&stats); err != nil {
+		usage.Error = fmt.Sprintf("decode container stats: %v", err)
+		return usage
+	}
+	usage.CPUSeconds = float64(stats.CPUStats.CPUUsage.TotalUsage) / float64(time.Second)
+	usage.MemoryBytes = stats.MemoryStats.Usage
+	usage.MemoryLimitBytes = stats.MemoryStats.Limit
+	return usage
+}
+
+// ExecutionCounts reports how many executions are running and queued.
+func ExecutionCounts() (running int, queued int) {
+	executions.mu.Lock()
+	defer executions.mu.Unlock()
+	for _, e := range executions.byID {
+		if e.info.State == api.ExecutionStateRunning {
+			running++
+		} else {
+			queued++
+		}
+	}
+	return running, queued
+}
@@ -0,0 +1,66 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/logging"
+)
+
+func TestExecutionRegistryTracksLifecycle(t *testing.T) {
+	registry := &executionRegistry{byID: make(map[string]*execution)}
+	ctx := WithRequester(logging.WithRequestID(context.Background(), "req-1"), "ci")
+
+	ctx, exec, done := registry.track(ctx, api.ExecutionKindSingle, "python")
+	if got := registry.snapshot(api.ExecutionStateQueued, time.Now()); len(got) != 1 || got[0].RequestID != "req-1" || got[0].Requester != "ci" || got[0].Language != "python" {
+		t.Fatalf("queued = %+v, want the tracked execution", got)
+	}
+
+	registry.start(exec)
+	registry.attach(ctx, "container-a")
+	running := registry.snapshot(api.ExecutionStateRunning, time.Now())
+	if len(running) != 1 || running[0].StartedAt == nil || len(running[0].Containers) != 1 || running[0].Containers[0].ID != "container-a" {
+		t.Fatalf("running = %+v, want started execution with container-a", running)
+	}
+
+	registry.detach("container-a")
+	if got := registry.snapshot("", time.Now()); len(got[0].Containers) != 0 {
+		t.Fatalf("containers after detach = %+v, want none", got[0].Containers)
+	}
+
+	done()
+	if got := registry.snapshot("", time.Now()); len(got) != 0 {
+		t.Fatalf("snapshot after done = %+v, want empty", got)
+	}
+}
+
+func TestExecutionRegistryKillCancelsQueuedExecution(t *testing.T) {
+	registry := &executionRegistry{byID: make(map[string]*execution)}
+	slots := &admission{freed: make(chan struct{})}
+	hold, err := slots.acquire(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	defer hold()
+
+	ctx, exec, done := registry.track(context.Background(), api.ExecutionKindSingle, "go")
+	defer done()
+	result := make(chan error, 1)
+	go func() {
+		_, err := slots.acquire(ctx, 1, 1)
+		result <- killed(ctx, err)
+	}()
+
+	if _, err := registry.kill(exec.info.ID); err != nil {
+		t.Fatalf("kill() error = %v", err)
+	}
+	if err := <-result; !errors.Is(err, ErrKilled) {
+		t.Fatalf("queued execution error = %v, want ErrKilled", err)
+	}
+	if _, err := registry.kill("missing"); !errors.Is(err, ErrExecutionNotFound) {
+		t.Fatalf("kill(missing) error = %v, want ErrExecutionNotFound", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package tracing configures OpenTelemetry span export and offers the small
+// helpers the rest of the evaluator uses to open and close spans.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterEnv     = "EVALUATOR_TRACE_EXPORTER"
+	OTLPEndpointEnv = "EVALUATOR_OTLP_ENDPOINT"
+	FileEnv         = "EVALUATOR_TRACE_FILE"
+
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+
+	defaultTraceFile = "traces.jsonl"
+	serviceName      = "gexec-sandbox-evaluator"
+	instrumentation  = "gexec-sandbox"
+)
+
+// Config selects where spans go. OTLPEndpoint is a URL such as
+// http://collector:4318; when empty the exporter falls back to the standard
+// OTEL_EXPORTER_OTLP_* variables.
+type Config struct {
+	Exporter     string
+	OTLPEndpoint string
+	File         string
+}
+
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Exporter:     strings.ToLower(strings.TrimSpace(os.Getenv(ExporterEnv))),
+		OTLPEndpoint: strings.TrimSpace(os.Getenv(OTLPEndpointEnv)),
+		File:         strings.TrimSpace(os.Getenv(FileEnv)),
+	}
+	switch cfg.Exporter {
+	case "":
+		cfg.Exporter = ExporterNone
+	case ExporterNone, ExporterOTLP:
+	case ExporterFile:
+		if cfg.File == "" {
+			cfg.File = defaultTraceFile
+		}
+	default:
+		return Config{}, fmt.Errorf("%s must be %q, %q or %q, got %q", ExporterEnv, ExporterNone, ExporterOTLP, ExporterFile, cfg.Exporter)
+	}
+	return cfg, nil
+}
+
+// Setup installs the global tracer provider and W3C trace context
+// propagation. The returned func flushes buffered spans; with no exporter
+// configured, spans are discarded and the func does nothing.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var closeFile func() error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		otlpExporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp trace exporter: %w", err)
+		}
+		exporter = otlpExporter
+	case ExporterFile:
+		file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		fileExporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("create file trace exporter: %w", err)
+		}
+		exporter = fileExporter
+		closeFile = file.Close
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %q", cfg.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFile != nil {
+			if closeErr := closeFile(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Start opens a span named name as a child of any span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer opens a server span for an incoming request, continuing the
+// caller's trace when headers carry W3C trace context.
+func StartServer(ctx context.Context, headers http.Header, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(headers))
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupFileExporterWritesSpans(t *testing.T) {
+	original := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(original) })
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterFile, File: path})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	End(child, errors.New("boom"))
+	End(parent, nil)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open trace file: %v", err)
+	}
+	defer file.Close()
+
+	type exported struct {
+		Name   string
+		Status struct{ Code string }
+		Parent struct{ SpanID string }
+	}
+	spans := map[string]exported{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span exported
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatalf("trace line %q is not JSON: %v", scanner.Text(), err)
+		}
+		spans[span.Name] = span
+	}
+	if len(spans) != 2 {
+		t.Fatalf("exported spans = %v, want parent and child", spans)
+	}
+	if spans["child"].Status.Code != "Error" {
+		t.Fatalf("child status = %q, want Error", spans["child"].Status.Code)
+	}
+	if spans["child"].Parent.SpanID == "" {
+		t.Fatal("child span has no parent")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(ExporterEnv, "")
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.Exporter != ExporterNone {
+		t.Fatalf("ConfigFromEnv() = %+v, %v, want exporter none", cfg, err)
+	}
+
+	t.Setenv(ExporterEnv, "file")
+	cfg, err = ConfigFromEnv()
+	if err != nil || cfg.File != defaultTraceFile {
+		t.Fatalf("ConfigFromEnv() = %+v, %v, want default trace file", cfg, err)
+	}
+
+	t.Setenv(ExporterEnv, "zipkin")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Fatal("ConfigFromEnv() accepted exporter zipkin")
+	}
+}
@@ -0,0 +1,141 @@
+// Package validation checks API requests against the configured limits before
+// any sandbox work starts, reporting every invalid field at once.
+package validation
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/config"
+	"gexec-sandbox/internal/sandbox"
+)
+
+// Error lists the invalid fields of a request.
+type Error struct {
+	Fields []api.FieldError
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + ": " + field.Message
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+func (e *Error) add(field string, format string, args ...any) {
+	e.Fields = append(e.Fields, api.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *Error) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ExecutionRequest checks req against cfg. A zero timeout_ms is valid and
+// means the configured default. The returned error is an *Error.
+func ExecutionRequest(req api.ExecutionRequest, cfg config.Config) error {
+	var errs Error
+
+	if req.Language == "" {
+		errs.add("language", "is required")
+	} else if _, ok := cfg.Languages[req.Language]; !ok {
+		errs.add("language", "unsupported language %q; supported: %s", req.Language, strings.Join(languages(cfg), ", "))
+	}
+
+	if req.SourceCode == "" {
+		errs.add("source_code", "cannot be empty")
+	} else if cfg.MaxSourceBytes > 0 && len(req.SourceCode) > cfg.MaxSourceBytes {
+		errs.add("source_code", "is %d bytes; the limit is %d", len(req.SourceCode), cfg.MaxSourceBytes)
+	}
+	if cfg.MaxStdinBytes > 0 && len(req.Stdin) > cfg.MaxStdinBytes {
+		errs.add("stdin", "is %d bytes; the limit is %d", len(req.Stdin), cfg.MaxStdinBytes)
+	}
+
+	switch {
+	case req.TimeoutMS < 0:
+		errs.add("timeout_ms", "cannot be negative")
+	case req.TimeoutMS == 0:
+	case cfg.MinTimeoutMS > 0 && req.TimeoutMS < cfg.MinTimeoutMS:
+		errs.add("timeout_ms", "must be at least %d", cfg.MinTimeoutMS)
+	case cfg.MaxTimeoutMS > 0 && req.TimeoutMS > cfg.MaxTimeoutMS:
+		errs.add("timeout_ms", "must be at most %d", cfg.MaxTimeoutMS)
+	}
+
+	names := make([]string, 0, len(req.Files))
+	for name := range req.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := "files." + name
+		if err := sandbox.ValidateFileName(name); err != nil {
+			errs.add(field, "must be a plain file name")
+			continue
+		}
+		if size := len(req.Files[name]); cfg.MaxSourceBytes > 0 && size > cfg.MaxSourceBytes {
+			errs.add(field, "is %d bytes; the limit is %d", size, cfg.MaxSourceBytes)
+		}
+	}
+
+	if req.Mode != "" && req.Mode != api.ExecutionModeTest {
+		errs.add("mode", "must be empty or %q", api.ExecutionModeTest)
+	}
+
+	// Auditing needs strace, which only the configured audit images ship.
+	if req.Audit {
+		if req.Mode == api.ExecutionModeTest {
+			errs.add("audit", "is not supported in %q mode", api.ExecutionModeTest)
+		} else if _, ok := cfg.AuditImages[req.Language]; !ok && req.Language != "" {
+			errs.add("audit", "no audit image is configured for language %q", req.Language)
+		}
+	}
+
+	return errs.err()
+}
+
+// BenchmarkSelection checks that sel's patterns are valid globs and its limit
+// is not negative. The returned error is an *Error.
+func BenchmarkSelection(sel benchmark.Selection) error {
+	var errs Error
+
+	selectors := sel.Selectors()
+	names := make([]string, 0, len(selectors))
+	for name := range selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		selector := selectors[name]
+		checkPatterns(&errs, name+".include", selector.Include)
+		checkPatterns(&errs, name+".exclude", selector.Exclude)
+	}
+	if sel.Limit < 0 {
+		errs.add("limit", "cannot be negative")
+	}
+
+	return errs.err()
+}
+
+func checkPatterns(errs *Error, field string, patterns []string) {
+	for i, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), "%q is not a valid glob pattern", pattern)
+		}
+	}
+}
+
+func languages(cfg config.Config) []string {
+	names := make([]string, 0, len(cfg.Languages))
+	for name := range cfg.Languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
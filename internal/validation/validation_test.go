@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gexec-sandbox/internal/api"
+	"gexec-sandbox/internal/benchmark"
+	"gexec-sandbox/internal/config"
+)
+
+func TestExecutionRequestEnforcesConfiguredBounds(t *testing.T) {
+	cfg := config.Config{
+		Languages:      map[string]string{"python": "python:3.9-slim", "go": "golang:1.24-alpine"},
+		MinTimeoutMS:   100,
+		MaxTimeoutMS:   1000,
+		MaxSourceBytes: 8,
+		AuditImages:    map[string]string{"python": "python-strace:3.9"},
+	}
+
+	tests := []struct {
+		name  string
+		req   api.ExecutionRequest
+		field string
+	}{
+		{name: "valid", req: api.ExecutionRequest{Language: "python", SourceCode: "print(1)"}},
+		{name: "default timeout", req: api.ExecutionRequest{Language: "go", SourceCode: "x", TimeoutMS: 0}},
+		{name: "unknown language", req: api.ExecutionRequest{Language: "ruby", SourceCode: "x"}, field: "language"},
+		{name: "timeout below", req: api.ExecutionRequest{Language: "go", SourceCode: "x", TimeoutMS: 50}, field: "timeout_ms"},
+		{name: "timeout above", req: api.ExecutionRequest{Language: "go", SourceCode: "x", TimeoutMS: 5000}, field: "timeout_ms"},
+		{name: "source too large", req: api.ExecutionRequest{Language: "go", SourceCode: "123456789"}, field: "source_code"},
+		{name: "file too large", req: api.ExecutionRequest{Language: "go", SourceCode: "x", Files: map[string]string{"a.txt": "123456789"}}, field: "files.a.txt"},
+		{name: "mode", req: api.ExecutionRequest{Language: "go", SourceCode: "x", Mode: "bench"}, field: "mode"},
+		{name: "audit", req: api.ExecutionRequest{Language: "python", SourceCode: "x", Audit: true}},
+		{name: "audit without image", req: api.ExecutionRequest{Language: "go", SourceCode: "x", Audit: true}, field: "audit"},
+		{name: "audit in test mode", req: api.ExecutionRequest{Language: "python", SourceCode: "x", Mode: api.ExecutionModeTest, Audit: true}, field: "audit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExecutionRequest(tt.req, cfg)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("ExecutionRequest() error = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *Error
+			if !errors.As(err, &invalid) || len(invalid.Fields) != 1 || invalid.Fields[0].Field != tt.field {
+				t.Fatalf("ExecutionRequest() error = %v, want only %s invalid", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestExecutionRequestListsSupportedLanguages(t *testing.T) {
+	err := ExecutionRequest(api.ExecutionRequest{Language: "ruby", SourceCode: "x"}, config.Config{
+		Languages: map[string]string{"python": "p", "go": "g"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "supported: go, python") {
+		t.Fatalf("error = %v, want sorted supported languages", err)
+	}
+}
+
+func TestBenchmarkSelectionRejectsBadPatternsAndLimit(t *testing.T) {
+	err := BenchmarkSelection(benchmark.Selection{
+		Tasks:  benchmark.Selector{Include: []string{"csv-*"}},
+		Models: benchmark.Selector{Exclude: []string{"ok", "gpt-["}},
+		Limit:  -1,
+	})
+	var invalid *Error
+	if !errors.As(err, &invalid) {
+		t.Fatalf("BenchmarkSelection() error = %v, want *Error", err)
+	}
+	var fields []string
+	for _, field := range invalid.Fields {
+		fields = append(fields, field.Field)
+	}
+	if strings.Join(fields, ",") != "models.exclude[1],limit" {
+		t.Fatalf("invalid fields = %v, want models.exclude[1] and limit", fields)
+	}
+}